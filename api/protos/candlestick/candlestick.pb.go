@@ -22,6 +22,211 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type Series int32
+
+const (
+	Series_CANDLES     Series = 0
+	Series_HEIKIN_ASHI Series = 1
+	Series_RENKO       Series = 2
+)
+
+// Enum value maps for Series.
+var (
+	Series_name = map[int32]string{
+		0: "CANDLES",
+		1: "HEIKIN_ASHI",
+		2: "RENKO",
+	}
+	Series_value = map[string]int32{
+		"CANDLES":     0,
+		"HEIKIN_ASHI": 1,
+		"RENKO":       2,
+	}
+)
+
+func (x Series) Enum() *Series {
+	p := new(Series)
+	*p = x
+	return p
+}
+
+func (x Series) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Series) Descriptor() protoreflect.EnumDescriptor {
+	return file_candlestick_proto_enumTypes[0].Descriptor()
+}
+
+func (Series) Type() protoreflect.EnumType {
+	return &file_candlestick_proto_enumTypes[0]
+}
+
+func (x Series) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Series.Descriptor instead.
+func (Series) EnumDescriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{0}
+}
+
+type Candlestick_BarType int32
+
+const (
+	Candlestick_TIME   Candlestick_BarType = 0
+	Candlestick_TICK   Candlestick_BarType = 1
+	Candlestick_VOLUME Candlestick_BarType = 2
+	Candlestick_DOLLAR Candlestick_BarType = 3
+	Candlestick_RANGE  Candlestick_BarType = 4
+)
+
+// Enum value maps for Candlestick_BarType.
+var (
+	Candlestick_BarType_name = map[int32]string{
+		0: "TIME",
+		1: "TICK",
+		2: "VOLUME",
+		3: "DOLLAR",
+		4: "RANGE",
+	}
+	Candlestick_BarType_value = map[string]int32{
+		"TIME":   0,
+		"TICK":   1,
+		"VOLUME": 2,
+		"DOLLAR": 3,
+		"RANGE":  4,
+	}
+)
+
+func (x Candlestick_BarType) Enum() *Candlestick_BarType {
+	p := new(Candlestick_BarType)
+	*p = x
+	return p
+}
+
+func (x Candlestick_BarType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Candlestick_BarType) Descriptor() protoreflect.EnumDescriptor {
+	return file_candlestick_proto_enumTypes[1].Descriptor()
+}
+
+func (Candlestick_BarType) Type() protoreflect.EnumType {
+	return &file_candlestick_proto_enumTypes[1]
+}
+
+func (x Candlestick_BarType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Candlestick_BarType.Descriptor instead.
+func (Candlestick_BarType) EnumDescriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{3, 0}
+}
+
+type Alert_Kind int32
+
+const (
+	Alert_KIND_UNSPECIFIED Alert_Kind = 0
+	Alert_PRICE_CROSS      Alert_Kind = 1
+	Alert_CANDLE_RANGE     Alert_Kind = 2
+	Alert_VOLUME_SPIKE     Alert_Kind = 3
+)
+
+// Enum value maps for Alert_Kind.
+var (
+	Alert_Kind_name = map[int32]string{
+		0: "KIND_UNSPECIFIED",
+		1: "PRICE_CROSS",
+		2: "CANDLE_RANGE",
+		3: "VOLUME_SPIKE",
+	}
+	Alert_Kind_value = map[string]int32{
+		"KIND_UNSPECIFIED": 0,
+		"PRICE_CROSS":      1,
+		"CANDLE_RANGE":     2,
+		"VOLUME_SPIKE":     3,
+	}
+)
+
+func (x Alert_Kind) Enum() *Alert_Kind {
+	p := new(Alert_Kind)
+	*p = x
+	return p
+}
+
+func (x Alert_Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Alert_Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_candlestick_proto_enumTypes[2].Descriptor()
+}
+
+func (Alert_Kind) Type() protoreflect.EnumType {
+	return &file_candlestick_proto_enumTypes[2]
+}
+
+func (x Alert_Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Alert_Kind.Descriptor instead.
+func (Alert_Kind) EnumDescriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{9, 0}
+}
+
+type Alert_Direction int32
+
+const (
+	Alert_ANY  Alert_Direction = 0
+	Alert_UP   Alert_Direction = 1
+	Alert_DOWN Alert_Direction = 2
+)
+
+// Enum value maps for Alert_Direction.
+var (
+	Alert_Direction_name = map[int32]string{
+		0: "ANY",
+		1: "UP",
+		2: "DOWN",
+	}
+	Alert_Direction_value = map[string]int32{
+		"ANY":  0,
+		"UP":   1,
+		"DOWN": 2,
+	}
+)
+
+func (x Alert_Direction) Enum() *Alert_Direction {
+	p := new(Alert_Direction)
+	*p = x
+	return p
+}
+
+func (x Alert_Direction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Alert_Direction) Descriptor() protoreflect.EnumDescriptor {
+	return file_candlestick_proto_enumTypes[3].Descriptor()
+}
+
+func (Alert_Direction) Type() protoreflect.EnumType {
+	return &file_candlestick_proto_enumTypes[3]
+}
+
+func (x Alert_Direction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Alert_Direction.Descriptor instead.
+func (Alert_Direction) EnumDescriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{9, 1}
+}
+
 type HealthResponse_Status int32
 
 const (
@@ -55,11 +260,11 @@ func (x HealthResponse_Status) String() string {
 }
 
 func (HealthResponse_Status) Descriptor() protoreflect.EnumDescriptor {
-	return file_candlestick_proto_enumTypes[0].Descriptor()
+	return file_candlestick_proto_enumTypes[4].Descriptor()
 }
 
 func (HealthResponse_Status) Type() protoreflect.EnumType {
-	return &file_candlestick_proto_enumTypes[0]
+	return &file_candlestick_proto_enumTypes[4]
 }
 
 func (x HealthResponse_Status) Number() protoreflect.EnumNumber {
@@ -68,12 +273,81 @@ func (x HealthResponse_Status) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use HealthResponse_Status.Descriptor instead.
 func (HealthResponse_Status) EnumDescriptor() ([]byte, []int) {
-	return file_candlestick_proto_rawDescGZIP(), []int{3, 0}
+	return file_candlestick_proto_rawDescGZIP(), []int{19, 0}
+}
+
+type FeedStatus_State int32
+
+const (
+	FeedStatus_UNKNOWN    FeedStatus_State = 0
+	FeedStatus_CONNECTING FeedStatus_State = 1
+	FeedStatus_LIVE       FeedStatus_State = 2
+	FeedStatus_BACKOFF    FeedStatus_State = 3
+	FeedStatus_STOPPED    FeedStatus_State = 4
+)
+
+// Enum value maps for FeedStatus_State.
+var (
+	FeedStatus_State_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "CONNECTING",
+		2: "LIVE",
+		3: "BACKOFF",
+		4: "STOPPED",
+	}
+	FeedStatus_State_value = map[string]int32{
+		"UNKNOWN":    0,
+		"CONNECTING": 1,
+		"LIVE":       2,
+		"BACKOFF":    3,
+		"STOPPED":    4,
+	}
+)
+
+func (x FeedStatus_State) Enum() *FeedStatus_State {
+	p := new(FeedStatus_State)
+	*p = x
+	return p
+}
+
+func (x FeedStatus_State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FeedStatus_State) Descriptor() protoreflect.EnumDescriptor {
+	return file_candlestick_proto_enumTypes[5].Descriptor()
+}
+
+func (FeedStatus_State) Type() protoreflect.EnumType {
+	return &file_candlestick_proto_enumTypes[5]
+}
+
+func (x FeedStatus_State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FeedStatus_State.Descriptor instead.
+func (FeedStatus_State) EnumDescriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{22, 0}
 }
 
 type StreamRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Symbols       []string               `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Instruments as "exchange:symbol", e.g. "coinbase:BTC-USD". A bare symbol
+	// refers to Binance.
+	Symbols []string `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	// Candle interval label such as "1s", "1m", "4h" or "1d", or an
+	// information driven bar such as "tick:1000", "volume:50",
+	// "dollar:1000000" or "range:25". Defaults to "1m".
+	Interval string `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	// Minimum time between updates sent on the stream. 0 sends every change.
+	ThrottleMs int64 `protobuf:"varint,3,opt,name=throttle_ms,json=throttleMs,proto3" json:"throttle_ms,omitempty"`
+	// When set (Unix milliseconds), finalized candles that started at or after
+	// this time are replayed before live updates begin.
+	Since int64 `protobuf:"varint,4,opt,name=since,proto3" json:"since,omitempty"`
+	// Which series to stream. Heikin-Ashi candles use interval like regular
+	// candles; Renko bricks ignore it.
+	Series        Series `protobuf:"varint,5,opt,name=series,proto3,enum=candlestick.Series" json:"series,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -115,35 +389,64 @@ func (x *StreamRequest) GetSymbols() []string {
 	return nil
 }
 
-type Candlestick struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
-	Open          float64                `protobuf:"fixed64,2,opt,name=open,proto3" json:"open,omitempty"`
-	High          float64                `protobuf:"fixed64,3,opt,name=high,proto3" json:"high,omitempty"`
-	Low           float64                `protobuf:"fixed64,4,opt,name=low,proto3" json:"low,omitempty"`
-	Close         float64                `protobuf:"fixed64,5,opt,name=close,proto3" json:"close,omitempty"`
-	Volume        float64                `protobuf:"fixed64,6,opt,name=volume,proto3" json:"volume,omitempty"`
-	StartTime     int64                  `protobuf:"varint,7,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
-	EndTime       int64                  `protobuf:"varint,8,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
-	IsFinal       bool                   `protobuf:"varint,9,opt,name=is_final,json=isFinal,proto3" json:"is_final,omitempty"`
+func (x *StreamRequest) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *StreamRequest) GetThrottleMs() int64 {
+	if x != nil {
+		return x.ThrottleMs
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetSince() int64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetSeries() Series {
+	if x != nil {
+		return x.Series
+	}
+	return Series_CANDLES
+}
+
+// GetCandlesRequest reads finalized candles in [from, to), oldest first.
+// Times are Unix milliseconds; to = 0 means now.
+type GetCandlesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Instrument as "exchange:symbol"; a bare symbol refers to Binance.
+	Symbol   string `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Interval string `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	From     int64  `protobuf:"varint,3,opt,name=from,proto3" json:"from,omitempty"`
+	To       int64  `protobuf:"varint,4,opt,name=to,proto3" json:"to,omitempty"`
+	Limit    int32  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Cursor returned as next_page_token by a previous call.
+	PageToken     string `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Candlestick) Reset() {
-	*x = Candlestick{}
+func (x *GetCandlesRequest) Reset() {
+	*x = GetCandlesRequest{}
 	mi := &file_candlestick_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Candlestick) String() string {
+func (x *GetCandlesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Candlestick) ProtoMessage() {}
+func (*GetCandlesRequest) ProtoMessage() {}
 
-func (x *Candlestick) ProtoReflect() protoreflect.Message {
+func (x *GetCandlesRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_candlestick_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -155,94 +458,76 @@ func (x *Candlestick) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Candlestick.ProtoReflect.Descriptor instead.
-func (*Candlestick) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetCandlesRequest.ProtoReflect.Descriptor instead.
+func (*GetCandlesRequest) Descriptor() ([]byte, []int) {
 	return file_candlestick_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *Candlestick) GetSymbol() string {
+func (x *GetCandlesRequest) GetSymbol() string {
 	if x != nil {
 		return x.Symbol
 	}
 	return ""
 }
 
-func (x *Candlestick) GetOpen() float64 {
-	if x != nil {
-		return x.Open
-	}
-	return 0
-}
-
-func (x *Candlestick) GetHigh() float64 {
-	if x != nil {
-		return x.High
-	}
-	return 0
-}
-
-func (x *Candlestick) GetLow() float64 {
-	if x != nil {
-		return x.Low
-	}
-	return 0
-}
-
-func (x *Candlestick) GetClose() float64 {
+func (x *GetCandlesRequest) GetInterval() string {
 	if x != nil {
-		return x.Close
+		return x.Interval
 	}
-	return 0
+	return ""
 }
 
-func (x *Candlestick) GetVolume() float64 {
+func (x *GetCandlesRequest) GetFrom() int64 {
 	if x != nil {
-		return x.Volume
+		return x.From
 	}
 	return 0
 }
 
-func (x *Candlestick) GetStartTime() int64 {
+func (x *GetCandlesRequest) GetTo() int64 {
 	if x != nil {
-		return x.StartTime
+		return x.To
 	}
 	return 0
 }
 
-func (x *Candlestick) GetEndTime() int64 {
+func (x *GetCandlesRequest) GetLimit() int32 {
 	if x != nil {
-		return x.EndTime
+		return x.Limit
 	}
 	return 0
 }
 
-func (x *Candlestick) GetIsFinal() bool {
+func (x *GetCandlesRequest) GetPageToken() string {
 	if x != nil {
-		return x.IsFinal
+		return x.PageToken
 	}
-	return false
+	return ""
 }
 
-type HealthRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+type GetCandlesResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Candles []*Candlestick         `protobuf:"bytes,1,rep,name=candles,proto3" json:"candles,omitempty"`
+	// Empty when there are no more candles in the range.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthRequest) Reset() {
-	*x = HealthRequest{}
+func (x *GetCandlesResponse) Reset() {
+	*x = GetCandlesResponse{}
 	mi := &file_candlestick_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthRequest) String() string {
+func (x *GetCandlesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthRequest) ProtoMessage() {}
+func (*GetCandlesResponse) ProtoMessage() {}
 
-func (x *HealthRequest) ProtoReflect() protoreflect.Message {
+func (x *GetCandlesResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_candlestick_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -254,34 +539,77 @@ func (x *HealthRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
-func (*HealthRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetCandlesResponse.ProtoReflect.Descriptor instead.
+func (*GetCandlesResponse) Descriptor() ([]byte, []int) {
 	return file_candlestick_proto_rawDescGZIP(), []int{2}
 }
 
-type HealthResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        HealthResponse_Status  `protobuf:"varint,1,opt,name=status,proto3,enum=candlestick.HealthResponse_Status" json:"status,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	LastDataMs    int64                  `protobuf:"varint,3,opt,name=last_data_ms,json=lastDataMs,proto3" json:"last_data_ms,omitempty"`
+func (x *GetCandlesResponse) GetCandles() []*Candlestick {
+	if x != nil {
+		return x.Candles
+	}
+	return nil
+}
+
+func (x *GetCandlesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type Candlestick struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Symbol string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// Exact decimal strings as traded, e.g. "0.00001234".
+	Open      string `protobuf:"bytes,12,opt,name=open,proto3" json:"open,omitempty"`
+	High      string `protobuf:"bytes,13,opt,name=high,proto3" json:"high,omitempty"`
+	Low       string `protobuf:"bytes,14,opt,name=low,proto3" json:"low,omitempty"`
+	Close     string `protobuf:"bytes,15,opt,name=close,proto3" json:"close,omitempty"`
+	Volume    string `protobuf:"bytes,16,opt,name=volume,proto3" json:"volume,omitempty"`
+	StartTime int64  `protobuf:"varint,7,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime   int64  `protobuf:"varint,8,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	IsFinal   bool   `protobuf:"varint,9,opt,name=is_final,json=isFinal,proto3" json:"is_final,omitempty"`
+	Interval  string `protobuf:"bytes,10,opt,name=interval,proto3" json:"interval,omitempty"`
+	Exchange  string `protobuf:"bytes,11,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	// Volume weighted average price, quote_volume / volume.
+	Vwap        string `protobuf:"bytes,17,opt,name=vwap,proto3" json:"vwap,omitempty"`
+	QuoteVolume string `protobuf:"bytes,18,opt,name=quote_volume,json=quoteVolume,proto3" json:"quote_volume,omitempty"`
+	TradeCount  int64  `protobuf:"varint,19,opt,name=trade_count,json=tradeCount,proto3" json:"trade_count,omitempty"`
+	// Volume split by the side that crossed the spread.
+	TakerBuyVolume  string `protobuf:"bytes,20,opt,name=taker_buy_volume,json=takerBuyVolume,proto3" json:"taker_buy_volume,omitempty"`
+	TakerSellVolume string `protobuf:"bytes,21,opt,name=taker_sell_volume,json=takerSellVolume,proto3" json:"taker_sell_volume,omitempty"`
+	// Bumped each time a finalized candle is corrected, e.g. by a late tick.
+	Revision int32 `protobuf:"varint,22,opt,name=revision,proto3" json:"revision,omitempty"`
+	// Flat gap fill bar for an interval without trades: OHLC repeat the
+	// previous close and volume is zero.
+	Synthetic bool `protobuf:"varint,23,opt,name=synthetic,proto3" json:"synthetic,omitempty"`
+	// What closes the bar. Bars other than TIME run from their first trade
+	// to their last and carry their spec, e.g. "volume:50", as interval.
+	BarType Candlestick_BarType `protobuf:"varint,24,opt,name=bar_type,json=barType,proto3,enum=candlestick.Candlestick_BarType" json:"bar_type,omitempty"`
+	// The series the candle belongs to; Renko bricks have interval "renko".
+	Series Series `protobuf:"varint,25,opt,name=series,proto3,enum=candlestick.Series" json:"series,omitempty"`
+	// When storage last wrote the bar, in Unix milliseconds; zero for
+	// candles that have not been stored yet, e.g. on the live stream.
+	UpdatedAt     int64 `protobuf:"varint,26,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthResponse) Reset() {
-	*x = HealthResponse{}
+func (x *Candlestick) Reset() {
+	*x = Candlestick{}
 	mi := &file_candlestick_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthResponse) String() string {
+func (x *Candlestick) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthResponse) ProtoMessage() {}
+func (*Candlestick) ProtoMessage() {}
 
-func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+func (x *Candlestick) ProtoReflect() protoreflect.Message {
 	mi := &file_candlestick_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -293,50 +621,1758 @@ func (x *HealthResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
-func (*HealthResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use Candlestick.ProtoReflect.Descriptor instead.
+func (*Candlestick) Descriptor() ([]byte, []int) {
 	return file_candlestick_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *HealthResponse) GetStatus() HealthResponse_Status {
+func (x *Candlestick) GetSymbol() string {
 	if x != nil {
-		return x.Status
+		return x.Symbol
 	}
-	return HealthResponse_UNKNOWN
+	return ""
 }
 
-func (x *HealthResponse) GetMessage() string {
+func (x *Candlestick) GetOpen() string {
 	if x != nil {
-		return x.Message
+		return x.Open
 	}
 	return ""
 }
 
-func (x *HealthResponse) GetLastDataMs() int64 {
+func (x *Candlestick) GetHigh() string {
 	if x != nil {
-		return x.LastDataMs
+		return x.High
 	}
-	return 0
+	return ""
 }
 
-var File_candlestick_proto protoreflect.FileDescriptor
+func (x *Candlestick) GetLow() string {
+	if x != nil {
+		return x.Low
+	}
+	return ""
+}
 
-const file_candlestick_proto_rawDesc = "" +
-	"\n" +
-	"\x11candlestick.proto\x12\vcandlestick\")\n" +
-	"\rStreamRequest\x12\x18\n" +
-	"\asymbols\x18\x01 \x03(\tR\asymbols\"\xe2\x01\n" +
-	"\vCandlestick\x12\x16\n" +
+func (x *Candlestick) GetClose() string {
+	if x != nil {
+		return x.Close
+	}
+	return ""
+}
+
+func (x *Candlestick) GetVolume() string {
+	if x != nil {
+		return x.Volume
+	}
+	return ""
+}
+
+func (x *Candlestick) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *Candlestick) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *Candlestick) GetIsFinal() bool {
+	if x != nil {
+		return x.IsFinal
+	}
+	return false
+}
+
+func (x *Candlestick) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *Candlestick) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+func (x *Candlestick) GetVwap() string {
+	if x != nil {
+		return x.Vwap
+	}
+	return ""
+}
+
+func (x *Candlestick) GetQuoteVolume() string {
+	if x != nil {
+		return x.QuoteVolume
+	}
+	return ""
+}
+
+func (x *Candlestick) GetTradeCount() int64 {
+	if x != nil {
+		return x.TradeCount
+	}
+	return 0
+}
+
+func (x *Candlestick) GetTakerBuyVolume() string {
+	if x != nil {
+		return x.TakerBuyVolume
+	}
+	return ""
+}
+
+func (x *Candlestick) GetTakerSellVolume() string {
+	if x != nil {
+		return x.TakerSellVolume
+	}
+	return ""
+}
+
+func (x *Candlestick) GetRevision() int32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *Candlestick) GetSynthetic() bool {
+	if x != nil {
+		return x.Synthetic
+	}
+	return false
+}
+
+func (x *Candlestick) GetBarType() Candlestick_BarType {
+	if x != nil {
+		return x.BarType
+	}
+	return Candlestick_TIME
+}
+
+func (x *Candlestick) GetSeries() Series {
+	if x != nil {
+		return x.Series
+	}
+	return Series_CANDLES
+}
+
+func (x *Candlestick) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type IndicatorRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Instruments as "exchange:symbol"; a bare symbol refers to Binance.
+	Symbols []string `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	// Candle interval label. Defaults to "1m".
+	Interval string `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	// Indicator specs such as "rsi:14" or "macd:12:26:9". Empty streams
+	// every indicator configured for the symbols and interval.
+	Indicators []string `protobuf:"bytes,3,rep,name=indicators,proto3" json:"indicators,omitempty"`
+	// When set (Unix milliseconds), stored values for candles that started at
+	// or after this time are replayed before live updates begin.
+	Since         int64 `protobuf:"varint,4,opt,name=since,proto3" json:"since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IndicatorRequest) Reset() {
+	*x = IndicatorRequest{}
+	mi := &file_candlestick_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndicatorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndicatorRequest) ProtoMessage() {}
+
+func (x *IndicatorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndicatorRequest.ProtoReflect.Descriptor instead.
+func (*IndicatorRequest) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *IndicatorRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+func (x *IndicatorRequest) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *IndicatorRequest) GetIndicators() []string {
+	if x != nil {
+		return x.Indicators
+	}
+	return nil
+}
+
+func (x *IndicatorRequest) GetSince() int64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+// IndicatorValue holds one indicator's outputs for one finalized candle,
+// e.g. {"value": 61.2} for RSI or {"macd", "signal", "histogram"} for MACD.
+type IndicatorValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exchange      string                 `protobuf:"bytes,1,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	Symbol        string                 `protobuf:"bytes,2,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Interval      string                 `protobuf:"bytes,3,opt,name=interval,proto3" json:"interval,omitempty"`
+	Indicator     string                 `protobuf:"bytes,4,opt,name=indicator,proto3" json:"indicator,omitempty"`
+	StartTime     int64                  `protobuf:"varint,5,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       int64                  `protobuf:"varint,6,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	Values        map[string]float64     `protobuf:"bytes,7,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IndicatorValue) Reset() {
+	*x = IndicatorValue{}
+	mi := &file_candlestick_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndicatorValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndicatorValue) ProtoMessage() {}
+
+func (x *IndicatorValue) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndicatorValue.ProtoReflect.Descriptor instead.
+func (*IndicatorValue) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *IndicatorValue) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+func (x *IndicatorValue) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *IndicatorValue) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *IndicatorValue) GetIndicator() string {
+	if x != nil {
+		return x.Indicator
+	}
+	return ""
+}
+
+func (x *IndicatorValue) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *IndicatorValue) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *IndicatorValue) GetValues() map[string]float64 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type OrderBookRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Instrument as "exchange:symbol"; a bare symbol refers to Binance.
+	Symbol string `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// Levels per side. Defaults to 10; a negative depth returns the whole
+	// book.
+	Depth int32 `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"`
+	// StreamOrderBook only: how often to send the book if it changed.
+	// Defaults to 100.
+	IntervalMs    int64 `protobuf:"varint,3,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderBookRequest) Reset() {
+	*x = OrderBookRequest{}
+	mi := &file_candlestick_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderBookRequest) ProtoMessage() {}
+
+func (x *OrderBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderBookRequest.ProtoReflect.Descriptor instead.
+func (*OrderBookRequest) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *OrderBookRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *OrderBookRequest) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *OrderBookRequest) GetIntervalMs() int64 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
+type PriceLevel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Price         string                 `protobuf:"bytes,1,opt,name=price,proto3" json:"price,omitempty"`
+	Quantity      string                 `protobuf:"bytes,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PriceLevel) Reset() {
+	*x = PriceLevel{}
+	mi := &file_candlestick_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PriceLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PriceLevel) ProtoMessage() {}
+
+func (x *PriceLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PriceLevel.ProtoReflect.Descriptor instead.
+func (*PriceLevel) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PriceLevel) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *PriceLevel) GetQuantity() string {
+	if x != nil {
+		return x.Quantity
+	}
+	return ""
+}
+
+type OrderBook struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Exchange string                 `protobuf:"bytes,1,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	Symbol   string                 `protobuf:"bytes,2,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// Exchange sequence number of the last update applied.
+	LastUpdateId int64 `protobuf:"varint,3,opt,name=last_update_id,json=lastUpdateId,proto3" json:"last_update_id,omitempty"`
+	// Unix milliseconds of the last update.
+	Time    int64       `protobuf:"varint,4,opt,name=time,proto3" json:"time,omitempty"`
+	BestBid *PriceLevel `protobuf:"bytes,5,opt,name=best_bid,json=bestBid,proto3" json:"best_bid,omitempty"`
+	BestAsk *PriceLevel `protobuf:"bytes,6,opt,name=best_ask,json=bestAsk,proto3" json:"best_ask,omitempty"`
+	// best_ask - best_bid; empty when a side is empty.
+	Spread string `protobuf:"bytes,7,opt,name=spread,proto3" json:"spread,omitempty"`
+	// Highest bid and lowest ask first.
+	Bids          []*PriceLevel `protobuf:"bytes,8,rep,name=bids,proto3" json:"bids,omitempty"`
+	Asks          []*PriceLevel `protobuf:"bytes,9,rep,name=asks,proto3" json:"asks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderBook) Reset() {
+	*x = OrderBook{}
+	mi := &file_candlestick_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderBook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderBook) ProtoMessage() {}
+
+func (x *OrderBook) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderBook.ProtoReflect.Descriptor instead.
+func (*OrderBook) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *OrderBook) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+func (x *OrderBook) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *OrderBook) GetLastUpdateId() int64 {
+	if x != nil {
+		return x.LastUpdateId
+	}
+	return 0
+}
+
+func (x *OrderBook) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *OrderBook) GetBestBid() *PriceLevel {
+	if x != nil {
+		return x.BestBid
+	}
+	return nil
+}
+
+func (x *OrderBook) GetBestAsk() *PriceLevel {
+	if x != nil {
+		return x.BestAsk
+	}
+	return nil
+}
+
+func (x *OrderBook) GetSpread() string {
+	if x != nil {
+		return x.Spread
+	}
+	return ""
+}
+
+func (x *OrderBook) GetBids() []*PriceLevel {
+	if x != nil {
+		return x.Bids
+	}
+	return nil
+}
+
+func (x *OrderBook) GetAsks() []*PriceLevel {
+	if x != nil {
+		return x.Asks
+	}
+	return nil
+}
+
+// Alert is a rule checked against live trading. PRICE_CROSS fires when a
+// trade crosses threshold; CANDLE_RANGE when a finalized candle's high - low
+// exceeds threshold percent of its open; VOLUME_SPIKE when a finalized
+// candle's volume is at least threshold times the average of the previous
+// lookback candles.
+type Alert struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Assigned by CreateAlert.
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Instrument as "exchange:symbol"; a bare symbol refers to Binance.
+	Symbol string     `protobuf:"bytes,2,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Kind   Alert_Kind `protobuf:"varint,3,opt,name=kind,proto3,enum=candlestick.Alert_Kind" json:"kind,omitempty"`
+	// Candle interval label for CANDLE_RANGE and VOLUME_SPIKE.
+	Interval string `protobuf:"bytes,4,opt,name=interval,proto3" json:"interval,omitempty"`
+	// Exact decimal string: a price, a percentage or a multiple.
+	Threshold string `protobuf:"bytes,5,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	// Which crossings of a PRICE_CROSS alert fire.
+	Direction Alert_Direction `protobuf:"varint,6,opt,name=direction,proto3,enum=candlestick.Alert_Direction" json:"direction,omitempty"`
+	// Candles averaged by VOLUME_SPIKE. Defaults to 20.
+	Lookback int32 `protobuf:"varint,7,opt,name=lookback,proto3" json:"lookback,omitempty"`
+	// Minimum time between two triggers. 0 uses the server default.
+	CooldownMs int64 `protobuf:"varint,8,opt,name=cooldown_ms,json=cooldownMs,proto3" json:"cooldown_ms,omitempty"`
+	// When set, triggers are also POSTed to this URL as JSON.
+	WebhookUrl string `protobuf:"bytes,9,opt,name=webhook_url,json=webhookUrl,proto3" json:"webhook_url,omitempty"`
+	CreatedAt  int64  `protobuf:"varint,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Unix milliseconds of the last trigger, 0 if it never fired.
+	LastTriggeredAt int64 `protobuf:"varint,11,opt,name=last_triggered_at,json=lastTriggeredAt,proto3" json:"last_triggered_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Alert) Reset() {
+	*x = Alert{}
+	mi := &file_candlestick_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Alert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Alert) ProtoMessage() {}
+
+func (x *Alert) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Alert.ProtoReflect.Descriptor instead.
+func (*Alert) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Alert) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Alert) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Alert) GetKind() Alert_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return Alert_KIND_UNSPECIFIED
+}
+
+func (x *Alert) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *Alert) GetThreshold() string {
+	if x != nil {
+		return x.Threshold
+	}
+	return ""
+}
+
+func (x *Alert) GetDirection() Alert_Direction {
+	if x != nil {
+		return x.Direction
+	}
+	return Alert_ANY
+}
+
+func (x *Alert) GetLookback() int32 {
+	if x != nil {
+		return x.Lookback
+	}
+	return 0
+}
+
+func (x *Alert) GetCooldownMs() int64 {
+	if x != nil {
+		return x.CooldownMs
+	}
+	return 0
+}
+
+func (x *Alert) GetWebhookUrl() string {
+	if x != nil {
+		return x.WebhookUrl
+	}
+	return ""
+}
+
+func (x *Alert) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Alert) GetLastTriggeredAt() int64 {
+	if x != nil {
+		return x.LastTriggeredAt
+	}
+	return 0
+}
+
+type CreateAlertRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alert         *Alert                 `protobuf:"bytes,1,opt,name=alert,proto3" json:"alert,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAlertRequest) Reset() {
+	*x = CreateAlertRequest{}
+	mi := &file_candlestick_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAlertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAlertRequest) ProtoMessage() {}
+
+func (x *CreateAlertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAlertRequest.ProtoReflect.Descriptor instead.
+func (*CreateAlertRequest) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CreateAlertRequest) GetAlert() *Alert {
+	if x != nil {
+		return x.Alert
+	}
+	return nil
+}
+
+// UpdateAlertRequest replaces the rule of alert.id.
+type UpdateAlertRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alert         *Alert                 `protobuf:"bytes,1,opt,name=alert,proto3" json:"alert,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateAlertRequest) Reset() {
+	*x = UpdateAlertRequest{}
+	mi := &file_candlestick_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateAlertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateAlertRequest) ProtoMessage() {}
+
+func (x *UpdateAlertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateAlertRequest.ProtoReflect.Descriptor instead.
+func (*UpdateAlertRequest) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UpdateAlertRequest) GetAlert() *Alert {
+	if x != nil {
+		return x.Alert
+	}
+	return nil
+}
+
+type DeleteAlertRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAlertRequest) Reset() {
+	*x = DeleteAlertRequest{}
+	mi := &file_candlestick_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAlertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAlertRequest) ProtoMessage() {}
+
+func (x *DeleteAlertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAlertRequest.ProtoReflect.Descriptor instead.
+func (*DeleteAlertRequest) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DeleteAlertRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteAlertResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAlertResponse) Reset() {
+	*x = DeleteAlertResponse{}
+	mi := &file_candlestick_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAlertResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAlertResponse) ProtoMessage() {}
+
+func (x *DeleteAlertResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAlertResponse.ProtoReflect.Descriptor instead.
+func (*DeleteAlertResponse) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{13}
+}
+
+type ListAlertsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only alerts for these instruments; empty lists all.
+	Symbols       []string `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAlertsRequest) Reset() {
+	*x = ListAlertsRequest{}
+	mi := &file_candlestick_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAlertsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAlertsRequest) ProtoMessage() {}
+
+func (x *ListAlertsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAlertsRequest.ProtoReflect.Descriptor instead.
+func (*ListAlertsRequest) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListAlertsRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+type ListAlertsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alerts        []*Alert               `protobuf:"bytes,1,rep,name=alerts,proto3" json:"alerts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAlertsResponse) Reset() {
+	*x = ListAlertsResponse{}
+	mi := &file_candlestick_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAlertsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAlertsResponse) ProtoMessage() {}
+
+func (x *ListAlertsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAlertsResponse.ProtoReflect.Descriptor instead.
+func (*ListAlertsResponse) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListAlertsResponse) GetAlerts() []*Alert {
+	if x != nil {
+		return x.Alerts
+	}
+	return nil
+}
+
+type StreamAlertsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only triggers of these alerts; empty streams all.
+	AlertIds      []string `protobuf:"bytes,1,rep,name=alert_ids,json=alertIds,proto3" json:"alert_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamAlertsRequest) Reset() {
+	*x = StreamAlertsRequest{}
+	mi := &file_candlestick_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamAlertsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamAlertsRequest) ProtoMessage() {}
+
+func (x *StreamAlertsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamAlertsRequest.ProtoReflect.Descriptor instead.
+func (*StreamAlertsRequest) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *StreamAlertsRequest) GetAlertIds() []string {
+	if x != nil {
+		return x.AlertIds
+	}
+	return nil
+}
+
+type AlertEvent struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	AlertId  string                 `protobuf:"bytes,2,opt,name=alert_id,json=alertId,proto3" json:"alert_id,omitempty"`
+	Exchange string                 `protobuf:"bytes,3,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	Symbol   string                 `protobuf:"bytes,4,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Kind     Alert_Kind             `protobuf:"varint,5,opt,name=kind,proto3,enum=candlestick.Alert_Kind" json:"kind,omitempty"`
+	// The price, range percentage or volume multiple that fired the alert.
+	Value   string `protobuf:"bytes,6,opt,name=value,proto3" json:"value,omitempty"`
+	Message string `protobuf:"bytes,7,opt,name=message,proto3" json:"message,omitempty"`
+	// Event time of the trade or candle end, Unix milliseconds.
+	TriggeredAt   int64 `protobuf:"varint,8,opt,name=triggered_at,json=triggeredAt,proto3" json:"triggered_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AlertEvent) Reset() {
+	*x = AlertEvent{}
+	mi := &file_candlestick_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AlertEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AlertEvent) ProtoMessage() {}
+
+func (x *AlertEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AlertEvent.ProtoReflect.Descriptor instead.
+func (*AlertEvent) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AlertEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AlertEvent) GetAlertId() string {
+	if x != nil {
+		return x.AlertId
+	}
+	return ""
+}
+
+func (x *AlertEvent) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+func (x *AlertEvent) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *AlertEvent) GetKind() Alert_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return Alert_KIND_UNSPECIFIED
+}
+
+func (x *AlertEvent) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *AlertEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AlertEvent) GetTriggeredAt() int64 {
+	if x != nil {
+		return x.TriggeredAt
+	}
+	return 0
+}
+
+type HealthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthRequest) Reset() {
+	*x = HealthRequest{}
+	mi := &file_candlestick_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthRequest) ProtoMessage() {}
+
+func (x *HealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{18}
+}
+
+type HealthResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        HealthResponse_Status  `protobuf:"varint,1,opt,name=status,proto3,enum=candlestick.HealthResponse_Status" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	LastDataMs    int64                  `protobuf:"varint,3,opt,name=last_data_ms,json=lastDataMs,proto3" json:"last_data_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	mi := &file_candlestick_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthResponse) ProtoMessage() {}
+
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *HealthResponse) GetStatus() HealthResponse_Status {
+	if x != nil {
+		return x.Status
+	}
+	return HealthResponse_UNKNOWN
+}
+
+func (x *HealthResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetLastDataMs() int64 {
+	if x != nil {
+		return x.LastDataMs
+	}
+	return 0
+}
+
+type FeedStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FeedStatusRequest) Reset() {
+	*x = FeedStatusRequest{}
+	mi := &file_candlestick_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FeedStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FeedStatusRequest) ProtoMessage() {}
+
+func (x *FeedStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FeedStatusRequest.ProtoReflect.Descriptor instead.
+func (*FeedStatusRequest) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{20}
+}
+
+type FeedStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Feeds         []*FeedStatus          `protobuf:"bytes,1,rep,name=feeds,proto3" json:"feeds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FeedStatusResponse) Reset() {
+	*x = FeedStatusResponse{}
+	mi := &file_candlestick_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FeedStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FeedStatusResponse) ProtoMessage() {}
+
+func (x *FeedStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FeedStatusResponse.ProtoReflect.Descriptor instead.
+func (*FeedStatusResponse) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *FeedStatusResponse) GetFeeds() []*FeedStatus {
+	if x != nil {
+		return x.Feeds
+	}
+	return nil
+}
+
+// FeedStatus is the connection state of one instrument's exchange feed.
+type FeedStatus struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Exchange string                 `protobuf:"bytes,1,opt,name=exchange,proto3" json:"exchange,omitempty"`
+	Symbol   string                 `protobuf:"bytes,2,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	State    FeedStatus_State       `protobuf:"varint,3,opt,name=state,proto3,enum=candlestick.FeedStatus_State" json:"state,omitempty"`
+	// Unix milliseconds when the feed entered its current state.
+	SinceMs       int64  `protobuf:"varint,4,opt,name=since_ms,json=sinceMs,proto3" json:"since_ms,omitempty"`
+	LastError     string `protobuf:"bytes,5,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	Reconnects    int32  `protobuf:"varint,6,opt,name=reconnects,proto3" json:"reconnects,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FeedStatus) Reset() {
+	*x = FeedStatus{}
+	mi := &file_candlestick_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FeedStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FeedStatus) ProtoMessage() {}
+
+func (x *FeedStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FeedStatus.ProtoReflect.Descriptor instead.
+func (*FeedStatus) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *FeedStatus) GetExchange() string {
+	if x != nil {
+		return x.Exchange
+	}
+	return ""
+}
+
+func (x *FeedStatus) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *FeedStatus) GetState() FeedStatus_State {
+	if x != nil {
+		return x.State
+	}
+	return FeedStatus_UNKNOWN
+}
+
+func (x *FeedStatus) GetSinceMs() int64 {
+	if x != nil {
+		return x.SinceMs
+	}
+	return 0
+}
+
+func (x *FeedStatus) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *FeedStatus) GetReconnects() int32 {
+	if x != nil {
+		return x.Reconnects
+	}
+	return 0
+}
+
+type StorageStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StorageStatusRequest) Reset() {
+	*x = StorageStatusRequest{}
+	mi := &file_candlestick_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StorageStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StorageStatusRequest) ProtoMessage() {}
+
+func (x *StorageStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StorageStatusRequest.ProtoReflect.Descriptor instead.
+func (*StorageStatusRequest) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{23}
+}
+
+// StorageStatus describes the candlesticks hypertable and the chunk,
+// compression and retention policies applied to it. Durations are in
+// milliseconds and zero where no policy is set.
+type StorageStatus struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ChunkIntervalMs    int64                  `protobuf:"varint,1,opt,name=chunk_interval_ms,json=chunkIntervalMs,proto3" json:"chunk_interval_ms,omitempty"`
+	Chunks             int32                  `protobuf:"varint,2,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	CompressedChunks   int32                  `protobuf:"varint,3,opt,name=compressed_chunks,json=compressedChunks,proto3" json:"compressed_chunks,omitempty"`
+	CompressionEnabled bool                   `protobuf:"varint,4,opt,name=compression_enabled,json=compressionEnabled,proto3" json:"compression_enabled,omitempty"`
+	CompressAfterMs    int64                  `protobuf:"varint,5,opt,name=compress_after_ms,json=compressAfterMs,proto3" json:"compress_after_ms,omitempty"`
+	// Age after which whole chunks are dropped.
+	DropAfterMs int64 `protobuf:"varint,6,opt,name=drop_after_ms,json=dropAfterMs,proto3" json:"drop_after_ms,omitempty"`
+	TotalBytes  int64 `protobuf:"varint,7,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	// Size of the compressed chunks before and after compression.
+	BeforeCompressionBytes int64                `protobuf:"varint,8,opt,name=before_compression_bytes,json=beforeCompressionBytes,proto3" json:"before_compression_bytes,omitempty"`
+	AfterCompressionBytes  int64                `protobuf:"varint,9,opt,name=after_compression_bytes,json=afterCompressionBytes,proto3" json:"after_compression_bytes,omitempty"`
+	Retention              []*IntervalRetention `protobuf:"bytes,10,rep,name=retention,proto3" json:"retention,omitempty"`
+	// Unix milliseconds when the policies were applied; zero until then.
+	ReconciledAtMs int64 `protobuf:"varint,11,opt,name=reconciled_at_ms,json=reconciledAtMs,proto3" json:"reconciled_at_ms,omitempty"`
+	// Why the latest retention run failed, empty if it succeeded.
+	LastError     string `protobuf:"bytes,12,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StorageStatus) Reset() {
+	*x = StorageStatus{}
+	mi := &file_candlestick_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StorageStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StorageStatus) ProtoMessage() {}
+
+func (x *StorageStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StorageStatus.ProtoReflect.Descriptor instead.
+func (*StorageStatus) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *StorageStatus) GetChunkIntervalMs() int64 {
+	if x != nil {
+		return x.ChunkIntervalMs
+	}
+	return 0
+}
+
+func (x *StorageStatus) GetChunks() int32 {
+	if x != nil {
+		return x.Chunks
+	}
+	return 0
+}
+
+func (x *StorageStatus) GetCompressedChunks() int32 {
+	if x != nil {
+		return x.CompressedChunks
+	}
+	return 0
+}
+
+func (x *StorageStatus) GetCompressionEnabled() bool {
+	if x != nil {
+		return x.CompressionEnabled
+	}
+	return false
+}
+
+func (x *StorageStatus) GetCompressAfterMs() int64 {
+	if x != nil {
+		return x.CompressAfterMs
+	}
+	return 0
+}
+
+func (x *StorageStatus) GetDropAfterMs() int64 {
+	if x != nil {
+		return x.DropAfterMs
+	}
+	return 0
+}
+
+func (x *StorageStatus) GetTotalBytes() int64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *StorageStatus) GetBeforeCompressionBytes() int64 {
+	if x != nil {
+		return x.BeforeCompressionBytes
+	}
+	return 0
+}
+
+func (x *StorageStatus) GetAfterCompressionBytes() int64 {
+	if x != nil {
+		return x.AfterCompressionBytes
+	}
+	return 0
+}
+
+func (x *StorageStatus) GetRetention() []*IntervalRetention {
+	if x != nil {
+		return x.Retention
+	}
+	return nil
+}
+
+func (x *StorageStatus) GetReconciledAtMs() int64 {
+	if x != nil {
+		return x.ReconciledAtMs
+	}
+	return 0
+}
+
+func (x *StorageStatus) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+// IntervalRetention is how long bars of one interval are kept; interval
+// "*" covers every interval without an entry of its own.
+type IntervalRetention struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Interval string                 `protobuf:"bytes,1,opt,name=interval,proto3" json:"interval,omitempty"`
+	KeepMs   int64                  `protobuf:"varint,2,opt,name=keep_ms,json=keepMs,proto3" json:"keep_ms,omitempty"`
+	// Unix milliseconds of the oldest stored bar, zero if there is none.
+	OldestMs int64 `protobuf:"varint,3,opt,name=oldest_ms,json=oldestMs,proto3" json:"oldest_ms,omitempty"`
+	// Bars deleted by the latest expiry run, at last_run_ms.
+	Deleted       int64 `protobuf:"varint,4,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	LastRunMs     int64 `protobuf:"varint,5,opt,name=last_run_ms,json=lastRunMs,proto3" json:"last_run_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IntervalRetention) Reset() {
+	*x = IntervalRetention{}
+	mi := &file_candlestick_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntervalRetention) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntervalRetention) ProtoMessage() {}
+
+func (x *IntervalRetention) ProtoReflect() protoreflect.Message {
+	mi := &file_candlestick_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntervalRetention.ProtoReflect.Descriptor instead.
+func (*IntervalRetention) Descriptor() ([]byte, []int) {
+	return file_candlestick_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *IntervalRetention) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *IntervalRetention) GetKeepMs() int64 {
+	if x != nil {
+		return x.KeepMs
+	}
+	return 0
+}
+
+func (x *IntervalRetention) GetOldestMs() int64 {
+	if x != nil {
+		return x.OldestMs
+	}
+	return 0
+}
+
+func (x *IntervalRetention) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+func (x *IntervalRetention) GetLastRunMs() int64 {
+	if x != nil {
+		return x.LastRunMs
+	}
+	return 0
+}
+
+var File_candlestick_proto protoreflect.FileDescriptor
+
+const file_candlestick_proto_rawDesc = "" +
+	"\n" +
+	"\x11candlestick.proto\x12\vcandlestick\"\xa9\x01\n" +
+	"\rStreamRequest\x12\x18\n" +
+	"\asymbols\x18\x01 \x03(\tR\asymbols\x12\x1a\n" +
+	"\binterval\x18\x02 \x01(\tR\binterval\x12\x1f\n" +
+	"\vthrottle_ms\x18\x03 \x01(\x03R\n" +
+	"throttleMs\x12\x14\n" +
+	"\x05since\x18\x04 \x01(\x03R\x05since\x12+\n" +
+	"\x06series\x18\x05 \x01(\x0e2\x13.candlestick.SeriesR\x06series\"\xa0\x01\n" +
+	"\x11GetCandlesRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x1a\n" +
+	"\binterval\x18\x02 \x01(\tR\binterval\x12\x12\n" +
+	"\x04from\x18\x03 \x01(\x03R\x04from\x12\x0e\n" +
+	"\x02to\x18\x04 \x01(\x03R\x02to\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x06 \x01(\tR\tpageToken\"p\n" +
+	"\x12GetCandlesResponse\x122\n" +
+	"\acandles\x18\x01 \x03(\v2\x18.candlestick.CandlestickR\acandles\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xd3\x05\n" +
+	"\vCandlestick\x12\x16\n" +
 	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x12\n" +
-	"\x04open\x18\x02 \x01(\x01R\x04open\x12\x12\n" +
-	"\x04high\x18\x03 \x01(\x01R\x04high\x12\x10\n" +
-	"\x03low\x18\x04 \x01(\x01R\x03low\x12\x14\n" +
-	"\x05close\x18\x05 \x01(\x01R\x05close\x12\x16\n" +
-	"\x06volume\x18\x06 \x01(\x01R\x06volume\x12\x1d\n" +
+	"\x04open\x18\f \x01(\tR\x04open\x12\x12\n" +
+	"\x04high\x18\r \x01(\tR\x04high\x12\x10\n" +
+	"\x03low\x18\x0e \x01(\tR\x03low\x12\x14\n" +
+	"\x05close\x18\x0f \x01(\tR\x05close\x12\x16\n" +
+	"\x06volume\x18\x10 \x01(\tR\x06volume\x12\x1d\n" +
 	"\n" +
 	"start_time\x18\a \x01(\x03R\tstartTime\x12\x19\n" +
 	"\bend_time\x18\b \x01(\x03R\aendTime\x12\x19\n" +
-	"\bis_final\x18\t \x01(\bR\aisFinal\"\x0f\n" +
+	"\bis_final\x18\t \x01(\bR\aisFinal\x12\x1a\n" +
+	"\binterval\x18\n" +
+	" \x01(\tR\binterval\x12\x1a\n" +
+	"\bexchange\x18\v \x01(\tR\bexchange\x12\x12\n" +
+	"\x04vwap\x18\x11 \x01(\tR\x04vwap\x12!\n" +
+	"\fquote_volume\x18\x12 \x01(\tR\vquoteVolume\x12\x1f\n" +
+	"\vtrade_count\x18\x13 \x01(\x03R\n" +
+	"tradeCount\x12(\n" +
+	"\x10taker_buy_volume\x18\x14 \x01(\tR\x0etakerBuyVolume\x12*\n" +
+	"\x11taker_sell_volume\x18\x15 \x01(\tR\x0ftakerSellVolume\x12\x1a\n" +
+	"\brevision\x18\x16 \x01(\x05R\brevision\x12\x1c\n" +
+	"\tsynthetic\x18\x17 \x01(\bR\tsynthetic\x12;\n" +
+	"\bbar_type\x18\x18 \x01(\x0e2 .candlestick.Candlestick.BarTypeR\abarType\x12+\n" +
+	"\x06series\x18\x19 \x01(\x0e2\x13.candlestick.SeriesR\x06series\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x1a \x01(\x03R\tupdatedAt\"@\n" +
+	"\aBarType\x12\b\n" +
+	"\x04TIME\x10\x00\x12\b\n" +
+	"\x04TICK\x10\x01\x12\n" +
+	"\n" +
+	"\x06VOLUME\x10\x02\x12\n" +
+	"\n" +
+	"\x06DOLLAR\x10\x03\x12\t\n" +
+	"\x05RANGE\x10\x04J\x04\b\x02\x10\a\"~\n" +
+	"\x10IndicatorRequest\x12\x18\n" +
+	"\asymbols\x18\x01 \x03(\tR\asymbols\x12\x1a\n" +
+	"\binterval\x18\x02 \x01(\tR\binterval\x12\x1e\n" +
+	"\n" +
+	"indicators\x18\x03 \x03(\tR\n" +
+	"indicators\x12\x14\n" +
+	"\x05since\x18\x04 \x01(\x03R\x05since\"\xb4\x02\n" +
+	"\x0eIndicatorValue\x12\x1a\n" +
+	"\bexchange\x18\x01 \x01(\tR\bexchange\x12\x16\n" +
+	"\x06symbol\x18\x02 \x01(\tR\x06symbol\x12\x1a\n" +
+	"\binterval\x18\x03 \x01(\tR\binterval\x12\x1c\n" +
+	"\tindicator\x18\x04 \x01(\tR\tindicator\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x05 \x01(\x03R\tstartTime\x12\x19\n" +
+	"\bend_time\x18\x06 \x01(\x03R\aendTime\x12?\n" +
+	"\x06values\x18\a \x03(\v2'.candlestick.IndicatorValue.ValuesEntryR\x06values\x1a9\n" +
+	"\vValuesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"a\n" +
+	"\x10OrderBookRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x14\n" +
+	"\x05depth\x18\x02 \x01(\x05R\x05depth\x12\x1f\n" +
+	"\vinterval_ms\x18\x03 \x01(\x03R\n" +
+	"intervalMs\">\n" +
+	"\n" +
+	"PriceLevel\x12\x14\n" +
+	"\x05price\x18\x01 \x01(\tR\x05price\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\tR\bquantity\"\xd3\x02\n" +
+	"\tOrderBook\x12\x1a\n" +
+	"\bexchange\x18\x01 \x01(\tR\bexchange\x12\x16\n" +
+	"\x06symbol\x18\x02 \x01(\tR\x06symbol\x12$\n" +
+	"\x0elast_update_id\x18\x03 \x01(\x03R\flastUpdateId\x12\x12\n" +
+	"\x04time\x18\x04 \x01(\x03R\x04time\x122\n" +
+	"\bbest_bid\x18\x05 \x01(\v2\x17.candlestick.PriceLevelR\abestBid\x122\n" +
+	"\bbest_ask\x18\x06 \x01(\v2\x17.candlestick.PriceLevelR\abestAsk\x12\x16\n" +
+	"\x06spread\x18\a \x01(\tR\x06spread\x12+\n" +
+	"\x04bids\x18\b \x03(\v2\x17.candlestick.PriceLevelR\x04bids\x12+\n" +
+	"\x04asks\x18\t \x03(\v2\x17.candlestick.PriceLevelR\x04asks\"\xf6\x03\n" +
+	"\x05Alert\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06symbol\x18\x02 \x01(\tR\x06symbol\x12+\n" +
+	"\x04kind\x18\x03 \x01(\x0e2\x17.candlestick.Alert.KindR\x04kind\x12\x1a\n" +
+	"\binterval\x18\x04 \x01(\tR\binterval\x12\x1c\n" +
+	"\tthreshold\x18\x05 \x01(\tR\tthreshold\x12:\n" +
+	"\tdirection\x18\x06 \x01(\x0e2\x1c.candlestick.Alert.DirectionR\tdirection\x12\x1a\n" +
+	"\blookback\x18\a \x01(\x05R\blookback\x12\x1f\n" +
+	"\vcooldown_ms\x18\b \x01(\x03R\n" +
+	"cooldownMs\x12\x1f\n" +
+	"\vwebhook_url\x18\t \x01(\tR\n" +
+	"webhookUrl\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\x03R\tcreatedAt\x12*\n" +
+	"\x11last_triggered_at\x18\v \x01(\x03R\x0flastTriggeredAt\"Q\n" +
+	"\x04Kind\x12\x14\n" +
+	"\x10KIND_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vPRICE_CROSS\x10\x01\x12\x10\n" +
+	"\fCANDLE_RANGE\x10\x02\x12\x10\n" +
+	"\fVOLUME_SPIKE\x10\x03\"&\n" +
+	"\tDirection\x12\a\n" +
+	"\x03ANY\x10\x00\x12\x06\n" +
+	"\x02UP\x10\x01\x12\b\n" +
+	"\x04DOWN\x10\x02\">\n" +
+	"\x12CreateAlertRequest\x12(\n" +
+	"\x05alert\x18\x01 \x01(\v2\x12.candlestick.AlertR\x05alert\">\n" +
+	"\x12UpdateAlertRequest\x12(\n" +
+	"\x05alert\x18\x01 \x01(\v2\x12.candlestick.AlertR\x05alert\"$\n" +
+	"\x12DeleteAlertRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x15\n" +
+	"\x13DeleteAlertResponse\"-\n" +
+	"\x11ListAlertsRequest\x12\x18\n" +
+	"\asymbols\x18\x01 \x03(\tR\asymbols\"@\n" +
+	"\x12ListAlertsResponse\x12*\n" +
+	"\x06alerts\x18\x01 \x03(\v2\x12.candlestick.AlertR\x06alerts\"2\n" +
+	"\x13StreamAlertsRequest\x12\x1b\n" +
+	"\talert_ids\x18\x01 \x03(\tR\balertIds\"\xeb\x01\n" +
+	"\n" +
+	"AlertEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\balert_id\x18\x02 \x01(\tR\aalertId\x12\x1a\n" +
+	"\bexchange\x18\x03 \x01(\tR\bexchange\x12\x16\n" +
+	"\x06symbol\x18\x04 \x01(\tR\x06symbol\x12+\n" +
+	"\x04kind\x18\x05 \x01(\x0e2\x17.candlestick.Alert.KindR\x04kind\x12\x14\n" +
+	"\x05value\x18\x06 \x01(\tR\x05value\x12\x18\n" +
+	"\amessage\x18\a \x01(\tR\amessage\x12!\n" +
+	"\ftriggered_at\x18\b \x01(\x03R\vtriggeredAt\"\x0f\n" +
 	"\rHealthRequest\"\xbb\x01\n" +
 	"\x0eHealthResponse\x12:\n" +
 	"\x06status\x18\x01 \x01(\x0e2\".candlestick.HealthResponse.StatusR\x06status\x12\x18\n" +
@@ -346,11 +2382,75 @@ const file_candlestick_proto_rawDesc = "" +
 	"\x06Status\x12\v\n" +
 	"\aUNKNOWN\x10\x00\x12\v\n" +
 	"\aHEALTHY\x10\x01\x12\r\n" +
-	"\tUNHEALTHY\x10\x022b\n" +
+	"\tUNHEALTHY\x10\x02\"\x13\n" +
+	"\x11FeedStatusRequest\"C\n" +
+	"\x12FeedStatusResponse\x12-\n" +
+	"\x05feeds\x18\x01 \x03(\v2\x17.candlestick.FeedStatusR\x05feeds\"\x99\x02\n" +
+	"\n" +
+	"FeedStatus\x12\x1a\n" +
+	"\bexchange\x18\x01 \x01(\tR\bexchange\x12\x16\n" +
+	"\x06symbol\x18\x02 \x01(\tR\x06symbol\x123\n" +
+	"\x05state\x18\x03 \x01(\x0e2\x1d.candlestick.FeedStatus.StateR\x05state\x12\x19\n" +
+	"\bsince_ms\x18\x04 \x01(\x03R\asinceMs\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x05 \x01(\tR\tlastError\x12\x1e\n" +
+	"\n" +
+	"reconnects\x18\x06 \x01(\x05R\n" +
+	"reconnects\"H\n" +
+	"\x05State\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\x0e\n" +
+	"\n" +
+	"CONNECTING\x10\x01\x12\b\n" +
+	"\x04LIVE\x10\x02\x12\v\n" +
+	"\aBACKOFF\x10\x03\x12\v\n" +
+	"\aSTOPPED\x10\x04\"\x16\n" +
+	"\x14StorageStatusRequest\"\x9b\x04\n" +
+	"\rStorageStatus\x12*\n" +
+	"\x11chunk_interval_ms\x18\x01 \x01(\x03R\x0fchunkIntervalMs\x12\x16\n" +
+	"\x06chunks\x18\x02 \x01(\x05R\x06chunks\x12+\n" +
+	"\x11compressed_chunks\x18\x03 \x01(\x05R\x10compressedChunks\x12/\n" +
+	"\x13compression_enabled\x18\x04 \x01(\bR\x12compressionEnabled\x12*\n" +
+	"\x11compress_after_ms\x18\x05 \x01(\x03R\x0fcompressAfterMs\x12\"\n" +
+	"\rdrop_after_ms\x18\x06 \x01(\x03R\vdropAfterMs\x12\x1f\n" +
+	"\vtotal_bytes\x18\a \x01(\x03R\n" +
+	"totalBytes\x128\n" +
+	"\x18before_compression_bytes\x18\b \x01(\x03R\x16beforeCompressionBytes\x126\n" +
+	"\x17after_compression_bytes\x18\t \x01(\x03R\x15afterCompressionBytes\x12<\n" +
+	"\tretention\x18\n" +
+	" \x03(\v2\x1e.candlestick.IntervalRetentionR\tretention\x12(\n" +
+	"\x10reconciled_at_ms\x18\v \x01(\x03R\x0ereconciledAtMs\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\f \x01(\tR\tlastError\"\x9f\x01\n" +
+	"\x11IntervalRetention\x12\x1a\n" +
+	"\binterval\x18\x01 \x01(\tR\binterval\x12\x17\n" +
+	"\akeep_ms\x18\x02 \x01(\x03R\x06keepMs\x12\x1b\n" +
+	"\toldest_ms\x18\x03 \x01(\x03R\boldestMs\x12\x18\n" +
+	"\adeleted\x18\x04 \x01(\x03R\adeleted\x12\x1e\n" +
+	"\vlast_run_ms\x18\x05 \x01(\x03R\tlastRunMs*1\n" +
+	"\x06Series\x12\v\n" +
+	"\aCANDLES\x10\x00\x12\x0f\n" +
+	"\vHEIKIN_ASHI\x10\x01\x12\t\n" +
+	"\x05RENKO\x10\x022\x96\x03\n" +
 	"\x12CandlestickService\x12L\n" +
-	"\x12StreamCandlesticks\x12\x1a.candlestick.StreamRequest\x1a\x18.candlestick.Candlestick0\x012W\n" +
+	"\x12StreamCandlesticks\x12\x1a.candlestick.StreamRequest\x1a\x18.candlestick.Candlestick0\x01\x12M\n" +
+	"\n" +
+	"GetCandles\x12\x1e.candlestick.GetCandlesRequest\x1a\x1f.candlestick.GetCandlesResponse\x12P\n" +
+	"\x10StreamIndicators\x12\x1d.candlestick.IndicatorRequest\x1a\x1b.candlestick.IndicatorValue0\x01\x12E\n" +
+	"\fGetOrderBook\x12\x1d.candlestick.OrderBookRequest\x1a\x16.candlestick.OrderBook\x12J\n" +
+	"\x0fStreamOrderBook\x12\x1d.candlestick.OrderBookRequest\x1a\x16.candlestick.OrderBook0\x012\x84\x03\n" +
+	"\fAlertService\x12B\n" +
+	"\vCreateAlert\x12\x1f.candlestick.CreateAlertRequest\x1a\x12.candlestick.Alert\x12B\n" +
+	"\vUpdateAlert\x12\x1f.candlestick.UpdateAlertRequest\x1a\x12.candlestick.Alert\x12P\n" +
+	"\vDeleteAlert\x12\x1f.candlestick.DeleteAlertRequest\x1a .candlestick.DeleteAlertResponse\x12M\n" +
+	"\n" +
+	"ListAlerts\x12\x1e.candlestick.ListAlertsRequest\x1a\x1f.candlestick.ListAlertsResponse\x12K\n" +
+	"\fStreamAlerts\x12 .candlestick.StreamAlertsRequest\x1a\x17.candlestick.AlertEvent0\x012\xa6\x01\n" +
 	"\x12HealthCheckService\x12A\n" +
-	"\x06Health\x12\x1a.candlestick.HealthRequest\x1a\x1b.candlestick.HealthResponseB$Z\"api/protos/candlestick;candlestickb\x06proto3"
+	"\x06Health\x12\x1a.candlestick.HealthRequest\x1a\x1b.candlestick.HealthResponse\x12M\n" +
+	"\n" +
+	"FeedStatus\x12\x1e.candlestick.FeedStatusRequest\x1a\x1f.candlestick.FeedStatusResponse2a\n" +
+	"\fAdminService\x12Q\n" +
+	"\x10GetStorageStatus\x12!.candlestick.StorageStatusRequest\x1a\x1a.candlestick.StorageStatusB$Z\"api/protos/candlestick;candlestickb\x06proto3"
 
 var (
 	file_candlestick_proto_rawDescOnce sync.Once
@@ -364,26 +2464,94 @@ func file_candlestick_proto_rawDescGZIP() []byte {
 	return file_candlestick_proto_rawDescData
 }
 
-var file_candlestick_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_candlestick_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_candlestick_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_candlestick_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
 var file_candlestick_proto_goTypes = []any{
-	(HealthResponse_Status)(0), // 0: candlestick.HealthResponse.Status
-	(*StreamRequest)(nil),      // 1: candlestick.StreamRequest
-	(*Candlestick)(nil),        // 2: candlestick.Candlestick
-	(*HealthRequest)(nil),      // 3: candlestick.HealthRequest
-	(*HealthResponse)(nil),     // 4: candlestick.HealthResponse
+	(Series)(0),                  // 0: candlestick.Series
+	(Candlestick_BarType)(0),     // 1: candlestick.Candlestick.BarType
+	(Alert_Kind)(0),              // 2: candlestick.Alert.Kind
+	(Alert_Direction)(0),         // 3: candlestick.Alert.Direction
+	(HealthResponse_Status)(0),   // 4: candlestick.HealthResponse.Status
+	(FeedStatus_State)(0),        // 5: candlestick.FeedStatus.State
+	(*StreamRequest)(nil),        // 6: candlestick.StreamRequest
+	(*GetCandlesRequest)(nil),    // 7: candlestick.GetCandlesRequest
+	(*GetCandlesResponse)(nil),   // 8: candlestick.GetCandlesResponse
+	(*Candlestick)(nil),          // 9: candlestick.Candlestick
+	(*IndicatorRequest)(nil),     // 10: candlestick.IndicatorRequest
+	(*IndicatorValue)(nil),       // 11: candlestick.IndicatorValue
+	(*OrderBookRequest)(nil),     // 12: candlestick.OrderBookRequest
+	(*PriceLevel)(nil),           // 13: candlestick.PriceLevel
+	(*OrderBook)(nil),            // 14: candlestick.OrderBook
+	(*Alert)(nil),                // 15: candlestick.Alert
+	(*CreateAlertRequest)(nil),   // 16: candlestick.CreateAlertRequest
+	(*UpdateAlertRequest)(nil),   // 17: candlestick.UpdateAlertRequest
+	(*DeleteAlertRequest)(nil),   // 18: candlestick.DeleteAlertRequest
+	(*DeleteAlertResponse)(nil),  // 19: candlestick.DeleteAlertResponse
+	(*ListAlertsRequest)(nil),    // 20: candlestick.ListAlertsRequest
+	(*ListAlertsResponse)(nil),   // 21: candlestick.ListAlertsResponse
+	(*StreamAlertsRequest)(nil),  // 22: candlestick.StreamAlertsRequest
+	(*AlertEvent)(nil),           // 23: candlestick.AlertEvent
+	(*HealthRequest)(nil),        // 24: candlestick.HealthRequest
+	(*HealthResponse)(nil),       // 25: candlestick.HealthResponse
+	(*FeedStatusRequest)(nil),    // 26: candlestick.FeedStatusRequest
+	(*FeedStatusResponse)(nil),   // 27: candlestick.FeedStatusResponse
+	(*FeedStatus)(nil),           // 28: candlestick.FeedStatus
+	(*StorageStatusRequest)(nil), // 29: candlestick.StorageStatusRequest
+	(*StorageStatus)(nil),        // 30: candlestick.StorageStatus
+	(*IntervalRetention)(nil),    // 31: candlestick.IntervalRetention
+	nil,                          // 32: candlestick.IndicatorValue.ValuesEntry
 }
 var file_candlestick_proto_depIdxs = []int32{
-	0, // 0: candlestick.HealthResponse.status:type_name -> candlestick.HealthResponse.Status
-	1, // 1: candlestick.CandlestickService.StreamCandlesticks:input_type -> candlestick.StreamRequest
-	3, // 2: candlestick.HealthCheckService.Health:input_type -> candlestick.HealthRequest
-	2, // 3: candlestick.CandlestickService.StreamCandlesticks:output_type -> candlestick.Candlestick
-	4, // 4: candlestick.HealthCheckService.Health:output_type -> candlestick.HealthResponse
-	3, // [3:5] is the sub-list for method output_type
-	1, // [1:3] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	0,  // 0: candlestick.StreamRequest.series:type_name -> candlestick.Series
+	9,  // 1: candlestick.GetCandlesResponse.candles:type_name -> candlestick.Candlestick
+	1,  // 2: candlestick.Candlestick.bar_type:type_name -> candlestick.Candlestick.BarType
+	0,  // 3: candlestick.Candlestick.series:type_name -> candlestick.Series
+	32, // 4: candlestick.IndicatorValue.values:type_name -> candlestick.IndicatorValue.ValuesEntry
+	13, // 5: candlestick.OrderBook.best_bid:type_name -> candlestick.PriceLevel
+	13, // 6: candlestick.OrderBook.best_ask:type_name -> candlestick.PriceLevel
+	13, // 7: candlestick.OrderBook.bids:type_name -> candlestick.PriceLevel
+	13, // 8: candlestick.OrderBook.asks:type_name -> candlestick.PriceLevel
+	2,  // 9: candlestick.Alert.kind:type_name -> candlestick.Alert.Kind
+	3,  // 10: candlestick.Alert.direction:type_name -> candlestick.Alert.Direction
+	15, // 11: candlestick.CreateAlertRequest.alert:type_name -> candlestick.Alert
+	15, // 12: candlestick.UpdateAlertRequest.alert:type_name -> candlestick.Alert
+	15, // 13: candlestick.ListAlertsResponse.alerts:type_name -> candlestick.Alert
+	2,  // 14: candlestick.AlertEvent.kind:type_name -> candlestick.Alert.Kind
+	4,  // 15: candlestick.HealthResponse.status:type_name -> candlestick.HealthResponse.Status
+	28, // 16: candlestick.FeedStatusResponse.feeds:type_name -> candlestick.FeedStatus
+	5,  // 17: candlestick.FeedStatus.state:type_name -> candlestick.FeedStatus.State
+	31, // 18: candlestick.StorageStatus.retention:type_name -> candlestick.IntervalRetention
+	6,  // 19: candlestick.CandlestickService.StreamCandlesticks:input_type -> candlestick.StreamRequest
+	7,  // 20: candlestick.CandlestickService.GetCandles:input_type -> candlestick.GetCandlesRequest
+	10, // 21: candlestick.CandlestickService.StreamIndicators:input_type -> candlestick.IndicatorRequest
+	12, // 22: candlestick.CandlestickService.GetOrderBook:input_type -> candlestick.OrderBookRequest
+	12, // 23: candlestick.CandlestickService.StreamOrderBook:input_type -> candlestick.OrderBookRequest
+	16, // 24: candlestick.AlertService.CreateAlert:input_type -> candlestick.CreateAlertRequest
+	17, // 25: candlestick.AlertService.UpdateAlert:input_type -> candlestick.UpdateAlertRequest
+	18, // 26: candlestick.AlertService.DeleteAlert:input_type -> candlestick.DeleteAlertRequest
+	20, // 27: candlestick.AlertService.ListAlerts:input_type -> candlestick.ListAlertsRequest
+	22, // 28: candlestick.AlertService.StreamAlerts:input_type -> candlestick.StreamAlertsRequest
+	24, // 29: candlestick.HealthCheckService.Health:input_type -> candlestick.HealthRequest
+	26, // 30: candlestick.HealthCheckService.FeedStatus:input_type -> candlestick.FeedStatusRequest
+	29, // 31: candlestick.AdminService.GetStorageStatus:input_type -> candlestick.StorageStatusRequest
+	9,  // 32: candlestick.CandlestickService.StreamCandlesticks:output_type -> candlestick.Candlestick
+	8,  // 33: candlestick.CandlestickService.GetCandles:output_type -> candlestick.GetCandlesResponse
+	11, // 34: candlestick.CandlestickService.StreamIndicators:output_type -> candlestick.IndicatorValue
+	14, // 35: candlestick.CandlestickService.GetOrderBook:output_type -> candlestick.OrderBook
+	14, // 36: candlestick.CandlestickService.StreamOrderBook:output_type -> candlestick.OrderBook
+	15, // 37: candlestick.AlertService.CreateAlert:output_type -> candlestick.Alert
+	15, // 38: candlestick.AlertService.UpdateAlert:output_type -> candlestick.Alert
+	19, // 39: candlestick.AlertService.DeleteAlert:output_type -> candlestick.DeleteAlertResponse
+	21, // 40: candlestick.AlertService.ListAlerts:output_type -> candlestick.ListAlertsResponse
+	23, // 41: candlestick.AlertService.StreamAlerts:output_type -> candlestick.AlertEvent
+	25, // 42: candlestick.HealthCheckService.Health:output_type -> candlestick.HealthResponse
+	27, // 43: candlestick.HealthCheckService.FeedStatus:output_type -> candlestick.FeedStatusResponse
+	30, // 44: candlestick.AdminService.GetStorageStatus:output_type -> candlestick.StorageStatus
+	32, // [32:45] is the sub-list for method output_type
+	19, // [19:32] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_candlestick_proto_init() }
@@ -396,10 +2564,10 @@ func file_candlestick_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_candlestick_proto_rawDesc), len(file_candlestick_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   4,
+			NumEnums:      6,
+			NumMessages:   27,
 			NumExtensions: 0,
-			NumServices:   2,
+			NumServices:   4,
 		},
 		GoTypes:           file_candlestick_proto_goTypes,
 		DependencyIndexes: file_candlestick_proto_depIdxs,
@@ -21,6 +21,10 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	CandlestickService_StreamCandlesticks_FullMethodName = "/candlestick.CandlestickService/StreamCandlesticks"
+	CandlestickService_GetCandles_FullMethodName         = "/candlestick.CandlestickService/GetCandles"
+	CandlestickService_StreamIndicators_FullMethodName   = "/candlestick.CandlestickService/StreamIndicators"
+	CandlestickService_GetOrderBook_FullMethodName       = "/candlestick.CandlestickService/GetOrderBook"
+	CandlestickService_StreamOrderBook_FullMethodName    = "/candlestick.CandlestickService/StreamOrderBook"
 )
 
 // CandlestickServiceClient is the client API for CandlestickService service.
@@ -28,6 +32,10 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type CandlestickServiceClient interface {
 	StreamCandlesticks(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Candlestick], error)
+	GetCandles(ctx context.Context, in *GetCandlesRequest, opts ...grpc.CallOption) (*GetCandlesResponse, error)
+	StreamIndicators(ctx context.Context, in *IndicatorRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IndicatorValue], error)
+	GetOrderBook(ctx context.Context, in *OrderBookRequest, opts ...grpc.CallOption) (*OrderBook, error)
+	StreamOrderBook(ctx context.Context, in *OrderBookRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderBook], error)
 }
 
 type candlestickServiceClient struct {
@@ -57,11 +65,73 @@ func (c *candlestickServiceClient) StreamCandlesticks(ctx context.Context, in *S
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type CandlestickService_StreamCandlesticksClient = grpc.ServerStreamingClient[Candlestick]
 
+func (c *candlestickServiceClient) GetCandles(ctx context.Context, in *GetCandlesRequest, opts ...grpc.CallOption) (*GetCandlesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCandlesResponse)
+	err := c.cc.Invoke(ctx, CandlestickService_GetCandles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *candlestickServiceClient) StreamIndicators(ctx context.Context, in *IndicatorRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IndicatorValue], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CandlestickService_ServiceDesc.Streams[1], CandlestickService_StreamIndicators_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[IndicatorRequest, IndicatorValue]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CandlestickService_StreamIndicatorsClient = grpc.ServerStreamingClient[IndicatorValue]
+
+func (c *candlestickServiceClient) GetOrderBook(ctx context.Context, in *OrderBookRequest, opts ...grpc.CallOption) (*OrderBook, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderBook)
+	err := c.cc.Invoke(ctx, CandlestickService_GetOrderBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *candlestickServiceClient) StreamOrderBook(ctx context.Context, in *OrderBookRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OrderBook], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CandlestickService_ServiceDesc.Streams[2], CandlestickService_StreamOrderBook_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[OrderBookRequest, OrderBook]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CandlestickService_StreamOrderBookClient = grpc.ServerStreamingClient[OrderBook]
+
 // CandlestickServiceServer is the server API for CandlestickService service.
 // All implementations must embed UnimplementedCandlestickServiceServer
 // for forward compatibility.
 type CandlestickServiceServer interface {
 	StreamCandlesticks(*StreamRequest, grpc.ServerStreamingServer[Candlestick]) error
+	GetCandles(context.Context, *GetCandlesRequest) (*GetCandlesResponse, error)
+	StreamIndicators(*IndicatorRequest, grpc.ServerStreamingServer[IndicatorValue]) error
+	GetOrderBook(context.Context, *OrderBookRequest) (*OrderBook, error)
+	StreamOrderBook(*OrderBookRequest, grpc.ServerStreamingServer[OrderBook]) error
 	mustEmbedUnimplementedCandlestickServiceServer()
 }
 
@@ -75,6 +145,18 @@ type UnimplementedCandlestickServiceServer struct{}
 func (UnimplementedCandlestickServiceServer) StreamCandlesticks(*StreamRequest, grpc.ServerStreamingServer[Candlestick]) error {
 	return status.Errorf(codes.Unimplemented, "method StreamCandlesticks not implemented")
 }
+func (UnimplementedCandlestickServiceServer) GetCandles(context.Context, *GetCandlesRequest) (*GetCandlesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCandles not implemented")
+}
+func (UnimplementedCandlestickServiceServer) StreamIndicators(*IndicatorRequest, grpc.ServerStreamingServer[IndicatorValue]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamIndicators not implemented")
+}
+func (UnimplementedCandlestickServiceServer) GetOrderBook(context.Context, *OrderBookRequest) (*OrderBook, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrderBook not implemented")
+}
+func (UnimplementedCandlestickServiceServer) StreamOrderBook(*OrderBookRequest, grpc.ServerStreamingServer[OrderBook]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamOrderBook not implemented")
+}
 func (UnimplementedCandlestickServiceServer) mustEmbedUnimplementedCandlestickServiceServer() {}
 func (UnimplementedCandlestickServiceServer) testEmbeddedByValue()                            {}
 
@@ -107,25 +189,361 @@ func _CandlestickService_StreamCandlesticks_Handler(srv interface{}, stream grpc
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type CandlestickService_StreamCandlesticksServer = grpc.ServerStreamingServer[Candlestick]
 
+func _CandlestickService_GetCandles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCandlesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CandlestickServiceServer).GetCandles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CandlestickService_GetCandles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CandlestickServiceServer).GetCandles(ctx, req.(*GetCandlesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CandlestickService_StreamIndicators_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(IndicatorRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CandlestickServiceServer).StreamIndicators(m, &grpc.GenericServerStream[IndicatorRequest, IndicatorValue]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CandlestickService_StreamIndicatorsServer = grpc.ServerStreamingServer[IndicatorValue]
+
+func _CandlestickService_GetOrderBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrderBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CandlestickServiceServer).GetOrderBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CandlestickService_GetOrderBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CandlestickServiceServer).GetOrderBook(ctx, req.(*OrderBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CandlestickService_StreamOrderBook_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(OrderBookRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CandlestickServiceServer).StreamOrderBook(m, &grpc.GenericServerStream[OrderBookRequest, OrderBook]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CandlestickService_StreamOrderBookServer = grpc.ServerStreamingServer[OrderBook]
+
 // CandlestickService_ServiceDesc is the grpc.ServiceDesc for CandlestickService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var CandlestickService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "candlestick.CandlestickService",
 	HandlerType: (*CandlestickServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCandles",
+			Handler:    _CandlestickService_GetCandles_Handler,
+		},
+		{
+			MethodName: "GetOrderBook",
+			Handler:    _CandlestickService_GetOrderBook_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "StreamCandlesticks",
 			Handler:       _CandlestickService_StreamCandlesticks_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamIndicators",
+			Handler:       _CandlestickService_StreamIndicators_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamOrderBook",
+			Handler:       _CandlestickService_StreamOrderBook_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "candlestick.proto",
 }
 
 const (
-	HealthCheckService_Health_FullMethodName = "/candlestick.HealthCheckService/Health"
+	AlertService_CreateAlert_FullMethodName  = "/candlestick.AlertService/CreateAlert"
+	AlertService_UpdateAlert_FullMethodName  = "/candlestick.AlertService/UpdateAlert"
+	AlertService_DeleteAlert_FullMethodName  = "/candlestick.AlertService/DeleteAlert"
+	AlertService_ListAlerts_FullMethodName   = "/candlestick.AlertService/ListAlerts"
+	AlertService_StreamAlerts_FullMethodName = "/candlestick.AlertService/StreamAlerts"
+)
+
+// AlertServiceClient is the client API for AlertService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AlertServiceClient interface {
+	CreateAlert(ctx context.Context, in *CreateAlertRequest, opts ...grpc.CallOption) (*Alert, error)
+	UpdateAlert(ctx context.Context, in *UpdateAlertRequest, opts ...grpc.CallOption) (*Alert, error)
+	DeleteAlert(ctx context.Context, in *DeleteAlertRequest, opts ...grpc.CallOption) (*DeleteAlertResponse, error)
+	ListAlerts(ctx context.Context, in *ListAlertsRequest, opts ...grpc.CallOption) (*ListAlertsResponse, error)
+	StreamAlerts(ctx context.Context, in *StreamAlertsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AlertEvent], error)
+}
+
+type alertServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAlertServiceClient(cc grpc.ClientConnInterface) AlertServiceClient {
+	return &alertServiceClient{cc}
+}
+
+func (c *alertServiceClient) CreateAlert(ctx context.Context, in *CreateAlertRequest, opts ...grpc.CallOption) (*Alert, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Alert)
+	err := c.cc.Invoke(ctx, AlertService_CreateAlert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *alertServiceClient) UpdateAlert(ctx context.Context, in *UpdateAlertRequest, opts ...grpc.CallOption) (*Alert, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Alert)
+	err := c.cc.Invoke(ctx, AlertService_UpdateAlert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *alertServiceClient) DeleteAlert(ctx context.Context, in *DeleteAlertRequest, opts ...grpc.CallOption) (*DeleteAlertResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteAlertResponse)
+	err := c.cc.Invoke(ctx, AlertService_DeleteAlert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *alertServiceClient) ListAlerts(ctx context.Context, in *ListAlertsRequest, opts ...grpc.CallOption) (*ListAlertsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAlertsResponse)
+	err := c.cc.Invoke(ctx, AlertService_ListAlerts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *alertServiceClient) StreamAlerts(ctx context.Context, in *StreamAlertsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AlertEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AlertService_ServiceDesc.Streams[0], AlertService_StreamAlerts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamAlertsRequest, AlertEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AlertService_StreamAlertsClient = grpc.ServerStreamingClient[AlertEvent]
+
+// AlertServiceServer is the server API for AlertService service.
+// All implementations must embed UnimplementedAlertServiceServer
+// for forward compatibility.
+type AlertServiceServer interface {
+	CreateAlert(context.Context, *CreateAlertRequest) (*Alert, error)
+	UpdateAlert(context.Context, *UpdateAlertRequest) (*Alert, error)
+	DeleteAlert(context.Context, *DeleteAlertRequest) (*DeleteAlertResponse, error)
+	ListAlerts(context.Context, *ListAlertsRequest) (*ListAlertsResponse, error)
+	StreamAlerts(*StreamAlertsRequest, grpc.ServerStreamingServer[AlertEvent]) error
+	mustEmbedUnimplementedAlertServiceServer()
+}
+
+// UnimplementedAlertServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAlertServiceServer struct{}
+
+func (UnimplementedAlertServiceServer) CreateAlert(context.Context, *CreateAlertRequest) (*Alert, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAlert not implemented")
+}
+func (UnimplementedAlertServiceServer) UpdateAlert(context.Context, *UpdateAlertRequest) (*Alert, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAlert not implemented")
+}
+func (UnimplementedAlertServiceServer) DeleteAlert(context.Context, *DeleteAlertRequest) (*DeleteAlertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAlert not implemented")
+}
+func (UnimplementedAlertServiceServer) ListAlerts(context.Context, *ListAlertsRequest) (*ListAlertsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAlerts not implemented")
+}
+func (UnimplementedAlertServiceServer) StreamAlerts(*StreamAlertsRequest, grpc.ServerStreamingServer[AlertEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamAlerts not implemented")
+}
+func (UnimplementedAlertServiceServer) mustEmbedUnimplementedAlertServiceServer() {}
+func (UnimplementedAlertServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAlertServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AlertServiceServer will
+// result in compilation errors.
+type UnsafeAlertServiceServer interface {
+	mustEmbedUnimplementedAlertServiceServer()
+}
+
+func RegisterAlertServiceServer(s grpc.ServiceRegistrar, srv AlertServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAlertServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AlertService_ServiceDesc, srv)
+}
+
+func _AlertService_CreateAlert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAlertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlertServiceServer).CreateAlert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AlertService_CreateAlert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlertServiceServer).CreateAlert(ctx, req.(*CreateAlertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AlertService_UpdateAlert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAlertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlertServiceServer).UpdateAlert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AlertService_UpdateAlert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlertServiceServer).UpdateAlert(ctx, req.(*UpdateAlertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AlertService_DeleteAlert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAlertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlertServiceServer).DeleteAlert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AlertService_DeleteAlert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlertServiceServer).DeleteAlert(ctx, req.(*DeleteAlertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AlertService_ListAlerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAlertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlertServiceServer).ListAlerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AlertService_ListAlerts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlertServiceServer).ListAlerts(ctx, req.(*ListAlertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AlertService_StreamAlerts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamAlertsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AlertServiceServer).StreamAlerts(m, &grpc.GenericServerStream[StreamAlertsRequest, AlertEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AlertService_StreamAlertsServer = grpc.ServerStreamingServer[AlertEvent]
+
+// AlertService_ServiceDesc is the grpc.ServiceDesc for AlertService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AlertService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "candlestick.AlertService",
+	HandlerType: (*AlertServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateAlert",
+			Handler:    _AlertService_CreateAlert_Handler,
+		},
+		{
+			MethodName: "UpdateAlert",
+			Handler:    _AlertService_UpdateAlert_Handler,
+		},
+		{
+			MethodName: "DeleteAlert",
+			Handler:    _AlertService_DeleteAlert_Handler,
+		},
+		{
+			MethodName: "ListAlerts",
+			Handler:    _AlertService_ListAlerts_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamAlerts",
+			Handler:       _AlertService_StreamAlerts_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "candlestick.proto",
+}
+
+const (
+	HealthCheckService_Health_FullMethodName     = "/candlestick.HealthCheckService/Health"
+	HealthCheckService_FeedStatus_FullMethodName = "/candlestick.HealthCheckService/FeedStatus"
 )
 
 // HealthCheckServiceClient is the client API for HealthCheckService service.
@@ -133,6 +551,7 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type HealthCheckServiceClient interface {
 	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	FeedStatus(ctx context.Context, in *FeedStatusRequest, opts ...grpc.CallOption) (*FeedStatusResponse, error)
 }
 
 type healthCheckServiceClient struct {
@@ -153,11 +572,22 @@ func (c *healthCheckServiceClient) Health(ctx context.Context, in *HealthRequest
 	return out, nil
 }
 
+func (c *healthCheckServiceClient) FeedStatus(ctx context.Context, in *FeedStatusRequest, opts ...grpc.CallOption) (*FeedStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FeedStatusResponse)
+	err := c.cc.Invoke(ctx, HealthCheckService_FeedStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // HealthCheckServiceServer is the server API for HealthCheckService service.
 // All implementations must embed UnimplementedHealthCheckServiceServer
 // for forward compatibility.
 type HealthCheckServiceServer interface {
 	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	FeedStatus(context.Context, *FeedStatusRequest) (*FeedStatusResponse, error)
 	mustEmbedUnimplementedHealthCheckServiceServer()
 }
 
@@ -171,6 +601,9 @@ type UnimplementedHealthCheckServiceServer struct{}
 func (UnimplementedHealthCheckServiceServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
 }
+func (UnimplementedHealthCheckServiceServer) FeedStatus(context.Context, *FeedStatusRequest) (*FeedStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FeedStatus not implemented")
+}
 func (UnimplementedHealthCheckServiceServer) mustEmbedUnimplementedHealthCheckServiceServer() {}
 func (UnimplementedHealthCheckServiceServer) testEmbeddedByValue()                            {}
 
@@ -210,6 +643,24 @@ func _HealthCheckService_Health_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _HealthCheckService_FeedStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FeedStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HealthCheckServiceServer).FeedStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HealthCheckService_FeedStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HealthCheckServiceServer).FeedStatus(ctx, req.(*FeedStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // HealthCheckService_ServiceDesc is the grpc.ServiceDesc for HealthCheckService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -221,6 +672,112 @@ var HealthCheckService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Health",
 			Handler:    _HealthCheckService_Health_Handler,
 		},
+		{
+			MethodName: "FeedStatus",
+			Handler:    _HealthCheckService_FeedStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "candlestick.proto",
+}
+
+const (
+	AdminService_GetStorageStatus_FullMethodName = "/candlestick.AdminService/GetStorageStatus"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminServiceClient interface {
+	GetStorageStatus(ctx context.Context, in *StorageStatusRequest, opts ...grpc.CallOption) (*StorageStatus, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) GetStorageStatus(ctx context.Context, in *StorageStatusRequest, opts ...grpc.CallOption) (*StorageStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StorageStatus)
+	err := c.cc.Invoke(ctx, AdminService_GetStorageStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+type AdminServiceServer interface {
+	GetStorageStatus(context.Context, *StorageStatusRequest) (*StorageStatus, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) GetStorageStatus(context.Context, *StorageStatusRequest) (*StorageStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStorageStatus not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_GetStorageStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StorageStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).GetStorageStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_GetStorageStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).GetStorageStatus(ctx, req.(*StorageStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "candlestick.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStorageStatus",
+			Handler:    _AdminService_GetStorageStatus_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "candlestick.proto",
@@ -2,20 +2,32 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/shopspring/decimal"
+
 	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/alerts"
 	"github.com/shubie/trading/internal/binance"
+	"github.com/shubie/trading/internal/bybit"
+	"github.com/shubie/trading/internal/coinbase"
 	"github.com/shubie/trading/internal/config"
+	"github.com/shubie/trading/internal/derived"
 	"github.com/shubie/trading/internal/grpcserver"
 	"github.com/shubie/trading/internal/health"
+	"github.com/shubie/trading/internal/indicators"
+	"github.com/shubie/trading/internal/kraken"
+	"github.com/shubie/trading/internal/orderbook"
+	"github.com/shubie/trading/internal/source"
 	"github.com/shubie/trading/internal/storage"
 )
 
@@ -25,25 +37,171 @@ func main() {
 		log.Fatal("Config error:", err)
 	}
 
-	store := storage.NewPostgresStorage(cfg.Storage.Postgres.DSN)
-	agg := aggregator.NewAggregator()
-	grpcServer := grpcserver.NewServer(cfg.GRPC.Port, agg)
+	aggOpts, err := aggregatorOptions(cfg)
+	if err != nil {
+		log.Fatal("Config error:", err)
+	}
+
+	rollupIntervals, err := parseRollupIntervals(cfg)
+	if err != nil {
+		log.Fatal("Config error:", err)
+	}
+
+	retention, err := retentionPolicy(cfg)
+	if err != nil {
+		log.Fatal("Config error:", err)
+	}
+
+	stageOpts, err := derivedOptions(cfg)
+	if err != nil {
+		log.Fatal("Config error:", err)
+	}
+
+	engineOpts, err := indicatorOptions(cfg)
+	if err != nil {
+		log.Fatal("Config error:", err)
+	}
+
+	sources, err := buildSources(cfg)
+	if err != nil {
+		log.Fatal("Config error:", err)
+	}
+	var feeds []source.StatusReporter
+	for _, src := range sources {
+		if r, ok := src.(source.StatusReporter); ok {
+			feeds = append(feeds, r)
+		}
+	}
+
+	store, err := openStore(cfg)
+	if err != nil {
+		log.Fatal("Storage error:", err)
+	}
+	// Only Postgres keeps more than candles.
+	pg, _ := store.(*storage.PostgresStorage)
+
+	mergeMode, err := storage.ParseMergeMode(cfg.Storage.MergeMode)
+	if err != nil {
+		log.Fatal("Storage error:", err)
+	}
+	// Derived series, indicator values and book snapshots are batched like
+	// candles.
+	batchOpts := []storage.WriterOption{
+		storage.WithBatchSize(cfg.Storage.Batch.Size),
+		storage.WithFlushInterval(cfg.Storage.Batch.FlushInterval),
+	}
+	writerOpts := append([]storage.WriterOption{
+		storage.WithMergeMode(mergeMode),
+		storage.WithWriteTimeout(cfg.Storage.Batch.WriteTimeout),
+		storage.WithRetryBackoff(cfg.Storage.Spool.Backoff.Min, cfg.Storage.Spool.Backoff.Max),
+	}, batchOpts...)
+	var spool *storage.Spool
+	if cfg.Storage.Spool.Path != "" {
+		spool, err = storage.OpenSpool(cfg.Storage.Spool.Path)
+		if err != nil {
+			log.Fatal("Spool error:", err)
+		}
+		writerOpts = append(writerOpts, storage.WithSpool(spool))
+	}
+	writer := storage.NewWriter(store, writerOpts...)
+	agg := aggregator.NewAggregator(aggOpts...)
+	stage := derived.NewStage(stageOpts...)
+	engine := indicators.NewEngine(engineOpts...)
+	webhook := alerts.NewWebhook()
+	alertOpts := []alerts.Option{alerts.WithNotifier(webhook)}
+	if pg != nil {
+		alertOpts = append(alertOpts, alerts.WithStore(pg))
+	}
+	if cfg.Alerts.DefaultCooldown > 0 {
+		alertOpts = append(alertOpts, alerts.WithDefaultCooldown(cfg.Alerts.DefaultCooldown))
+	}
+	alertEngine := alerts.NewEngine(alertOpts...)
+	grpcOpts := []grpcserver.Option{
+		grpcserver.WithFeeds(feeds...),
+		grpcserver.WithDerived(stage),
+		grpcserver.WithIndicators(engine),
+		grpcserver.WithAlerts(alertEngine),
+	}
+	var depth *binance.DepthClient
+	if len(cfg.Binance.Depth.Symbols) > 0 {
+		depth = binance.NewDepthClient(cfg.Binance.WSSURL, cfg.Binance.Depth.Symbols,
+			binance.WithDepthRESTURL(cfg.Binance.RESTURL),
+			binance.WithDepthLimit(cfg.Binance.Depth.Limit),
+			binance.WithDepthBackoff(cfg.Binance.Backoff.Min, cfg.Binance.Backoff.Max))
+		grpcOpts = append(grpcOpts, grpcserver.WithOrderBooks(depth))
+	}
+	if pg != nil {
+		grpcOpts = append(grpcOpts, grpcserver.WithStorageStatus(pg))
+	}
+	grpcServer := grpcserver.NewServer(cfg.GRPC.Port, agg, store, grpcOpts...)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	tickChan := make(chan binance.Tick, cfg.Buffers.TickChan)
+	if err := engine.WarmUp(ctx, store); err != nil {
+		log.Printf("Indicator warm up failed: %v", err)
+	}
+	if pg != nil {
+		if err := stage.WarmUp(ctx, pg); err != nil {
+			log.Printf("Derived series warm up failed: %v", err)
+		}
+	}
+	if err := alertEngine.Load(ctx); err != nil {
+		log.Printf("Failed to load alerts: %v", err)
+	}
+
+	// Feeds -> alerts -> derived stage -> aggregator -> alerts -> derived
+	// stage -> indicator engine -> storage.
+	feedChan := make(chan source.Tick, cfg.Buffers.TickChan)
+	checkedTicks := make(chan source.Tick, cfg.Buffers.TickChan)
+	tickChan := make(chan source.Tick, cfg.Buffers.TickChan)
 	candleChan := make(chan aggregator.Candle, cfg.Buffers.CandleChan)
+	checkedCandles := make(chan aggregator.Candle, cfg.Buffers.CandleChan)
+	stagedChan := make(chan aggregator.Candle, cfg.Buffers.CandleChan)
+	persistChan := make(chan aggregator.Candle, cfg.Buffers.CandleChan)
+	barChan := make(chan derived.Bar, cfg.Buffers.CandleChan)
+	valueChan := make(chan indicators.Value, cfg.Buffers.CandleChan)
 
 	var wg sync.WaitGroup
 
-	binanceClient := binance.NewClient(cfg.Binance.WSSURL, cfg.Binance.Symbols)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		binanceClient.Connect(ctx, tickChan)
+		source.Run(ctx, sources, feedChan)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		alertEngine.Run(ctx, feedChan, checkedTicks, candleChan, checkedCandles)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		webhook.Run(ctx)
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stage.Run(ctx, checkedTicks, tickChan, checkedCandles, stagedChan, barChan)
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		engine.Run(ctx, stagedChan, persistChan, valueChan)
+	}()
+
+	config.WatchConfig(func(newCfg *config.Config) {
+		for _, src := range sources {
+			if client, ok := src.(*binance.Client); ok {
+				syncBinanceSymbols(client, newCfg.Binance.Symbols)
+			}
+		}
+	})
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -59,18 +217,46 @@ func main() {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		store.StartPersisting(ctx, candleChan)
+		<-writer.StartPersisting(ctx, persistChan)
 	}()
+	if pg != nil {
+		pg.StartPersistingDerived(barChan, batchOpts...)
+		pg.StartPersistingIndicators(valueChan, batchOpts...)
+		pg.StartRollup(ctx, rollupIntervals)
+		if retention != nil {
+			pg.StartRetention(ctx, *retention)
+		}
+	} else {
+		go discard(barChan)
+		go discard(valueChan)
+	}
 
-	healthHandler := health.NewHandler(agg, cfg.Health.DataTimeout)
+	if depth != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			depth.Run(ctx)
+		}()
+		if pg != nil && cfg.Binance.Depth.SnapshotInterval > 0 {
+			snapshotChan := make(chan orderbook.Snapshot, cfg.Buffers.CandleChan)
+			go orderbook.RunSnapshots(ctx, depth, cfg.Binance.Depth.SnapshotInterval, cfg.Binance.Depth.SnapshotLevels, snapshotChan)
+			pg.StartPersistingBooks(snapshotChan, batchOpts...)
+		}
+	}
+
+	healthHandler := health.NewHandler(agg, cfg.Health.DataTimeout, feeds...)
+	if spool != nil {
+		healthHandler.SetSpool(spool)
+	}
+	healthServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Health.Port),
+		Handler: healthHandler,
+	}
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		log.Printf("HTTP health server starting on port %d", cfg.Health.Port)
-		if err := http.ListenAndServe(
-			fmt.Sprintf(":%d", cfg.Health.Port),
-			healthHandler,
-		); err != nil {
+		if err := healthServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Printf("HTTP server error: %v", err)
 		}
 	}()
@@ -85,11 +271,14 @@ func main() {
 
 	cancel()
 	grpcServer.Stop()
-	store.Close()
+	healthServer.Shutdown(shutdownCtx)
 
+	// The store is closed only once the writer has flushed the candles
+	// the aggregator finalizes on shutdown, and the persisters stopped.
 	done := make(chan struct{})
 	go func() {
 		wg.Wait()
+		store.Close()
 		close(done)
 	}()
 
@@ -100,3 +289,211 @@ func main() {
 		log.Println("Shutdown timeout exceeded")
 	}
 }
+
+func openStore(cfg *config.Config) (storage.CandleStore, error) {
+	switch strings.ToLower(cfg.Storage.Backend) {
+	case "", "postgres":
+		return storage.NewPostgresStorage(cfg.Storage.Postgres.DSN,
+			storage.WithCopyThreshold(cfg.Storage.Postgres.CopyThreshold))
+	case "sqlite":
+		return storage.OpenSQLite(cfg.Storage.SQLite.Path)
+	case "memory":
+		return storage.NewMemoryStorage(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+}
+
+// discard drains a side output the storage backend does not keep.
+func discard[T any](ch <-chan T) {
+	for range ch {
+	}
+}
+
+func buildSources(cfg *config.Config) ([]source.Source, error) {
+	var sources []source.Source
+	for _, name := range cfg.Sources {
+		switch strings.ToLower(name) {
+		case binance.Exchange:
+			sources = append(sources, binance.NewClient(cfg.Binance.WSSURL, cfg.Binance.Symbols,
+				binance.WithRESTURL(cfg.Binance.RESTURL),
+				binance.WithBackfillWeightLimit(cfg.Binance.Backfill.WeightLimit),
+				binance.WithMaxStreamsPerConnection(cfg.Binance.MaxStreamsPerConnection),
+				binance.WithBackoff(cfg.Binance.Backoff.Min, cfg.Binance.Backoff.Max),
+				binance.WithKeepalive(cfg.Binance.Keepalive.PingInterval, cfg.Binance.Keepalive.ReadTimeout),
+				binance.WithStaleTimeout(cfg.Binance.StaleAfter),
+				binance.WithMaxConnectionAge(cfg.Binance.MaxConnectionAge)))
+		case coinbase.Exchange:
+			sources = append(sources, coinbase.NewClient(cfg.Coinbase.WSSURL, cfg.Coinbase.Symbols))
+		case kraken.Exchange:
+			sources = append(sources, kraken.NewClient(cfg.Kraken.WSSURL, cfg.Kraken.Symbols))
+		case bybit.Exchange:
+			sources = append(sources, bybit.NewClient(cfg.Bybit.WSSURL, cfg.Bybit.Symbols))
+		default:
+			return nil, fmt.Errorf("unknown source %q", name)
+		}
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no sources configured")
+	}
+	return sources, nil
+}
+
+// syncBinanceSymbols applies symbol list changes from a config reload to a
+// running client without reconnecting unaffected streams.
+func syncBinanceSymbols(client *binance.Client, symbols []string) {
+	wanted := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		wanted[strings.ToUpper(symbol)] = true
+	}
+
+	var removed []string
+	for _, symbol := range client.Symbols() {
+		if !wanted[symbol] {
+			removed = append(removed, symbol)
+		}
+	}
+	if len(removed) > 0 {
+		log.Printf("Unsubscribing binance symbols %v", removed)
+		client.Unsubscribe(removed...)
+	}
+	client.Subscribe(symbols...)
+}
+
+func aggregatorOptions(cfg *config.Config) ([]aggregator.Option, error) {
+	intervals, err := parseIntervals(cfg.Aggregator.Intervals)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := aggregator.ParseLatePolicy(cfg.Aggregator.LatePolicy)
+	if err != nil {
+		return nil, err
+	}
+	opts := []aggregator.Option{
+		aggregator.WithIntervals(intervals...),
+		aggregator.WithAllowedLateness(cfg.Aggregator.AllowedLateness),
+		aggregator.WithIdleTimeout(cfg.Aggregator.IdleTimeout),
+		aggregator.WithLatePolicy(policy),
+	}
+
+	for _, sc := range cfg.Aggregator.Symbols {
+		if sc.GapFill {
+			opts = append(opts, aggregator.WithGapFill(sc.Symbol))
+		}
+		if len(sc.Bars) > 0 {
+			specs := make([]aggregator.BarSpec, 0, len(sc.Bars))
+			for _, bar := range sc.Bars {
+				spec, err := aggregator.ParseBarSpec(bar)
+				if err != nil {
+					return nil, fmt.Errorf("symbol %s: %w", sc.Symbol, err)
+				}
+				specs = append(specs, spec)
+			}
+			opts = append(opts, aggregator.WithSymbolBars(sc.Symbol, specs...))
+		}
+		if len(sc.Intervals) == 0 {
+			continue
+		}
+		intervals, err := parseIntervals(sc.Intervals)
+		if err != nil {
+			return nil, fmt.Errorf("symbol %s: %w", sc.Symbol, err)
+		}
+		opts = append(opts, aggregator.WithSymbolIntervals(sc.Symbol, intervals...))
+	}
+	return opts, nil
+}
+
+func derivedOptions(cfg *config.Config) ([]derived.Option, error) {
+	opts := []derived.Option{
+		derived.WithHeikinAshi(cfg.Derived.HeikinAshi),
+		derived.WithRenkoPercent(decimal.NewFromFloat(cfg.Derived.Renko.BoxPercent)),
+	}
+	for _, rc := range cfg.Derived.Renko.Symbols {
+		box, err := decimal.NewFromString(rc.Box)
+		if err != nil || !box.IsPositive() {
+			return nil, fmt.Errorf("symbol %s: invalid renko box %q", rc.Symbol, rc.Box)
+		}
+		opts = append(opts, derived.WithSymbolRenko(rc.Symbol, box))
+	}
+	return opts, nil
+}
+
+func indicatorOptions(cfg *config.Config) ([]indicators.Option, error) {
+	var opts []indicators.Option
+	for _, ic := range cfg.Indicators {
+		d, err := aggregator.ParseInterval(ic.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("indicators for %s: %w", ic.Symbol, err)
+		}
+		specs := make([]indicators.Spec, 0, len(ic.Indicators))
+		for _, name := range ic.Indicators {
+			spec, err := indicators.Parse(name)
+			if err != nil {
+				return nil, fmt.Errorf("indicators for %s: %w", ic.Symbol, err)
+			}
+			specs = append(specs, spec)
+		}
+		opts = append(opts, indicators.WithSeries(ic.Symbol, aggregator.FormatInterval(d), specs...))
+	}
+	return opts, nil
+}
+
+// retentionPolicy parses storage.retention, or returns nil if the section is
+// empty and the hypertable is left as it is.
+func retentionPolicy(cfg *config.Config) (*storage.RetentionPolicy, error) {
+	rc := cfg.Storage.Retention
+	if rc.ChunkInterval == "" && rc.CompressAfter == "" && rc.Default == "" && len(rc.Keep) == 0 {
+		return nil, nil
+	}
+	policy, err := storage.ParseRetentionPolicy(rc.ChunkInterval, rc.CompressAfter, rc.Default, rc.Keep)
+	if err != nil {
+		return nil, fmt.Errorf("storage.retention.%w", err)
+	}
+	return &policy, nil
+}
+
+// parseRollupIntervals parses storage.rollup.intervals, rejecting any the
+// aggregator also builds live: the rollup would overwrite the live bars with
+// bumped revisions and the two would fight over every bucket.
+func parseRollupIntervals(cfg *config.Config) ([]time.Duration, error) {
+	intervals, err := parseIntervals(cfg.Storage.Rollup.Intervals)
+	if err != nil {
+		return nil, err
+	}
+
+	live := make(map[time.Duration]string)
+	for _, label := range cfg.Aggregator.Intervals {
+		if d, err := aggregator.ParseInterval(label); err == nil {
+			live[d] = "aggregator.intervals"
+		}
+	}
+	for _, sc := range cfg.Aggregator.Symbols {
+		for _, label := range sc.Intervals {
+			if d, err := aggregator.ParseInterval(label); err == nil && live[d] == "" {
+				live[d] = "aggregator symbol " + sc.Symbol
+			}
+		}
+	}
+
+	for _, interval := range intervals {
+		if err := storage.ValidateRollupInterval(interval); err != nil {
+			return nil, err
+		}
+		if by, ok := live[interval]; ok {
+			return nil, fmt.Errorf("rollup interval %s is also built live by %s", aggregator.FormatInterval(interval), by)
+		}
+	}
+	return intervals, nil
+}
+
+func parseIntervals(labels []string) ([]time.Duration, error) {
+	intervals := make([]time.Duration, 0, len(labels))
+	for _, label := range labels {
+		d, err := aggregator.ParseInterval(label)
+		if err != nil {
+			return nil, err
+		}
+		intervals = append(intervals, d)
+	}
+	return intervals, nil
+}
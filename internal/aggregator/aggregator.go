@@ -3,37 +3,151 @@ package aggregator
 import (
 	"context"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/shubie/trading/internal/binance"
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/source"
 )
 
 type Candle struct {
-	Symbol    string    `db:"symbol"`
-	Open      float64   `db:"open"`
-	High      float64   `db:"high"`
-	Low       float64   `db:"low"`
-	Close     float64   `db:"close"`
-	Volume    float64   `db:"volume"`
-	StartTime time.Time `db:"start_time"`
-	EndTime   time.Time `db:"end_time"`
-	Finalized bool      `db:"-"`
+	Exchange string          `db:"exchange"`
+	Symbol   string          `db:"symbol"`
+	Interval string          `db:"interval"`
+	BarType  BarType         `db:"bar_type"`
+	Open     decimal.Decimal `db:"open"`
+	High     decimal.Decimal `db:"high"`
+	Low      decimal.Decimal `db:"low"`
+	Close    decimal.Decimal `db:"close"`
+	Volume   decimal.Decimal `db:"volume"`
+	// VWAP is QuoteVolume / Volume, the volume weighted average price.
+	VWAP        decimal.Decimal `db:"vwap"`
+	QuoteVolume decimal.Decimal `db:"quote_volume"`
+	TradeCount  int64           `db:"trade_count"`
+	// Taker volumes split Volume by aggressor side. Trades whose side the
+	// exchange does not report are in neither.
+	TakerBuyVolume  decimal.Decimal `db:"taker_buy_volume"`
+	TakerSellVolume decimal.Decimal `db:"taker_sell_volume"`
+	StartTime       time.Time       `db:"start_time"`
+	EndTime         time.Time       `db:"end_time"`
+	// Synthetic marks a flat gap fill candle for an interval without
+	// trades: OHLC carry the previous close and volume is zero.
+	Synthetic bool `db:"synthetic"`
+	Finalized bool `db:"-"`
+	// Revision counts corrections made after the candle was first
+	// finalized, e.g. by late ticks. Stores keep the revision of the
+	// version they hold.
+	Revision int `db:"revision"`
+	// UpdatedAt is when a store last wrote the candle; zero for candles
+	// that were not read from one.
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (c Candle) Instrument() source.Instrument {
+	return source.Instrument{Exchange: c.Exchange, Symbol: c.Symbol}
+}
+
+// vwapPlaces is the number of decimal places VWAP is rounded to.
+const vwapPlaces = 18
+
+// add folds a trade into the candle's price and volume statistics.
+func (c *Candle) add(tick source.Tick) {
+	if tick.Price.GreaterThan(c.High) {
+		c.High = tick.Price
+	}
+	if tick.Price.LessThan(c.Low) {
+		c.Low = tick.Price
+	}
+	c.Close = tick.Price
+	c.Volume = c.Volume.Add(tick.Quantity)
+	c.QuoteVolume = c.QuoteVolume.Add(tick.Price.Mul(tick.Quantity))
+	if c.Volume.IsPositive() {
+		c.VWAP = c.QuoteVolume.DivRound(c.Volume, vwapPlaces)
+	}
+
+	switch tick.Side {
+	case source.SideBuy:
+		c.TakerBuyVolume = c.TakerBuyVolume.Add(tick.Quantity)
+	case source.SideSell:
+		c.TakerSellVolume = c.TakerSellVolume.Add(tick.Quantity)
+	}
+
+	if tick.Trades > 0 {
+		c.TradeCount += tick.Trades
+	} else {
+		c.TradeCount++
+	}
+}
+
+// recentFinalizedLimit bounds how many finalized bars are kept in memory per
+// symbol and interval so reconnecting streams can be backfilled before the
+// bars reach storage.
+const recentFinalizedLimit = 500
+
+type Option func(*Aggregator)
+
+// WithIntervals sets the intervals built for every symbol that has no
+// explicit override.
+func WithIntervals(intervals ...time.Duration) Option {
+	return func(a *Aggregator) {
+		a.intervals = intervals
+	}
+}
+
+// WithSymbolIntervals overrides the intervals built for a symbol. The symbol
+// may be qualified as "exchange:symbol" to apply to one venue only.
+func WithSymbolIntervals(symbol string, intervals ...time.Duration) Option {
+	return func(a *Aggregator) {
+		a.symbolIntervals[symbol] = intervals
+	}
 }
 
 type Aggregator struct {
-	mu           sync.RWMutex
-	candles      map[string]*Candle
-	lastTickTime time.Time
+	mu              sync.RWMutex
+	candles         map[string]*Candle
+	intervals       []time.Duration
+	symbolIntervals map[string][]time.Duration
+	recent          map[string][]Candle
+	hub             *Hub
+	lastTickTime    time.Time
+
+	allowedLateness time.Duration
+	idleTimeout     time.Duration
+	latePolicy      LatePolicy
+	watermarks      map[source.Instrument]*watermark
+	lateStats       LateStats
+
+	gapFill map[string]bool
+	last    map[string]Candle // newest finalized candle per series
+
+	symbolBars map[string][]BarSpec
+	bars       map[string]*Candle // open information driven bar per series
 }
 
-func NewAggregator() *Aggregator {
-	return &Aggregator{
-		candles: make(map[string]*Candle),
+func NewAggregator(opts ...Option) *Aggregator {
+	a := &Aggregator{
+		candles:         make(map[string]*Candle),
+		intervals:       []time.Duration{DefaultInterval},
+		symbolIntervals: make(map[string][]time.Duration),
+		recent:          make(map[string][]Candle),
+		hub:             NewHub(),
+		allowedLateness: DefaultAllowedLateness,
+		idleTimeout:     DefaultIdleTimeout,
+		watermarks:      make(map[source.Instrument]*watermark),
+		gapFill:         make(map[string]bool),
+		last:            make(map[string]Candle),
+		symbolBars:      make(map[string][]BarSpec),
+		bars:            make(map[string]*Candle),
+	}
+	for _, opt := range opts {
+		opt(a)
 	}
+	return a
 }
 
-func (a *Aggregator) Run(ctx context.Context, tickChan <-chan binance.Tick, candleChan chan<- Candle) {
+func (a *Aggregator) Run(ctx context.Context, tickChan <-chan source.Tick, candleChan chan<- Candle) {
 	defer close(candleChan)
 	log.Println("Aggregator service started")
 	finalizeTicker := time.NewTicker(1 * time.Second)
@@ -48,7 +162,7 @@ func (a *Aggregator) Run(ctx context.Context, tickChan <-chan binance.Tick, cand
 				return
 			}
 			log.Printf("Aggregator received tick: %+v\n", tick) // ← ADD THIS
-			a.processTick(tick)
+			a.processTick(tick, candleChan)
 
 		case <-finalizeTicker.C:
 			a.finalizeExpired(candleChan)
@@ -61,57 +175,100 @@ func (a *Aggregator) Run(ctx context.Context, tickChan <-chan binance.Tick, cand
 	}
 }
 
-func (a *Aggregator) processTick(tick binance.Tick) {
+func (a *Aggregator) intervalsFor(inst source.Instrument) []time.Duration {
+	if intervals, ok := a.symbolIntervals[inst.String()]; ok {
+		return intervals
+	}
+	if intervals, ok := a.symbolIntervals[inst.Symbol]; ok {
+		return intervals
+	}
+	return a.intervals
+}
+
+func seriesKey(inst source.Instrument, interval string) string {
+	return inst.String() + "|" + interval
+}
+
+func candleKey(inst source.Instrument, interval string, startTime time.Time) string {
+	return seriesKey(inst, interval) + "|" + startTime.String()
+}
+
+// processTick buckets a tick by its exchange timestamp. A tick for a window
+// its instrument's watermark has already passed is late and handled by the
+// late tick policy rather than reopening the window.
+func (a *Aggregator) processTick(tick source.Tick, candleChan chan<- Candle) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	a.lastTickTime = tick.Timestamp
-
-	startTime := tick.Timestamp.Truncate(time.Minute)
-	key := tick.Symbol + startTime.String()
-
-	candle, exists := a.candles[key]
-	if !exists {
-		candle = &Candle{
-			Symbol:    tick.Symbol,
-			Open:      tick.Price,
-			High:      tick.Price,
-			Low:       tick.Price,
-			Close:     tick.Price,
-			Volume:    tick.Quantity,
-			StartTime: startTime,
-			EndTime:   startTime.Add(time.Minute),
-		}
-		a.candles[key] = candle
-		return
+	if tick.Timestamp.After(a.lastTickTime) {
+		a.lastTickTime = tick.Timestamp
 	}
 
-	if tick.Price > candle.High {
-		candle.High = tick.Price
+	inst := tick.Instrument()
+	w := a.watermarkFor(inst)
+	w.lastArrival = time.Now()
+	if tick.Timestamp.After(w.maxEvent) {
+		w.maxEvent = tick.Timestamp
 	}
-	if tick.Price < candle.Low {
-		candle.Low = tick.Price
+
+	for _, interval := range a.intervalsFor(inst) {
+		label := FormatInterval(interval)
+		startTime := tick.Timestamp.Truncate(interval)
+		key := candleKey(inst, label, startTime)
+
+		candle, exists := a.candles[key]
+		if !exists {
+			if !w.mark.Before(startTime.Add(interval)) {
+				a.late(tick, label, startTime, interval, candleChan)
+				continue
+			}
+			candle = &Candle{
+				Exchange:  tick.Exchange,
+				Symbol:    tick.Symbol,
+				Interval:  label,
+				BarType:   BarTime,
+				Open:      tick.Price,
+				High:      tick.Price,
+				Low:       tick.Price,
+				StartTime: startTime,
+				EndTime:   startTime.Add(interval),
+			}
+			a.candles[key] = candle
+		}
+		candle.add(tick)
+		a.hub.Publish(*candle)
 	}
-	candle.Close = tick.Price
-	candle.Volume += tick.Quantity
+
+	a.processBars(tick, candleChan)
 }
 
+// finalizeExpired advances every instrument's watermark and finalizes the
+// candles it has passed, oldest first.
 func (a *Aggregator) finalizeExpired(candleChan chan<- Candle) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	now := time.Now()
+	marks := make(map[source.Instrument]time.Time, len(a.watermarks))
+	for inst, w := range a.watermarks {
+		marks[inst] = a.advance(w, now)
+	}
+
+	var expired []*Candle
 	for key, candle := range a.candles {
-		if now.After(candle.EndTime) {
-			candle.Finalized = true
-			candleChan <- *candle
+		if !marks[candle.Instrument()].Before(candle.EndTime) {
+			expired = append(expired, candle)
 			delete(a.candles, key)
-			log.Printf("Finalized candle for %s: %s-%s",
-				candle.Symbol,
-				candle.StartTime.Format(time.RFC3339),
-				candle.EndTime.Format(time.RFC3339))
 		}
 	}
+	for _, candle := range a.closeInOrder(expired, candleChan) {
+		log.Printf("Finalized %s candle for %s: %s-%s",
+			candle.Interval,
+			candle.Instrument(),
+			candle.StartTime.Format(time.RFC3339),
+			candle.EndTime.Format(time.RFC3339))
+	}
+	a.fillIdle(marks, candleChan)
 }
 
 func (a *Aggregator) finalizeAll(candleChan chan<- Candle) {
@@ -119,23 +276,111 @@ func (a *Aggregator) finalizeAll(candleChan chan<- Candle) {
 	defer a.mu.Unlock()
 	log.Println("Finalizing all remaining candles")
 
+	remaining := make([]*Candle, 0, len(a.candles)+len(a.bars))
 	for key, candle := range a.candles {
-		candle.Finalized = true
-		candleChan <- *candle
+		remaining = append(remaining, candle)
 		delete(a.candles, key)
 	}
+	// Information driven bars are cut short; their volume is kept.
+	for key, bar := range a.bars {
+		remaining = append(remaining, bar)
+		delete(a.bars, key)
+	}
+	a.closeInOrder(remaining, candleChan)
+}
+
+// closeInOrder finalizes candles oldest first, gap filling before each. It
+// must be called with a.mu held.
+func (a *Aggregator) closeInOrder(candles []*Candle, candleChan chan<- Candle) []*Candle {
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].StartTime.Before(candles[j].StartTime)
+	})
+	for _, candle := range candles {
+		if candle.BarType == BarTime {
+			a.fill(candle.Instrument(), candle.Interval, candle.EndTime.Sub(candle.StartTime), candle.StartTime, candleChan)
+		}
+		a.finalize(*candle, candleChan)
+	}
+	return candles
+}
+
+// finalize emits a closed candle. It must be called with a.mu held.
+func (a *Aggregator) finalize(candle Candle, candleChan chan<- Candle) {
+	candle.Finalized = true
+	candleChan <- candle
+	a.remember(candle)
+	a.hub.Publish(candle)
+
+	key := seriesKey(candle.Instrument(), candle.Interval)
+	if last, ok := a.last[key]; !ok || candle.EndTime.After(last.EndTime) {
+		a.last[key] = candle
+	}
+}
+
+func (a *Aggregator) remember(candle Candle) {
+	key := seriesKey(candle.Instrument(), candle.Interval)
+	recent := append(a.recent[key], candle)
+	if len(recent) > recentFinalizedLimit {
+		recent = recent[len(recent)-recentFinalizedLimit:]
+	}
+	a.recent[key] = recent
+}
+
+// RecentFinalized returns the finalized candles still held in memory for the
+// instrument and interval label that started at or after since, oldest first.
+func (a *Aggregator) RecentFinalized(inst source.Instrument, interval string, since time.Time) []Candle {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var candles []Candle
+	for _, candle := range a.recent[seriesKey(inst, interval)] {
+		if !candle.StartTime.Before(since) {
+			candles = append(candles, candle)
+		}
+	}
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].StartTime.Before(candles[j].StartTime)
+	})
+	return candles
+}
+
+// Subscribe returns a subscription to candle updates for the instruments and
+// interval label, seeded with their current in-progress candles.
+func (a *Aggregator) Subscribe(insts []source.Instrument, interval string, throttle time.Duration) *Subscription {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	sub := a.hub.Subscribe(insts, interval, throttle)
+	for _, open := range []map[string]*Candle{a.candles, a.bars} {
+		for _, candle := range open {
+			if sub.matches(*candle) {
+				sub.offer(*candle)
+			}
+		}
+	}
+	return sub
 }
 
-func (a *Aggregator) GetCurrentCandle(symbol string) *Candle {
+// GetCurrentCandle returns a copy of the most recent in-progress candle for
+// the instrument and interval label, or nil if there is none.
+func (a *Aggregator) GetCurrentCandle(inst source.Instrument, interval string) *Candle {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
+	current := a.bars[seriesKey(inst, interval)]
 	for _, candle := range a.candles {
-		if candle.Symbol == symbol && !candle.Finalized {
-			return candle
+		if candle.Instrument() != inst || candle.Interval != interval || candle.Finalized {
+			continue
 		}
+		if current == nil || candle.StartTime.After(current.StartTime) {
+			current = candle
+		}
+	}
+	if current == nil {
+		return nil
 	}
-	return nil
+	c := *current
+	return &c
 }
 
 func (a *Aggregator) GetLastDataTime() time.Time {
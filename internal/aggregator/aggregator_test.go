@@ -5,12 +5,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
+
 	"github.com/shubie/trading/internal/aggregator"
-	"github.com/shubie/trading/internal/binance"
+	"github.com/shubie/trading/internal/source"
 )
 
 func TestAggregator_Run(t *testing.T) {
-	tickChan := make(chan binance.Tick)
+	tickChan := make(chan source.Tick)
 	candleChan := make(chan aggregator.Candle)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -21,10 +23,11 @@ func TestAggregator_Run(t *testing.T) {
 
 	// I am sending a test tick to the tickChan channel
 	now := time.Now()
-	tick := binance.Tick{
+	tick := source.Tick{
+		Exchange:  "binance",
 		Symbol:    "BTCUSDT",
-		Price:     10000.0,
-		Quantity:  0.5,
+		Price:     decimal.NewFromInt(10000),
+		Quantity:  decimal.RequireFromString("0.5"),
 		Timestamp: now,
 	}
 	tickChan <- tick
@@ -42,10 +45,524 @@ func TestAggregator_Run(t *testing.T) {
 	if finalizedCandle.Symbol != "BTCUSDT" {
 		t.Errorf("Expected symbol BTCUSDT, got %s", finalizedCandle.Symbol)
 	}
-	if finalizedCandle.Open != 10000.0 || finalizedCandle.Close != 10000.0 {
-		t.Errorf("Expected open/close to be 10000.0, got %f/%f", finalizedCandle.Open, finalizedCandle.Close)
+	if !finalizedCandle.Open.Equal(tick.Price) || !finalizedCandle.Close.Equal(tick.Price) {
+		t.Errorf("Expected open/close to be 10000, got %s/%s", finalizedCandle.Open, finalizedCandle.Close)
 	}
 	if !finalizedCandle.Finalized {
 		t.Errorf("Expected candle to be finalized")
 	}
 }
+
+func TestAggregator_MultipleIntervals(t *testing.T) {
+	tickChan := make(chan source.Tick)
+	candleChan := make(chan aggregator.Candle, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	agg := aggregator.NewAggregator(
+		aggregator.WithIntervals(time.Minute),
+		aggregator.WithSymbolIntervals("ETHUSDT", time.Minute, 5*time.Minute),
+		aggregator.WithIdleTimeout(100*time.Millisecond),
+	)
+	go agg.Run(ctx, tickChan, candleChan)
+
+	// Ticks from the past; once the symbols go idle their candles close.
+	base := time.Now().Add(-time.Hour).Truncate(5 * time.Minute)
+	tickChan <- source.Tick{Exchange: "binance", Symbol: "ETHUSDT", Price: decimal.NewFromInt(2000), Quantity: decimal.NewFromInt(1), Timestamp: base}
+	tickChan <- source.Tick{Exchange: "binance", Symbol: "ETHUSDT", Price: decimal.NewFromInt(2010), Quantity: decimal.NewFromInt(2), Timestamp: base.Add(2 * time.Minute)}
+	tickChan <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(10000), Quantity: decimal.NewFromInt(1), Timestamp: base}
+
+	got := make(map[string][]aggregator.Candle)
+	for i := 0; i < 4; i++ {
+		select {
+		case c := <-candleChan:
+			got[c.Symbol+"/"+c.Interval] = append(got[c.Symbol+"/"+c.Interval], c)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("Timeout waiting for candle %d, got %v", i+1, got)
+		}
+	}
+
+	if len(got["ETHUSDT/1m"]) != 2 {
+		t.Errorf("Expected 2 ETHUSDT 1m candles, got %d", len(got["ETHUSDT/1m"]))
+	}
+	if len(got["BTCUSDT/1m"]) != 1 {
+		t.Errorf("Expected 1 BTCUSDT 1m candle, got %d", len(got["BTCUSDT/1m"]))
+	}
+	fiveMin := got["ETHUSDT/5m"]
+	if len(fiveMin) != 1 {
+		t.Fatalf("Expected 1 ETHUSDT 5m candle, got %d", len(fiveMin))
+	}
+	if !fiveMin[0].Open.Equal(decimal.NewFromInt(2000)) || !fiveMin[0].Close.Equal(decimal.NewFromInt(2010)) ||
+		!fiveMin[0].Volume.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("Unexpected 5m candle: %+v", fiveMin[0])
+	}
+	if !fiveMin[0].EndTime.Equal(base.Add(5 * time.Minute)) {
+		t.Errorf("Expected 5m candle to end at %v, got %v", base.Add(5*time.Minute), fiveMin[0].EndTime)
+	}
+}
+
+func TestParseInterval(t *testing.T) {
+	tests := map[string]string{
+		"1s":  "1s",
+		"60s": "1m",
+		"15m": "15m",
+		"4h":  "4h",
+		"24h": "1d",
+		"1d":  "1d",
+	}
+	for in, want := range tests {
+		d, err := aggregator.ParseInterval(in)
+		if err != nil {
+			t.Errorf("ParseInterval(%q) returned error: %v", in, err)
+			continue
+		}
+		if got := aggregator.FormatInterval(d); got != want {
+			t.Errorf("FormatInterval(ParseInterval(%q)) = %q, want %q", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"", "0d", "1x", "500ms"} {
+		if _, err := aggregator.ParseInterval(in); err == nil {
+			t.Errorf("ParseInterval(%q) expected an error", in)
+		}
+	}
+}
+
+func TestHub_CoalescesAndThrottles(t *testing.T) {
+	hub := aggregator.NewHub()
+	sub := hub.Subscribe([]source.Instrument{{Exchange: "binance", Symbol: "BTCUSDT"}}, "1m", 200*time.Millisecond)
+	defer sub.Close()
+
+	start := time.Now().Truncate(time.Minute)
+	publish := func(price int64) {
+		hub.Publish(aggregator.Candle{Exchange: "binance", Symbol: "BTCUSDT", Interval: "1m", Close: decimal.NewFromInt(price), StartTime: start})
+	}
+	hub.Publish(aggregator.Candle{Exchange: "binance", Symbol: "ETHUSDT", Interval: "1m", StartTime: start})
+	hub.Publish(aggregator.Candle{Exchange: "coinbase", Symbol: "BTCUSDT", Interval: "1m", StartTime: start})
+	hub.Publish(aggregator.Candle{Exchange: "binance", Symbol: "BTCUSDT", Interval: "5m", StartTime: start})
+	publish(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	first, err := sub.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if len(first) != 1 || !first[0].Close.Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("Expected only the BTCUSDT 1m update, got %+v", first)
+	}
+
+	sent := time.Now()
+	for price := int64(2); price <= 5; price++ {
+		publish(price)
+	}
+	second, err := sub.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if elapsed := time.Since(sent); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected throttle to delay the second batch, got %v", elapsed)
+	}
+	if len(second) != 1 || !second[0].Close.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("Expected updates to coalesce to the latest bar, got %+v", second)
+	}
+}
+
+func TestAggregator_SeparatesExchanges(t *testing.T) {
+	tickChan := make(chan source.Tick)
+	candleChan := make(chan aggregator.Candle, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	agg := aggregator.NewAggregator()
+	go agg.Run(ctx, tickChan, candleChan)
+
+	now := time.Now()
+	tickChan <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(100), Quantity: decimal.NewFromInt(1), Timestamp: now}
+	tickChan <- source.Tick{Exchange: "bybit", Symbol: "BTCUSDT", Price: decimal.NewFromInt(101), Quantity: decimal.NewFromInt(2), Timestamp: now}
+	time.Sleep(50 * time.Millisecond)
+
+	for exchange, price := range map[string]int64{"binance": 100, "bybit": 101} {
+		inst := source.Instrument{Exchange: exchange, Symbol: "BTCUSDT"}
+		candle := agg.GetCurrentCandle(inst, "1m")
+		if candle == nil {
+			t.Fatalf("Expected a current candle for %s", inst)
+		}
+		if candle.Exchange != exchange || !candle.Close.Equal(decimal.NewFromInt(price)) {
+			t.Errorf("Unexpected candle for %s: %+v", inst, candle)
+		}
+	}
+}
+
+func TestAggregator_ExactDecimals(t *testing.T) {
+	tickChan := make(chan source.Tick)
+	candleChan := make(chan aggregator.Candle, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	agg := aggregator.NewAggregator()
+	go agg.Run(ctx, tickChan, candleChan)
+
+	// Sub-satoshi prices and volumes that do not sum exactly in float64.
+	now := time.Now()
+	for _, tick := range []struct{ price, qty string }{
+		{"0.00000123", "0.1"},
+		{"0.00000125", "0.2"},
+		{"0.00000121", "0.3"},
+	} {
+		tickChan <- source.Tick{
+			Exchange:  "binance",
+			Symbol:    "PEPEUSDT",
+			Price:     decimal.RequireFromString(tick.price),
+			Quantity:  decimal.RequireFromString(tick.qty),
+			Timestamp: now,
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	candle := agg.GetCurrentCandle(source.Instrument{Exchange: "binance", Symbol: "PEPEUSDT"}, "1m")
+	if candle == nil {
+		t.Fatal("Expected a current candle")
+	}
+	got := []string{candle.Open.String(), candle.High.String(), candle.Low.String(), candle.Close.String(), candle.Volume.String()}
+	want := []string{"0.00000123", "0.00000125", "0.00000121", "0.00000121", "0.6"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected OHLCV %v, got %v", want, got)
+			break
+		}
+	}
+}
+
+func TestAggregator_OrderFlow(t *testing.T) {
+	tickChan := make(chan source.Tick)
+	candleChan := make(chan aggregator.Candle, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	agg := aggregator.NewAggregator()
+	go agg.Run(ctx, tickChan, candleChan)
+
+	now := time.Now()
+	for _, tick := range []source.Tick{
+		{Price: decimal.NewFromInt(100), Quantity: decimal.NewFromInt(1), Side: source.SideBuy, Trades: 3},
+		{Price: decimal.NewFromInt(103), Quantity: decimal.NewFromInt(2), Side: source.SideSell, Trades: 1},
+		{Price: decimal.NewFromInt(101), Quantity: decimal.NewFromInt(1)},
+	} {
+		tick.Exchange, tick.Symbol, tick.Timestamp = "binance", "BTCUSDT", now
+		tickChan <- tick
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	candle := agg.GetCurrentCandle(source.Instrument{Exchange: "binance", Symbol: "BTCUSDT"}, "1m")
+	if candle == nil {
+		t.Fatal("Expected a current candle")
+	}
+	// 100*1 + 103*2 + 101*1 = 407 over 4 units.
+	if candle.QuoteVolume.String() != "407" || candle.VWAP.String() != "101.75" {
+		t.Errorf("Expected quote volume 407 and VWAP 101.75, got %s and %s", candle.QuoteVolume, candle.VWAP)
+	}
+	if candle.TradeCount != 5 {
+		t.Errorf("Expected 5 trades, got %d", candle.TradeCount)
+	}
+	if candle.TakerBuyVolume.String() != "1" || candle.TakerSellVolume.String() != "2" {
+		t.Errorf("Expected taker buy/sell volume 1/2, got %s/%s", candle.TakerBuyVolume, candle.TakerSellVolume)
+	}
+}
+
+func TestAggregator_EventTimeWatermark(t *testing.T) {
+	tickChan := make(chan source.Tick)
+	candleChan := make(chan aggregator.Candle, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	agg := aggregator.NewAggregator(aggregator.WithAllowedLateness(10*time.Second), aggregator.WithIdleTimeout(0))
+	go agg.Run(ctx, tickChan, candleChan)
+
+	// Long past by wall clock, but only event time may close the window.
+	base := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	tick := func(offset time.Duration) {
+		tickChan <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(1), Quantity: decimal.NewFromInt(1), Timestamp: base.Add(offset)}
+	}
+
+	tick(0)
+	tick(65 * time.Second) // next window, but within the allowed lateness
+	select {
+	case c := <-candleChan:
+		t.Fatalf("Expected the first window to stay open within the allowed lateness, got %+v", c)
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	tick(5 * time.Second) // late but allowed, lands in the open window
+	tick(71 * time.Second)
+	select {
+	case c := <-candleChan:
+		if !c.StartTime.Equal(base) || c.TradeCount != 2 {
+			t.Errorf("Expected the first window with both its ticks, got %+v", c)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timeout waiting for the watermark to finalize the first window")
+	}
+}
+
+func TestAggregator_LateTicks(t *testing.T) {
+	for _, policy := range []aggregator.LatePolicy{aggregator.LateDrop, aggregator.LateAmend} {
+		t.Run(policy.String(), func(t *testing.T) {
+			tickChan := make(chan source.Tick)
+			candleChan := make(chan aggregator.Candle, 10)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			agg := aggregator.NewAggregator(aggregator.WithAllowedLateness(0), aggregator.WithLatePolicy(policy))
+			go agg.Run(ctx, tickChan, candleChan)
+
+			base := time.Now().Add(-time.Hour).Truncate(time.Minute)
+			tick := func(offset time.Duration, qty int64) {
+				tickChan <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(1), Quantity: decimal.NewFromInt(qty), Timestamp: base.Add(offset)}
+			}
+
+			tick(0, 1)
+			tick(time.Minute, 1)
+			select {
+			case c := <-candleChan:
+				if !c.StartTime.Equal(base) || c.Revision != 0 {
+					t.Fatalf("Expected the first window, got %+v", c)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatal("Timeout waiting for the first window")
+			}
+
+			tick(30*time.Second, 2)
+			time.Sleep(50 * time.Millisecond)
+
+			late := agg.LateStats()
+			switch policy {
+			case aggregator.LateDrop:
+				if late.Dropped != 1 || late.Amended != 0 {
+					t.Errorf("Expected one dropped late tick, got %+v", late)
+				}
+				select {
+				case c := <-candleChan:
+					t.Errorf("Expected no candle for a dropped tick, got %+v", c)
+				default:
+				}
+			case aggregator.LateAmend:
+				if late.Amended != 1 || late.Dropped != 0 {
+					t.Errorf("Expected one amended late tick, got %+v", late)
+				}
+				select {
+				case c := <-candleChan:
+					if !c.StartTime.Equal(base) || c.Revision != 1 || c.Volume.String() != "3" || !c.Finalized {
+						t.Errorf("Expected a correction to the first window, got %+v", c)
+					}
+				default:
+					t.Error("Expected a correction to be emitted")
+				}
+			}
+
+			if current := agg.GetCurrentCandle(source.Instrument{Exchange: "binance", Symbol: "BTCUSDT"}, "1m"); current == nil || !current.StartTime.Equal(base.Add(time.Minute)) {
+				t.Errorf("Expected the late tick not to reopen its window, current candle %+v", current)
+			}
+		})
+	}
+}
+
+func TestAggregator_GapFill(t *testing.T) {
+	tickChan := make(chan source.Tick)
+	candleChan := make(chan aggregator.Candle, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	agg := aggregator.NewAggregator(
+		aggregator.WithAllowedLateness(0),
+		aggregator.WithIdleTimeout(0),
+		aggregator.WithGapFill("binance:BTCUSDT"),
+	)
+	go agg.Run(ctx, tickChan, candleChan)
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	tick := func(symbol string, offset time.Duration, price int64) {
+		tickChan <- source.Tick{Exchange: "binance", Symbol: symbol, Price: decimal.NewFromInt(price), Quantity: decimal.NewFromInt(1), Timestamp: base.Add(offset)}
+	}
+
+	// Two silent minutes between the first and the last trade.
+	tick("BTCUSDT", 0, 100)
+	tick("BTCUSDT", 10*time.Second, 105)
+	tick("BTCUSDT", 3*time.Minute, 110)
+	tick("BTCUSDT", 4*time.Minute, 120)
+	// Not gap filled.
+	tick("ETHUSDT", 0, 10)
+	tick("ETHUSDT", 3*time.Minute, 11)
+	tick("ETHUSDT", 4*time.Minute, 12)
+
+	var btc, eth []aggregator.Candle
+	deadline := time.After(3 * time.Second)
+	for len(btc) < 4 || len(eth) < 2 {
+		select {
+		case c := <-candleChan:
+			if c.Symbol == "BTCUSDT" {
+				btc = append(btc, c)
+			} else {
+				eth = append(eth, c)
+			}
+		case <-deadline:
+			t.Fatalf("Timeout waiting for candles, got %d BTCUSDT and %d ETHUSDT", len(btc), len(eth))
+		}
+	}
+
+	for i, c := range btc {
+		if want := base.Add(time.Duration(i) * time.Minute); !c.StartTime.Equal(want) {
+			t.Fatalf("Expected a dense series, candle %d starts at %v, want %v", i, c.StartTime, want)
+		}
+	}
+	for _, c := range btc[1:3] {
+		if !c.Synthetic || !c.Finalized || !c.Volume.IsZero() || c.TradeCount != 0 {
+			t.Errorf("Expected a finalized synthetic candle without volume, got %+v", c)
+		}
+		for _, price := range []decimal.Decimal{c.Open, c.High, c.Low, c.Close} {
+			if !price.Equal(decimal.NewFromInt(105)) {
+				t.Errorf("Expected a flat candle at the previous close 105, got %+v", c)
+			}
+		}
+	}
+	if btc[0].Synthetic || btc[3].Synthetic {
+		t.Errorf("Expected traded candles not to be synthetic, got %+v and %+v", btc[0], btc[3])
+	}
+	if eth[1].StartTime.Sub(eth[0].StartTime) != 3*time.Minute {
+		t.Errorf("Expected ETHUSDT to keep its gap, got candles at %v and %v", eth[0].StartTime, eth[1].StartTime)
+	}
+
+	// An idle symbol is filled as its watermark moves on.
+	agg2 := aggregator.NewAggregator(
+		aggregator.WithIntervals(time.Second),
+		aggregator.WithAllowedLateness(0),
+		aggregator.WithIdleTimeout(100*time.Millisecond),
+		aggregator.WithGapFill("BTCUSDT"),
+	)
+	tickChan2 := make(chan source.Tick)
+	candleChan2 := make(chan aggregator.Candle, 10)
+	go agg2.Run(ctx, tickChan2, candleChan2)
+
+	tickChan2 <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(100), Quantity: decimal.NewFromInt(1), Timestamp: time.Now()}
+	for i := 0; i < 3; i++ {
+		select {
+		case c := <-candleChan2:
+			if c.Synthetic != (i > 0) {
+				t.Errorf("Candle %d: expected synthetic %v, got %+v", i, i > 0, c)
+			}
+		case <-time.After(4 * time.Second):
+			t.Fatalf("Timeout waiting for candle %d of an idle symbol", i)
+		}
+	}
+}
+
+func TestParseBarSpec(t *testing.T) {
+	for in, want := range map[string]string{
+		"tick:1000":      "tick:1000",
+		"volume:50.0":    "volume:50",
+		"dollar:1000000": "dollar:1000000",
+		" range:0.25 ":   "range:0.25",
+	} {
+		spec, err := aggregator.ParseBarSpec(in)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", in, err)
+			continue
+		}
+		if spec.String() != want {
+			t.Errorf("%q: expected %q, got %q", in, want, spec)
+		}
+	}
+	for _, in := range []string{"1m", "tick", "tick:1.5", "volume:0", "range:-1", "renko:10", "dollar:abc"} {
+		if _, err := aggregator.ParseBarSpec(in); err == nil {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestAggregator_InformationBars(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	ticks := []source.Tick{
+		{Price: decimal.NewFromInt(100), Quantity: decimal.NewFromInt(2), Trades: 1},
+		{Price: decimal.NewFromInt(102), Quantity: decimal.NewFromInt(3), Trades: 2},
+		{Price: decimal.NewFromInt(97), Quantity: decimal.NewFromInt(1), Trades: 1},
+		{Price: decimal.NewFromInt(99), Quantity: decimal.NewFromInt(4), Trades: 1},
+		{Price: decimal.NewFromInt(103), Quantity: decimal.NewFromInt(1), Trades: 1},
+	}
+	for i := range ticks {
+		ticks[i].Exchange = "binance"
+		ticks[i].Symbol = "BTCUSDT"
+		// The first two ticks share a timestamp.
+		ticks[i].Timestamp = base.Add(time.Duration(max(i, 1)) * time.Second)
+	}
+
+	tests := []struct {
+		bar string
+		// closes lists the close of each finalized bar and volumes their
+		// volume, ending with the bar cut short on shutdown.
+		closes  []int64
+		volumes []int64
+	}{
+		{bar: "tick:3", closes: []int64{102, 103}, volumes: []int64{5, 6}},
+		{bar: "volume:5", closes: []int64{102, 99, 103}, volumes: []int64{5, 5, 1}},
+		{bar: "dollar:300", closes: []int64{102, 99, 103}, volumes: []int64{5, 5, 1}},
+		{bar: "range:5", closes: []int64{97, 103}, volumes: []int64{6, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.bar, func(t *testing.T) {
+			spec, err := aggregator.ParseBarSpec(tt.bar)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tickChan := make(chan source.Tick)
+			candleChan := make(chan aggregator.Candle, 20)
+
+			agg := aggregator.NewAggregator(
+				aggregator.WithIntervals(time.Hour),
+				aggregator.WithIdleTimeout(0),
+				aggregator.WithSymbolBars("binance:BTCUSDT", spec),
+			)
+			done := make(chan struct{})
+			go func() {
+				agg.Run(context.Background(), tickChan, candleChan)
+				close(done)
+			}()
+			for _, tick := range ticks {
+				tickChan <- tick
+			}
+			close(tickChan)
+			<-done
+
+			var bars []aggregator.Candle
+			for c := range candleChan {
+				if c.Interval == tt.bar {
+					bars = append(bars, c)
+				}
+			}
+			if len(bars) != len(tt.closes) {
+				t.Fatalf("Expected %d bars, got %d: %+v", len(tt.closes), len(bars), bars)
+			}
+			for i, bar := range bars {
+				if bar.BarType != spec.Type || !bar.Finalized {
+					t.Errorf("Bar %d: expected a finalized %s bar, got %+v", i, spec.Type, bar)
+				}
+				if !bar.Close.Equal(decimal.NewFromInt(tt.closes[i])) || !bar.Volume.Equal(decimal.NewFromInt(tt.volumes[i])) {
+					t.Errorf("Bar %d: expected close %d volume %d, got %s %s", i, tt.closes[i], tt.volumes[i], bar.Close, bar.Volume)
+				}
+				if i > 0 && !bar.StartTime.After(bars[i-1].StartTime) {
+					t.Errorf("Bar %d: start %v not after the previous bar's %v", i, bar.StartTime, bars[i-1].StartTime)
+				}
+				if bar.EndTime.Before(bar.StartTime) {
+					t.Errorf("Bar %d: ends %v before it starts %v", i, bar.EndTime, bar.StartTime)
+				}
+			}
+		})
+	}
+}
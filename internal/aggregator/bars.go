@@ -0,0 +1,141 @@
+package aggregator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/source"
+)
+
+// BarType says what closes a candle.
+type BarType string
+
+const (
+	// BarTime closes at the end of a fixed time interval.
+	BarTime BarType = "time"
+	// BarTick closes once it holds N trades.
+	BarTick BarType = "tick"
+	// BarVolume closes once its base volume reaches N.
+	BarVolume BarType = "volume"
+	// BarDollar closes once its quote volume reaches N.
+	BarDollar BarType = "dollar"
+	// BarRange closes once its high and low are N apart.
+	BarRange BarType = "range"
+)
+
+// BarSpec describes an information driven bar series such as "volume:50".
+// Its String form is the interval label its candles carry.
+type BarSpec struct {
+	Type      BarType
+	Threshold decimal.Decimal
+}
+
+// ParseBarSpec parses "type:threshold", e.g. "tick:1000", "volume:50",
+// "dollar:1000000" or "range:25.5".
+func ParseBarSpec(s string) (BarSpec, error) {
+	kind, threshold, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return BarSpec{}, fmt.Errorf("invalid bar %q: want type:threshold", s)
+	}
+	spec := BarSpec{Type: BarType(kind)}
+	switch spec.Type {
+	case BarTick, BarVolume, BarDollar, BarRange:
+	default:
+		return BarSpec{}, fmt.Errorf("invalid bar %q: unknown type %q", s, kind)
+	}
+
+	var err error
+	if spec.Threshold, err = decimal.NewFromString(threshold); err != nil {
+		return BarSpec{}, fmt.Errorf("invalid bar %q: %w", s, err)
+	}
+	if !spec.Threshold.IsPositive() {
+		return BarSpec{}, fmt.Errorf("invalid bar %q: threshold must be positive", s)
+	}
+	if spec.Type == BarTick && !spec.Threshold.IsInteger() {
+		return BarSpec{}, fmt.Errorf("invalid bar %q: trade count must be whole", s)
+	}
+	return spec, nil
+}
+
+func (s BarSpec) String() string {
+	return string(s.Type) + ":" + s.Threshold.String()
+}
+
+// full reports whether the bar has reached its threshold. The trade that
+// reaches it is part of the bar; trades are never split.
+func (s BarSpec) full(c *Candle) bool {
+	switch s.Type {
+	case BarTick:
+		return decimal.NewFromInt(c.TradeCount).GreaterThanOrEqual(s.Threshold)
+	case BarVolume:
+		return c.Volume.GreaterThanOrEqual(s.Threshold)
+	case BarDollar:
+		return c.QuoteVolume.GreaterThanOrEqual(s.Threshold)
+	case BarRange:
+		return c.High.Sub(c.Low).GreaterThanOrEqual(s.Threshold)
+	default:
+		return false
+	}
+}
+
+// WithSymbolBars builds information driven bars for a symbol alongside its
+// time bars. The symbol may be qualified as "exchange:symbol" to apply to one
+// venue only.
+func WithSymbolBars(symbol string, specs ...BarSpec) Option {
+	return func(a *Aggregator) {
+		a.symbolBars[symbol] = specs
+	}
+}
+
+func (a *Aggregator) barsFor(inst source.Instrument) []BarSpec {
+	if specs, ok := a.symbolBars[inst.String()]; ok {
+		return specs
+	}
+	return a.symbolBars[inst.Symbol]
+}
+
+// processBars adds a tick to the instrument's information driven bars in
+// arrival order, finalizing those it fills. A bar runs from its first tick to
+// its last; start times are nudged forward a microsecond where needed to stay
+// unique within the series. It must be called with a.mu held.
+func (a *Aggregator) processBars(tick source.Tick, candleChan chan<- Candle) {
+	inst := tick.Instrument()
+	for _, spec := range a.barsFor(inst) {
+		label := spec.String()
+		key := seriesKey(inst, label)
+
+		bar, ok := a.bars[key]
+		if !ok {
+			start := tick.Timestamp
+			if last, ok := a.last[key]; ok && !start.After(last.StartTime) {
+				start = last.StartTime.Add(time.Microsecond)
+			}
+			bar = &Candle{
+				Exchange:  tick.Exchange,
+				Symbol:    tick.Symbol,
+				Interval:  label,
+				BarType:   spec.Type,
+				Open:      tick.Price,
+				High:      tick.Price,
+				Low:       tick.Price,
+				StartTime: start,
+				EndTime:   start,
+			}
+			a.bars[key] = bar
+		}
+		bar.add(tick)
+		if tick.Timestamp.After(bar.EndTime) {
+			bar.EndTime = tick.Timestamp
+		}
+
+		if spec.full(bar) {
+			delete(a.bars, key)
+			a.finalize(*bar, candleChan)
+			continue
+		}
+		a.hub.Publish(*bar)
+	}
+}
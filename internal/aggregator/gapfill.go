@@ -0,0 +1,63 @@
+package aggregator
+
+import (
+	"time"
+
+	"github.com/shubie/trading/internal/source"
+)
+
+// WithGapFill emits a flat synthetic candle for every interval in which the
+// symbol did not trade, so its series has no holes. The symbol may be
+// qualified as "exchange:symbol" to apply to one venue only. Filling starts
+// after the first real candle, whose close the flat candles carry forward.
+func WithGapFill(symbols ...string) Option {
+	return func(a *Aggregator) {
+		for _, symbol := range symbols {
+			a.gapFill[symbol] = true
+		}
+	}
+}
+
+func (a *Aggregator) gapFills(inst source.Instrument) bool {
+	return a.gapFill[inst.String()] || a.gapFill[inst.Symbol]
+}
+
+// fill finalizes flat candles for the empty windows between the series'
+// last finalized candle and until. It must be called with a.mu held.
+func (a *Aggregator) fill(inst source.Instrument, label string, interval time.Duration, until time.Time, candleChan chan<- Candle) {
+	if !a.gapFills(inst) {
+		return
+	}
+	last, ok := a.last[seriesKey(inst, label)]
+	if !ok {
+		return
+	}
+	for start := last.EndTime; start.Before(until); start = start.Add(interval) {
+		a.finalize(Candle{
+			Exchange:  inst.Exchange,
+			Symbol:    inst.Symbol,
+			Interval:  label,
+			BarType:   BarTime,
+			Open:      last.Close,
+			High:      last.Close,
+			Low:       last.Close,
+			Close:     last.Close,
+			StartTime: start,
+			EndTime:   start.Add(interval),
+			Synthetic: true,
+		}, candleChan)
+	}
+}
+
+// fillIdle fills the windows of gap filled instruments that their watermark
+// has passed without a tick. It must be called with a.mu held.
+func (a *Aggregator) fillIdle(marks map[source.Instrument]time.Time, candleChan chan<- Candle) {
+	for inst, mark := range marks {
+		if !a.gapFills(inst) {
+			continue
+		}
+		for _, interval := range a.intervalsFor(inst) {
+			a.fill(inst, FormatInterval(interval), interval, mark.Truncate(interval), candleChan)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package aggregator
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shubie/trading/internal/source"
+)
+
+// Hub fans candle updates out to subscribers. Publishing never blocks: each
+// subscription keeps only the latest state of every bar it has not yet
+// consumed, so a slow reader sees fewer, fresher updates instead of stalling
+// the aggregator.
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+type Subscription struct {
+	hub      *Hub
+	insts    map[source.Instrument]struct{}
+	interval string
+	throttle time.Duration
+	lastSent time.Time
+
+	mu      sync.Mutex
+	pending map[string]Candle
+	notify  chan struct{}
+}
+
+// Subscribe registers interest in candles of one interval for the given
+// instruments. A positive throttle limits how often Next returns.
+func (h *Hub) Subscribe(insts []source.Instrument, interval string, throttle time.Duration) *Subscription {
+	sub := &Subscription{
+		hub:      h,
+		insts:    make(map[source.Instrument]struct{}, len(insts)),
+		interval: interval,
+		throttle: throttle,
+		pending:  make(map[string]Candle),
+		notify:   make(chan struct{}, 1),
+	}
+	for _, inst := range insts {
+		sub.insts[inst] = struct{}{}
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+func (h *Hub) Publish(candle Candle) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subs {
+		if sub.matches(candle) {
+			sub.offer(candle)
+		}
+	}
+}
+
+func (s *Subscription) matches(candle Candle) bool {
+	if candle.Interval != s.interval {
+		return false
+	}
+	_, ok := s.insts[candle.Instrument()]
+	return ok
+}
+
+func (s *Subscription) offer(candle Candle) {
+	s.mu.Lock()
+	s.pending[candleKey(candle.Instrument(), candle.Interval, candle.StartTime)] = candle
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Next blocks until at least one update is pending and returns the pending
+// candles ordered by start time. It returns ctx.Err() once ctx is done.
+func (s *Subscription) Next(ctx context.Context) ([]Candle, error) {
+	if s.throttle > 0 && !s.lastSent.IsZero() {
+		if wait := s.throttle - time.Since(s.lastSent); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	select {
+	case <-s.notify:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	candles := make([]Candle, 0, len(s.pending))
+	for key, candle := range s.pending {
+		candles = append(candles, candle)
+		delete(s.pending, key)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(candles, func(i, j int) bool {
+		if !candles[i].StartTime.Equal(candles[j].StartTime) {
+			return candles[i].StartTime.Before(candles[j].StartTime)
+		}
+		return candles[i].Instrument().String() < candles[j].Instrument().String()
+	})
+	s.lastSent = time.Now()
+	return candles, nil
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	delete(s.hub.subs, s)
+	s.hub.mu.Unlock()
+}
@@ -0,0 +1,47 @@
+package aggregator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultInterval = time.Minute
+
+// ParseInterval accepts Go duration strings plus a "d" suffix for whole days,
+// e.g. "1s", "15m", "4h", "1d".
+func ParseInterval(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid interval %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q: %w", s, err)
+	}
+	if d < time.Second || d%time.Second != 0 {
+		return 0, fmt.Errorf("invalid interval %q: must be a whole number of seconds", s)
+	}
+	return d, nil
+}
+
+// FormatInterval renders an interval using its largest whole unit, which is
+// the label stored alongside candles and used by clients ("1m", "4h", "1d").
+func FormatInterval(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
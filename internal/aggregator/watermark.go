@@ -0,0 +1,199 @@
+package aggregator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shubie/trading/internal/source"
+)
+
+const (
+	// DefaultAllowedLateness is how far behind the newest tick of an
+	// instrument a tick may be and still land in an open candle.
+	DefaultAllowedLateness = time.Second
+
+	// DefaultIdleTimeout is how long an instrument may go without ticks
+	// before its candles are closed by wall clock instead.
+	DefaultIdleTimeout = 5 * time.Second
+)
+
+// LatePolicy decides what happens to a tick whose candle has already been
+// finalized.
+type LatePolicy int
+
+const (
+	// LateDrop counts the tick and discards it.
+	LateDrop LatePolicy = iota
+	// LateAmend folds the tick into the finalized candle and re-emits it
+	// with a higher Revision as a correction.
+	LateAmend
+)
+
+func (p LatePolicy) String() string {
+	switch p {
+	case LateAmend:
+		return "amend"
+	default:
+		return "drop"
+	}
+}
+
+// ParseLatePolicy parses "drop" or "amend".
+func ParseLatePolicy(s string) (LatePolicy, error) {
+	switch s {
+	case "", "drop":
+		return LateDrop, nil
+	case "amend":
+		return LateAmend, nil
+	default:
+		return LateDrop, fmt.Errorf("unknown late tick policy %q", s)
+	}
+}
+
+// LateStats counts candle updates from ticks that arrived after their
+// window was finalized. A tick counts once per interval it was late for.
+type LateStats struct {
+	Dropped uint64
+	Amended uint64
+}
+
+// WithAllowedLateness sets how far a tick may trail the newest tick of its
+// instrument before its window is closed.
+func WithAllowedLateness(d time.Duration) Option {
+	return func(a *Aggregator) {
+		a.allowedLateness = d
+	}
+}
+
+// WithIdleTimeout closes the candles of an instrument that has had no ticks
+// for d by wall clock, so illiquid symbols still finalize. Zero waits for
+// the next tick however long that takes.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(a *Aggregator) {
+		a.idleTimeout = d
+	}
+}
+
+// WithLatePolicy sets how ticks for already finalized candles are handled.
+func WithLatePolicy(p LatePolicy) Option {
+	return func(a *Aggregator) {
+		a.latePolicy = p
+	}
+}
+
+// watermark tracks event time for one instrument. Candles are finalized
+// once mark reaches their EndTime; mark only moves forward.
+type watermark struct {
+	maxEvent    time.Time // newest tick timestamp
+	lastArrival time.Time // wall clock time the newest tick arrived
+	mark        time.Time
+}
+
+func (a *Aggregator) watermarkFor(inst source.Instrument) *watermark {
+	w, ok := a.watermarks[inst]
+	if !ok {
+		w = &watermark{}
+		a.watermarks[inst] = w
+	}
+	return w
+}
+
+// advance moves the watermark up to the newest tick less the allowed
+// lateness or, for an idle instrument, up to the wall clock less the
+// allowed lateness.
+func (a *Aggregator) advance(w *watermark, now time.Time) time.Time {
+	mark := w.maxEvent.Add(-a.allowedLateness)
+	if a.idleTimeout > 0 && now.Sub(w.lastArrival) >= a.idleTimeout {
+		if idle := now.Add(-a.allowedLateness); idle.After(mark) {
+			mark = idle
+		}
+	}
+	if mark.After(w.mark) {
+		w.mark = mark
+	}
+	return w.mark
+}
+
+// late applies the late tick policy to a tick whose window has been
+// finalized. It must be called with a.mu held.
+func (a *Aggregator) late(tick source.Tick, label string, start time.Time, interval time.Duration, candleChan chan<- Candle) {
+	if a.latePolicy == LateAmend {
+		if candle, ok := a.amend(tick, label, start, interval); ok {
+			a.lateStats.Amended++
+			candleChan <- candle
+			a.hub.Publish(candle)
+			return
+		}
+	}
+	a.lateStats.Dropped++
+}
+
+// amend folds a late tick into the remembered finalized candle for its
+// window. A window that produced no candle gets one, unless it is older than
+// every remembered candle, in which case a stored candle may exist that
+// cannot be amended here.
+func (a *Aggregator) amend(tick source.Tick, label string, start time.Time, interval time.Duration) (Candle, bool) {
+	key := seriesKey(tick.Instrument(), label)
+	recent := a.recent[key]
+	for i := range recent {
+		if recent[i].StartTime.Equal(start) {
+			if recent[i].Synthetic {
+				// The window did trade after all; the tick replaces the
+				// flat fill rather than extending it.
+				recent[i] = Candle{
+					Exchange:  tick.Exchange,
+					Symbol:    tick.Symbol,
+					Interval:  label,
+					BarType:   BarTime,
+					Open:      tick.Price,
+					High:      tick.Price,
+					Low:       tick.Price,
+					StartTime: start,
+					EndTime:   start.Add(interval),
+					Finalized: true,
+					Revision:  recent[i].Revision,
+				}
+			}
+			recent[i].add(tick)
+			recent[i].Revision++
+			if a.last[key].StartTime.Equal(start) {
+				a.last[key] = recent[i]
+			}
+			return recent[i], true
+		}
+	}
+
+	if len(recent) > 0 {
+		oldest := recent[0].StartTime
+		for _, candle := range recent[1:] {
+			if candle.StartTime.Before(oldest) {
+				oldest = candle.StartTime
+			}
+		}
+		if start.Before(oldest) {
+			return Candle{}, false
+		}
+	}
+	candle := Candle{
+		Exchange:  tick.Exchange,
+		Symbol:    tick.Symbol,
+		Interval:  label,
+		BarType:   BarTime,
+		Open:      tick.Price,
+		High:      tick.Price,
+		Low:       tick.Price,
+		StartTime: start,
+		EndTime:   start.Add(interval),
+		Finalized: true,
+	}
+	candle.add(tick)
+	a.remember(candle)
+	return candle, true
+}
+
+// LateStats returns how many late candle updates were dropped or amended.
+func (a *Aggregator) LateStats() LateStats {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.lateStats
+}
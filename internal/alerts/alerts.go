@@ -0,0 +1,146 @@
+// Package alerts checks user defined rules against live ticks and finalized
+// candles and delivers their triggers to stream subscribers and webhooks.
+package alerts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+)
+
+// Kind is the rule an alert checks.
+type Kind string
+
+const (
+	// PriceCross fires when a trade crosses Threshold.
+	PriceCross Kind = "price_cross"
+	// CandleRange fires when a finalized candle's high - low exceeds
+	// Threshold percent of its open.
+	CandleRange Kind = "candle_range"
+	// VolumeSpike fires when a finalized candle's volume is at least
+	// Threshold times the average volume of the Lookback candles before it.
+	VolumeSpike Kind = "volume_spike"
+)
+
+// Direction narrows which crossings fire a PriceCross alert.
+type Direction string
+
+const (
+	Any  Direction = "any"
+	Up   Direction = "up"
+	Down Direction = "down"
+)
+
+const (
+	DefaultLookback = 20
+	maxLookback     = 500
+)
+
+var ErrNotFound = errors.New("alert not found")
+
+// Alert is a rule on one instrument.
+type Alert struct {
+	ID        string
+	Exchange  string
+	Symbol    string
+	Kind      Kind
+	Interval  string // candle interval label for candle rules
+	Threshold decimal.Decimal
+	Direction Direction
+	Lookback  int
+	// Cooldown is the minimum time between two triggers; zero uses the
+	// engine's default.
+	Cooldown      time.Duration
+	WebhookURL    string
+	CreatedAt     time.Time
+	LastTriggered time.Time
+}
+
+func (a Alert) Instrument() source.Instrument {
+	return source.Instrument{Exchange: a.Exchange, Symbol: a.Symbol}
+}
+
+// Event is one trigger of an alert.
+type Event struct {
+	ID       string          `json:"id"`
+	AlertID  string          `json:"alert_id"`
+	Exchange string          `json:"exchange"`
+	Symbol   string          `json:"symbol"`
+	Kind     Kind            `json:"kind"`
+	Interval string          `json:"interval,omitempty"`
+	Value    decimal.Decimal `json:"value"` // the price, range percent or volume multiple
+	Message  string          `json:"message"`
+	// TriggeredAt is the event time of the trade or the candle end.
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// normalize validates an alert and fills in its defaults.
+func normalize(a Alert) (Alert, error) {
+	if a.Symbol == "" {
+		return Alert{}, errors.New("symbol is required")
+	}
+	if a.Exchange == "" {
+		inst := source.ParseInstrument(a.Symbol)
+		a.Exchange, a.Symbol = inst.Exchange, inst.Symbol
+	}
+	if !a.Threshold.IsPositive() {
+		return Alert{}, fmt.Errorf("threshold must be positive, got %s", a.Threshold)
+	}
+	if a.Cooldown < 0 {
+		return Alert{}, errors.New("cooldown must not be negative")
+	}
+	if a.WebhookURL != "" {
+		u, err := url.Parse(a.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Alert{}, fmt.Errorf("invalid webhook url %q", a.WebhookURL)
+		}
+	}
+
+	switch a.Kind {
+	case PriceCross:
+		a.Interval, a.Lookback = "", 0
+		switch a.Direction {
+		case "":
+			a.Direction = Any
+		case Any, Up, Down:
+		default:
+			return Alert{}, fmt.Errorf("unknown direction %q", a.Direction)
+		}
+	case CandleRange, VolumeSpike:
+		d, err := aggregator.ParseInterval(a.Interval)
+		if err != nil {
+			return Alert{}, err
+		}
+		a.Interval = aggregator.FormatInterval(d)
+		a.Direction = ""
+		if a.Kind == CandleRange {
+			a.Lookback = 0
+			break
+		}
+		if a.Lookback == 0 {
+			a.Lookback = DefaultLookback
+		}
+		if a.Lookback < 1 || a.Lookback > maxLookback {
+			return Alert{}, fmt.Errorf("lookback must be between 1 and %d", maxLookback)
+		}
+	default:
+		return Alert{}, fmt.Errorf("unknown alert kind %q", a.Kind)
+	}
+	return a, nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("alerts: reading random id: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
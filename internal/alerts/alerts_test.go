@@ -0,0 +1,305 @@
+package alerts_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/alerts"
+	"github.com/shubie/trading/internal/source"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+// run pushes ticks and then candles through an engine and returns the
+// events it published.
+func run(t *testing.T, engine *alerts.Engine, ticks []source.Tick, candles []aggregator.Candle) []alerts.Event {
+	t.Helper()
+	sub := engine.Subscribe(nil)
+	defer sub.Close()
+
+	tickIn := make(chan source.Tick)
+	tickOut := make(chan source.Tick, len(ticks))
+	candleIn := make(chan aggregator.Candle)
+	candleOut := make(chan aggregator.Candle, len(candles))
+
+	done := make(chan struct{})
+	go func() {
+		engine.Run(context.Background(), tickIn, tickOut, candleIn, candleOut)
+		close(done)
+	}()
+	for _, tick := range ticks {
+		tickIn <- tick
+	}
+	close(tickIn)
+	for _, candle := range candles {
+		candleIn <- candle
+	}
+	close(candleIn)
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timeout waiting for the engine to stop")
+	}
+	if len(tickOut) != len(ticks) || len(candleOut) != len(candles) {
+		t.Fatalf("Passed through %d ticks and %d candles, want %d and %d", len(tickOut), len(candleOut), len(ticks), len(candles))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	events, _ := sub.Next(ctx)
+	return events
+}
+
+func TestEngine_PriceCross(t *testing.T) {
+	engine := alerts.NewEngine(alerts.WithDefaultCooldown(time.Minute))
+	ctx := context.Background()
+	up, err := engine.Create(ctx, alerts.Alert{Symbol: "BTCUSDT", Kind: alerts.PriceCross, Threshold: dec("70000"), Direction: alerts.Up})
+	if err != nil {
+		t.Fatal(err)
+	}
+	anyDir, err := engine.Create(ctx, alerts.Alert{Symbol: "BTCUSDT", Kind: alerts.PriceCross, Threshold: dec("70000"), Cooldown: 3 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Now()
+	tick := func(sec int, price string) source.Tick {
+		return source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: dec(price), Quantity: dec("1"), Timestamp: base.Add(time.Duration(sec) * time.Second)}
+	}
+	events := run(t, engine, []source.Tick{
+		tick(0, "69990"),
+		tick(1, "70010"), // up: both fire
+		tick(2, "69990"), // down: any is cooling down
+		tick(3, "70010"), // up: both cooling down
+		tick(5, "69990"), // down: any fires
+		tick(6, "69980"), // no crossing
+		{Exchange: "kraken", Symbol: "BTCUSDT", Price: dec("80000"), Timestamp: base.Add(7 * time.Second)},
+	}, nil)
+
+	var got []string
+	for _, e := range events {
+		got = append(got, e.AlertID+"@"+e.TriggeredAt.Sub(base).String())
+	}
+	want := []string{up.ID + "@1s", anyDir.ID + "@1s", anyDir.ID + "@5s"}
+	if len(got) != len(want) {
+		t.Fatalf("Got events %v, want %v", got, want)
+	}
+	// Alerts fired by the same tick may come in either order.
+	if got[0] != want[0] && got[0] != want[1] || got[2] != want[2] {
+		t.Errorf("Got events %v, want %v", got, want)
+	}
+}
+
+func TestEngine_CandleRules(t *testing.T) {
+	engine := alerts.NewEngine(alerts.WithDefaultCooldown(time.Nanosecond))
+	ctx := context.Background()
+	rangeAlert, err := engine.Create(ctx, alerts.Alert{Symbol: "ETHUSDT", Kind: alerts.CandleRange, Interval: "1m", Threshold: dec("2")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spike, err := engine.Create(ctx, alerts.Alert{Symbol: "ETHUSDT", Kind: alerts.VolumeSpike, Interval: "1m", Threshold: dec("5"), Lookback: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Now().Truncate(time.Minute)
+	candle := func(i int, low, high, volume string) aggregator.Candle {
+		return aggregator.Candle{
+			Exchange: "binance", Symbol: "ETHUSDT", Interval: "1m", BarType: aggregator.BarTime,
+			Open: dec("100"), High: dec(high), Low: dec(low), Close: dec("100"), Volume: dec(volume),
+			StartTime: base.Add(time.Duration(i) * time.Minute), EndTime: base.Add(time.Duration(i+1) * time.Minute),
+			Finalized: true,
+		}
+	}
+	inProgress := candle(4, "90", "110", "100")
+	inProgress.Finalized = false
+	correction := candle(3, "90", "110", "100")
+	correction.Revision = 1
+	fiveMinute := candle(4, "90", "110", "100")
+	fiveMinute.Interval = "5m"
+
+	events := run(t, engine, nil, []aggregator.Candle{
+		candle(0, "99", "101", "10"),
+		candle(1, "99", "101", "10"),
+		candle(2, "98", "100.5", "10"), // 2.5% range
+		candle(3, "99", "101", "49"),   // 4.9x volume
+		inProgress,
+		correction,
+		fiveMinute,
+		candle(4, "99", "101", "115"), // 5x the average of 10, 10 and 49
+	})
+
+	if len(events) != 2 {
+		t.Fatalf("Got %d events, want 2: %+v", len(events), events)
+	}
+	if events[0].AlertID != rangeAlert.ID || !events[0].Value.Equal(dec("2.5")) || !events[0].TriggeredAt.Equal(base.Add(3*time.Minute)) {
+		t.Errorf("Unexpected range event: %+v", events[0])
+	}
+	if events[1].AlertID != spike.ID || !events[1].Value.Equal(dec("5")) {
+		t.Errorf("Unexpected volume event: %+v", events[1])
+	}
+}
+
+type fakeStore struct {
+	mu        sync.Mutex
+	alerts    map[string]alerts.Alert
+	triggered chan string
+}
+
+func (f *fakeStore) SaveAlert(_ context.Context, a alerts.Alert) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.alerts[a.ID] = a
+	return nil
+}
+
+func (f *fakeStore) DeleteAlert(_ context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.alerts, id)
+	return nil
+}
+
+func (f *fakeStore) ListAlerts(context.Context) ([]alerts.Alert, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var list []alerts.Alert
+	for _, a := range f.alerts {
+		list = append(list, a)
+	}
+	return list, nil
+}
+
+func (f *fakeStore) MarkAlertTriggered(_ context.Context, id string, at time.Time) error {
+	f.mu.Lock()
+	a := f.alerts[id]
+	a.LastTriggered = at
+	f.alerts[id] = a
+	f.mu.Unlock()
+	f.triggered <- id
+	return nil
+}
+
+func TestEngine_CRUDAndCooldownSurviveRestart(t *testing.T) {
+	store := &fakeStore{alerts: make(map[string]alerts.Alert), triggered: make(chan string, 10)}
+	engine := alerts.NewEngine(alerts.WithStore(store))
+	ctx := context.Background()
+
+	if _, err := engine.Create(ctx, alerts.Alert{Symbol: "BTCUSDT", Kind: alerts.CandleRange, Threshold: dec("2")}); !errors.Is(err, alerts.ErrInvalid) {
+		t.Errorf("Create without interval: got %v, want ErrInvalid", err)
+	}
+	if err := engine.Delete(ctx, "missing"); !errors.Is(err, alerts.ErrNotFound) {
+		t.Errorf("Delete missing: got %v, want ErrNotFound", err)
+	}
+
+	a, err := engine.Create(ctx, alerts.Alert{Symbol: "coinbase:BTC-USD", Kind: alerts.PriceCross, Threshold: dec("100")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Exchange != "coinbase" || a.Symbol != "BTC-USD" || a.Direction != alerts.Any {
+		t.Errorf("Alert not normalized: %+v", a)
+	}
+	other, err := engine.Create(ctx, alerts.Alert{Symbol: "ETHUSDT", Kind: alerts.PriceCross, Threshold: dec("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Delete(ctx, other.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Now()
+	tick := func(sec int, price string) source.Tick {
+		return source.Tick{Exchange: "coinbase", Symbol: "BTC-USD", Price: dec(price), Timestamp: base.Add(time.Duration(sec) * time.Second)}
+	}
+	if events := run(t, engine, []source.Tick{tick(0, "99"), tick(1, "101")}, nil); len(events) != 1 {
+		t.Fatalf("Got %d events, want 1", len(events))
+	}
+	select {
+	case <-store.triggered:
+	case <-time.After(time.Second):
+		t.Fatal("Trigger was not recorded")
+	}
+
+	// A restarted engine loads the alert with its last trigger, so it is
+	// still cooling down.
+	restarted := alerts.NewEngine(alerts.WithStore(store))
+	if err := restarted.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	list := restarted.List(nil)
+	if len(list) != 1 || list[0].ID != a.ID || !list[0].LastTriggered.Equal(base.Add(time.Second)) {
+		t.Fatalf("Loaded %+v", list)
+	}
+	if events := run(t, restarted, []source.Tick{tick(2, "99"), tick(3, "101")}, nil); len(events) != 0 {
+		t.Errorf("Got %d events during cooldown, want 0", len(events))
+	}
+
+	updated, err := restarted.Update(ctx, alerts.Alert{ID: a.ID, Symbol: "coinbase:BTC-USD", Kind: alerts.PriceCross, Threshold: dec("200")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.CreatedAt.Equal(a.CreatedAt) || !updated.LastTriggered.Equal(list[0].LastTriggered) {
+		t.Errorf("Update lost times: %+v", updated)
+	}
+	if _, err := restarted.Update(ctx, alerts.Alert{ID: "missing", Symbol: "BTCUSDT", Kind: alerts.PriceCross, Threshold: dec("1")}); !errors.Is(err, alerts.ErrNotFound) {
+		t.Errorf("Update missing: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestWebhook_RetriesUntilDelivered(t *testing.T) {
+	var calls atomic.Int32
+	received := make(chan alerts.Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var e alerts.Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("Decoding webhook body: %v", err)
+		}
+		received <- e
+	}))
+	defer srv.Close()
+
+	webhook := alerts.NewWebhook(alerts.WithRetryBackoff(time.Millisecond, time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go webhook.Run(ctx)
+
+	engine := alerts.NewEngine(alerts.WithNotifier(webhook))
+	a, err := engine.Create(ctx, alerts.Alert{Symbol: "BTCUSDT", Kind: alerts.PriceCross, Threshold: dec("70000"), WebhookURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	run(t, engine, []source.Tick{
+		{Exchange: "binance", Symbol: "BTCUSDT", Price: dec("69000"), Timestamp: now},
+		{Exchange: "binance", Symbol: "BTCUSDT", Price: dec("71000"), Timestamp: now},
+	}, nil)
+
+	select {
+	case e := <-received:
+		if e.AlertID != a.ID || !e.Value.Equal(dec("71000")) || e.Kind != alerts.PriceCross {
+			t.Errorf("Unexpected webhook event: %+v", e)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timeout waiting for webhook delivery")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Webhook called %d times, want 2", n)
+	}
+}
@@ -0,0 +1,488 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+)
+
+const (
+	// DefaultCooldown applies to alerts that set none.
+	DefaultCooldown = 5 * time.Minute
+
+	// subscriptionBuffer bounds the events a subscriber may fall behind by;
+	// the oldest are dropped beyond it.
+	subscriptionBuffer = 1000
+
+	storeTimeout = 5 * time.Second
+)
+
+var (
+	hundred = decimal.NewFromInt(100)
+
+	// ErrInvalid wraps the reason an alert was rejected.
+	ErrInvalid = errors.New("invalid alert")
+)
+
+// Store keeps alerts across restarts.
+type Store interface {
+	SaveAlert(ctx context.Context, a Alert) error
+	DeleteAlert(ctx context.Context, id string) error
+	ListAlerts(ctx context.Context) ([]Alert, error)
+	MarkAlertTriggered(ctx context.Context, id string, at time.Time) error
+}
+
+// Notifier delivers an event to an alert's webhook.
+type Notifier interface {
+	Notify(url string, e Event)
+}
+
+type Option func(*Engine)
+
+// WithStore persists alerts and their last trigger times in store.
+func WithStore(store Store) Option {
+	return func(e *Engine) {
+		e.store = store
+	}
+}
+
+// WithNotifier sends events of alerts with a webhook URL to n.
+func WithNotifier(n Notifier) Option {
+	return func(e *Engine) {
+		e.notifier = n
+	}
+}
+
+// WithDefaultCooldown sets the cooldown of alerts that set none.
+func WithDefaultCooldown(d time.Duration) Option {
+	return func(e *Engine) {
+		e.cooldown = d
+	}
+}
+
+// Engine checks alerts against the ticks and candles passing through it.
+type Engine struct {
+	store    Store
+	notifier Notifier
+	cooldown time.Duration
+
+	mu      sync.Mutex
+	alerts  map[string]*Alert
+	prices  map[source.Instrument]decimal.Decimal // last trade price
+	volumes map[string][]decimal.Decimal          // recent finalized volumes per series, oldest first
+
+	subsMu sync.RWMutex
+	subs   map[*Subscription]struct{}
+}
+
+func NewEngine(opts ...Option) *Engine {
+	e := &Engine{
+		cooldown: DefaultCooldown,
+		alerts:   make(map[string]*Alert),
+		prices:   make(map[source.Instrument]decimal.Decimal),
+		volumes:  make(map[string][]decimal.Decimal),
+		subs:     make(map[*Subscription]struct{}),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func seriesKey(inst source.Instrument, interval string) string {
+	return inst.String() + "|" + interval
+}
+
+// Load reads the stored alerts, replacing those in memory.
+func (e *Engine) Load(ctx context.Context) error {
+	if e.store == nil {
+		return nil
+	}
+	stored, err := e.store.ListAlerts(ctx)
+	if err != nil {
+		return fmt.Errorf("load alerts: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.alerts = make(map[string]*Alert, len(stored))
+	for _, a := range stored {
+		e.alerts[a.ID] = &a
+	}
+	log.Printf("Loaded %d alerts", len(stored))
+	return nil
+}
+
+// Create validates and registers a new alert, returning it with its ID.
+func (e *Engine) Create(ctx context.Context, a Alert) (Alert, error) {
+	a, err := normalize(a)
+	if err != nil {
+		return Alert{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	a.ID = newID()
+	a.CreatedAt = time.Now().UTC()
+	a.LastTriggered = time.Time{}
+
+	if e.store != nil {
+		if err := e.store.SaveAlert(ctx, a); err != nil {
+			return Alert{}, err
+		}
+	}
+	e.mu.Lock()
+	e.alerts[a.ID] = &a
+	e.mu.Unlock()
+	return a, nil
+}
+
+// Update replaces the rule of the alert with a.ID. Its creation and last
+// trigger times are kept, so an update does not bypass the cooldown.
+func (e *Engine) Update(ctx context.Context, a Alert) (Alert, error) {
+	a, err := normalize(a)
+	if err != nil {
+		return Alert{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	e.mu.Lock()
+	existing, ok := e.alerts[a.ID]
+	if ok {
+		a.CreatedAt, a.LastTriggered = existing.CreatedAt, existing.LastTriggered
+	}
+	e.mu.Unlock()
+	if !ok {
+		return Alert{}, ErrNotFound
+	}
+
+	if e.store != nil {
+		if err := e.store.SaveAlert(ctx, a); err != nil {
+			return Alert{}, err
+		}
+	}
+	e.mu.Lock()
+	if _, ok := e.alerts[a.ID]; ok {
+		e.alerts[a.ID] = &a
+	}
+	e.mu.Unlock()
+	return a, nil
+}
+
+func (e *Engine) Delete(ctx context.Context, id string) error {
+	e.mu.Lock()
+	_, ok := e.alerts[id]
+	e.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+
+	if e.store != nil {
+		if err := e.store.DeleteAlert(ctx, id); err != nil {
+			return err
+		}
+	}
+	e.mu.Lock()
+	delete(e.alerts, id)
+	e.mu.Unlock()
+	return nil
+}
+
+// List returns the alerts on the given instruments, or all alerts when none
+// are given, oldest first.
+func (e *Engine) List(insts []source.Instrument) []Alert {
+	want := make(map[source.Instrument]struct{}, len(insts))
+	for _, inst := range insts {
+		want[inst] = struct{}{}
+	}
+
+	e.mu.Lock()
+	list := make([]Alert, 0, len(e.alerts))
+	for _, a := range e.alerts {
+		if _, ok := want[a.Instrument()]; ok || len(want) == 0 {
+			list = append(list, *a)
+		}
+	}
+	e.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.Before(list[j].CreatedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Run forwards tickIn to tickOut and candleIn to candleOut, checking alerts
+// on the way. It returns once both inputs are closed, closing both outputs.
+func (e *Engine) Run(ctx context.Context,
+	tickIn <-chan source.Tick, tickOut chan<- source.Tick,
+	candleIn <-chan aggregator.Candle, candleOut chan<- aggregator.Candle) {
+	log.Println("Alert engine started")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer close(tickOut)
+		for tick := range tickIn {
+			e.deliver(e.checkTick(tick))
+			select {
+			case tickOut <- tick:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		defer close(candleOut)
+		// The aggregator flushes its last candles after ctx is done, so
+		// keep draining until it closes the channel, and pass every
+		// candle on: downstream drains until this closes candleOut.
+		for candle := range candleIn {
+			e.deliver(e.checkCandle(candle))
+			candleOut <- candle
+		}
+	}()
+	wg.Wait()
+	log.Println("Alert engine stopped")
+}
+
+// checkTick fires the price alerts whose threshold lies between the
+// instrument's previous trade and this one.
+func (e *Engine) checkTick(tick source.Tick) []delivery {
+	inst := tick.Instrument()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	prev, seen := e.prices[inst]
+	e.prices[inst] = tick.Price
+	if !seen {
+		return nil
+	}
+
+	var out []delivery
+	for _, a := range e.alerts {
+		if a.Kind != PriceCross || a.Instrument() != inst {
+			continue
+		}
+		up := prev.LessThan(a.Threshold) && !tick.Price.LessThan(a.Threshold)
+		down := prev.GreaterThan(a.Threshold) && !tick.Price.GreaterThan(a.Threshold)
+		if !(up && a.Direction != Down) && !(down && a.Direction != Up) {
+			continue
+		}
+		word := "above"
+		if down {
+			word = "below"
+		}
+		msg := fmt.Sprintf("%s crossed %s %s at %s", inst, word, a.Threshold, tick.Price)
+		if d, ok := e.trigger(a, tick.Price, tick.Timestamp, msg); ok {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// checkCandle fires the candle alerts of a newly finalized candle.
+// In-progress candles and corrections are ignored.
+func (e *Engine) checkCandle(c aggregator.Candle) []delivery {
+	if !c.Finalized || c.Revision > 0 || c.BarType != aggregator.BarTime {
+		return nil
+	}
+	inst := c.Instrument()
+	key := seriesKey(inst, c.Interval)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	history := e.volumes[key]
+	needed := 0
+	var out []delivery
+	for _, a := range e.alerts {
+		if a.Instrument() != inst || a.Interval != c.Interval {
+			continue
+		}
+		switch a.Kind {
+		case CandleRange:
+			if !c.Open.IsPositive() {
+				continue
+			}
+			percent := c.High.Sub(c.Low).Mul(hundred).DivRound(c.Open, 4)
+			if !percent.GreaterThan(a.Threshold) {
+				continue
+			}
+			msg := fmt.Sprintf("%s %s candle ranged %s%%, over %s%%", inst, c.Interval, percent, a.Threshold)
+			if d, ok := e.trigger(a, percent, c.EndTime, msg); ok {
+				out = append(out, d)
+			}
+
+		case VolumeSpike:
+			needed = max(needed, a.Lookback)
+			if len(history) < a.Lookback {
+				continue
+			}
+			sum := decimal.Zero
+			for _, v := range history[len(history)-a.Lookback:] {
+				sum = sum.Add(v)
+			}
+			if !sum.IsPositive() {
+				continue
+			}
+			multiple := c.Volume.Mul(decimal.NewFromInt(int64(a.Lookback))).DivRound(sum, 4)
+			if multiple.LessThan(a.Threshold) {
+				continue
+			}
+			msg := fmt.Sprintf("%s %s volume %s is %sx its %d candle average", inst, c.Interval, c.Volume, multiple, a.Lookback)
+			if d, ok := e.trigger(a, multiple, c.EndTime, msg); ok {
+				out = append(out, d)
+			}
+		}
+	}
+
+	// Only series with volume alerts keep a history.
+	if needed == 0 {
+		delete(e.volumes, key)
+		return out
+	}
+	history = append(history, c.Volume)
+	if len(history) > needed {
+		history = history[len(history)-needed:]
+	}
+	e.volumes[key] = history
+	return out
+}
+
+// delivery is an event with the webhook it goes to.
+type delivery struct {
+	event   Event
+	webhook string
+}
+
+// trigger records that a fired at the given event time, unless it is still
+// cooling down from its last trigger. e.mu must be held.
+func (e *Engine) trigger(a *Alert, value decimal.Decimal, at time.Time, msg string) (delivery, bool) {
+	cooldown := a.Cooldown
+	if cooldown == 0 {
+		cooldown = e.cooldown
+	}
+	if !a.LastTriggered.IsZero() && at.Sub(a.LastTriggered) < cooldown {
+		return delivery{}, false
+	}
+	a.LastTriggered = at
+
+	return delivery{
+		event: Event{
+			ID:          newID(),
+			AlertID:     a.ID,
+			Exchange:    a.Exchange,
+			Symbol:      a.Symbol,
+			Kind:        a.Kind,
+			Interval:    a.Interval,
+			Value:       value,
+			Message:     msg,
+			TriggeredAt: at,
+		},
+		webhook: a.WebhookURL,
+	}, true
+}
+
+func (e *Engine) deliver(deliveries []delivery) {
+	for _, d := range deliveries {
+		log.Printf("Alert %s: %s", d.event.AlertID, d.event.Message)
+		e.publish(d.event)
+		if d.webhook != "" && e.notifier != nil {
+			e.notifier.Notify(d.webhook, d.event)
+		}
+		if e.store != nil {
+			go func(id string, at time.Time) {
+				ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+				defer cancel()
+				if err := e.store.MarkAlertTriggered(ctx, id, at); err != nil {
+					log.Printf("Failed to record trigger of alert %s: %v", id, err)
+				}
+			}(d.event.AlertID, d.event.TriggeredAt)
+		}
+	}
+}
+
+// Subscription receives the events of some or all alerts.
+type Subscription struct {
+	engine *Engine
+	ids    map[string]struct{}
+
+	mu      sync.Mutex
+	pending []Event
+	notify  chan struct{}
+}
+
+// Subscribe registers interest in the events of the given alerts, or of all
+// alerts when none are given.
+func (e *Engine) Subscribe(alertIDs []string) *Subscription {
+	sub := &Subscription{
+		engine: e,
+		ids:    make(map[string]struct{}, len(alertIDs)),
+		notify: make(chan struct{}, 1),
+	}
+	for _, id := range alertIDs {
+		sub.ids[id] = struct{}{}
+	}
+
+	e.subsMu.Lock()
+	e.subs[sub] = struct{}{}
+	e.subsMu.Unlock()
+	return sub
+}
+
+func (e *Engine) publish(ev Event) {
+	e.subsMu.RLock()
+	defer e.subsMu.RUnlock()
+
+	for sub := range e.subs {
+		if _, ok := sub.ids[ev.AlertID]; ok || len(sub.ids) == 0 {
+			sub.offer(ev)
+		}
+	}
+}
+
+func (s *Subscription) offer(ev Event) {
+	s.mu.Lock()
+	s.pending = append(s.pending, ev)
+	if len(s.pending) > subscriptionBuffer {
+		s.pending = s.pending[len(s.pending)-subscriptionBuffer:]
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Next blocks until events are pending and returns them in trigger order.
+func (s *Subscription) Next(ctx context.Context) ([]Event, error) {
+	select {
+	case <-s.notify:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	events := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	return events, nil
+}
+
+func (s *Subscription) Close() {
+	s.engine.subsMu.Lock()
+	delete(s.engine.subs, s)
+	s.engine.subsMu.Unlock()
+}
@@ -0,0 +1,129 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/shubie/trading/internal/backoff"
+)
+
+const (
+	webhookQueue    = 1000
+	webhookAttempts = 3
+	webhookTimeout  = 10 * time.Second
+)
+
+type WebhookOption func(*Webhook)
+
+// WithAttempts sets how many times a delivery is tried before it is dropped.
+func WithAttempts(n int) WebhookOption {
+	return func(w *Webhook) {
+		w.attempts = n
+	}
+}
+
+// WithRetryBackoff sets the delays between attempts.
+func WithRetryBackoff(min, max time.Duration) WebhookOption {
+	return func(w *Webhook) {
+		w.backoff = backoff.New(min, max)
+	}
+}
+
+// Webhook POSTs events as JSON from a single background worker, so a slow
+// endpoint never holds up the pipeline. Failed deliveries are retried with
+// backoff; a 4xx response other than 429 is not retried.
+type Webhook struct {
+	client   *http.Client
+	attempts int
+	backoff  *backoff.Backoff
+	queue    chan delivery
+}
+
+func NewWebhook(opts ...WebhookOption) *Webhook {
+	w := &Webhook{
+		client:   &http.Client{Timeout: webhookTimeout},
+		attempts: webhookAttempts,
+		backoff:  backoff.New(time.Second, 30*time.Second),
+		queue:    make(chan delivery, webhookQueue),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Notify queues an event for delivery, dropping it if the queue is full.
+func (w *Webhook) Notify(url string, e Event) {
+	select {
+	case w.queue <- delivery{event: e, webhook: url}:
+	default:
+		log.Printf("Webhook queue full, dropping event %s of alert %s", e.ID, e.AlertID)
+	}
+}
+
+// Run delivers queued events until ctx is done.
+func (w *Webhook) Run(ctx context.Context) {
+	log.Println("Webhook sender started")
+	defer log.Println("Webhook sender stopped")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-w.queue:
+			w.send(ctx, d)
+		}
+	}
+}
+
+func (w *Webhook) send(ctx context.Context, d delivery) {
+	body, err := json.Marshal(d.event)
+	if err != nil {
+		log.Printf("Webhook encoding error: %v", err)
+		return
+	}
+
+	w.backoff.Reset()
+	for attempt := 1; ; attempt++ {
+		retry, err := w.post(ctx, d.webhook, body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= w.attempts {
+			log.Printf("Webhook delivery of event %s to %s failed after %d attempts: %v", d.event.ID, d.webhook, attempt, err)
+			return
+		}
+		if !w.backoff.Wait(ctx) {
+			return
+		}
+	}
+}
+
+// post sends one attempt, reporting whether a failure is worth retrying.
+func (w *Webhook) post(ctx context.Context, url string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("status %s", resp.Status)
+	default:
+		return false, fmt.Errorf("status %s", resp.Status)
+	}
+}
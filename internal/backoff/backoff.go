@@ -0,0 +1,80 @@
+package backoff
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	DefaultMin    = time.Second
+	DefaultMax    = time.Minute
+	DefaultFactor = 2.0
+	DefaultJitter = 0.5
+)
+
+// Backoff produces capped exponential delays. Jitter is the fraction of each
+// delay that is randomised, so concurrent clients do not retry in lockstep.
+// A Backoff is not safe for concurrent use.
+type Backoff struct {
+	Min    time.Duration
+	Max    time.Duration
+	Factor float64
+	Jitter float64
+
+	attempt int
+}
+
+func New(min, max time.Duration) *Backoff {
+	return &Backoff{
+		Min:    min,
+		Max:    max,
+		Factor: DefaultFactor,
+		Jitter: DefaultJitter,
+	}
+}
+
+// Next returns the delay before the next attempt and advances the sequence.
+func (b *Backoff) Next() time.Duration {
+	min, max := b.Min, b.Max
+	if min <= 0 {
+		min = DefaultMin
+	}
+	if max < min {
+		max = min
+	}
+	factor := b.Factor
+	if factor < 1 {
+		factor = DefaultFactor
+	}
+
+	d := float64(min) * math.Pow(factor, float64(b.attempt))
+	if d > float64(max) {
+		d = float64(max)
+	} else {
+		b.attempt++
+	}
+
+	if b.Jitter > 0 {
+		d -= rand.Float64() * b.Jitter * d
+	}
+	return time.Duration(d)
+}
+
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
+
+// Wait sleeps for the next delay, returning false if ctx ends first.
+func (b *Backoff) Wait(ctx context.Context) bool {
+	timer := time.NewTimer(b.Next())
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,49 @@
+package backoff_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shubie/trading/internal/backoff"
+)
+
+func TestBackoff_ExponentialAndCapped(t *testing.T) {
+	b := backoff.New(100*time.Millisecond, time.Second)
+	b.Jitter = 0
+
+	want := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, w := range want {
+		if got := b.Next(); got != w {
+			t.Errorf("attempt %d: expected %v, got %v", i, w, got)
+		}
+	}
+
+	b.Reset()
+	if got := b.Next(); got != 100*time.Millisecond {
+		t.Errorf("expected reset to restart at the minimum, got %v", got)
+	}
+}
+
+func TestBackoff_Jitter(t *testing.T) {
+	b := backoff.New(time.Second, time.Second)
+	b.Jitter = 0.5
+
+	distinct := make(map[time.Duration]bool)
+	for i := 0; i < 20; i++ {
+		d := b.Next()
+		if d < 500*time.Millisecond || d > time.Second {
+			t.Fatalf("jittered delay %v outside [500ms, 1s]", d)
+		}
+		distinct[d] = true
+	}
+	if len(distinct) < 2 {
+		t.Errorf("expected jitter to vary delays")
+	}
+}
@@ -2,95 +2,299 @@ package binance
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"log"
-	"strconv"
+	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/gorilla/websocket"
+	"github.com/shubie/trading/internal/backoff"
+	"github.com/shubie/trading/internal/source"
 )
 
-type Tick struct {
-	Symbol    string
-	Price     float64
-	Quantity  float64
-	Timestamp time.Time
+const (
+	Exchange = "binance"
+
+	// DefaultRESTURL serves the aggTrades history used to fill gaps.
+	DefaultRESTURL = "https://api.binance.com"
+
+	// Binance allows up to 1024 streams on one combined connection; staying
+	// well below keeps SUBSCRIBE bursts inside the per-connection rate limit.
+	DefaultMaxStreamsPerConnection = 200
+
+	// Binance pings every 20 seconds and drops clients that stay silent for a
+	// minute; pinging back lets us spot a half-open connection just as fast.
+	DefaultPingInterval = 20 * time.Second
+	DefaultReadTimeout  = time.Minute
+
+	// Binance closes every connection after 24 hours. Rotating an hour early
+	// leaves room to retry if the replacement fails to dial.
+	DefaultMaxConnectionAge = 23 * time.Hour
+)
+
+type Option func(*Client)
+
+// WithMaxStreamsPerConnection caps how many symbols share one combined
+// stream connection before another connection is opened.
+func WithMaxStreamsPerConnection(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.maxStreams = n
+		}
+	}
+}
+
+// WithBackoff sets the bounds of the capped exponential reconnect delay.
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *Client) {
+		if min > 0 {
+			c.backoffMin = min
+		}
+		if max > 0 {
+			c.backoffMax = max
+		}
+	}
+}
+
+// WithRESTURL sets the REST API host missed trades are backfilled from.
+func WithRESTURL(restURL string) Option {
+	return func(c *Client) {
+		if restURL != "" {
+			c.restURL = strings.TrimSuffix(restURL, "/")
+		}
+	}
 }
 
+// WithBackfillWeightLimit sets the request weight the IP may use in a
+// minute, as Binance reports it, before backfills wait for the next minute.
+func WithBackfillWeightLimit(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.backfillWeightLimit = n
+		}
+	}
+}
+
+// WithKeepalive sets how often the client pings the server and how long a
+// connection may go without any frame, data or control, before it is
+// considered dead.
+func WithKeepalive(pingInterval, readTimeout time.Duration) Option {
+	return func(c *Client) {
+		if pingInterval > 0 {
+			c.pingInterval = pingInterval
+		}
+		if readTimeout > 0 {
+			c.readTimeout = readTimeout
+		}
+	}
+}
+
+// WithStaleTimeout forces a reconnect when any single symbol has had no
+// trades for d, even though the connection itself is still alive. Zero
+// disables the watchdog, which suits illiquid symbols.
+func WithStaleTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.staleAfter = d
+	}
+}
+
+// WithMaxConnectionAge sets how long a connection is kept before it is
+// replaced by a fresh, overlapping one.
+func WithMaxConnectionAge(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.maxConnAge = d
+		}
+	}
+}
+
+// Client consumes aggTrade streams over Binance's combined stream endpoint,
+// sharding symbols across as many connections as maxStreams requires.
 type Client struct {
-	wssURL  string
-	symbols []string
+	baseURL    string
+	restURL    string
+	httpClient *http.Client
+	maxStreams int
+	backoffMin time.Duration
+	backoffMax time.Duration
+
+	backfillWeightLimit int
+
+	pingInterval time.Duration
+	readTimeout  time.Duration
+	staleAfter   time.Duration
+	maxConnAge   time.Duration
+
+	mu          sync.Mutex
+	ctx         context.Context
+	tickChan    chan<- source.Tick
+	wg          sync.WaitGroup
+	shards      []*shard
+	symbols     map[string]*shard
+	nextShardID int
 }
 
-func NewClient(wssURL string, symbols []string) *Client {
-	return &Client{
-		wssURL:  wssURL,
-		symbols: symbols,
+// NewClient accepts the stream host, e.g. wss://stream.binance.com:9443. A
+// trailing /ws or /stream path is ignored.
+func NewClient(wssURL string, symbols []string, opts ...Option) *Client {
+	base := strings.TrimSuffix(wssURL, "/")
+	base = strings.TrimSuffix(base, "/ws")
+	base = strings.TrimSuffix(base, "/stream")
+
+	c := &Client{
+		baseURL:    base,
+		restURL:    DefaultRESTURL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		maxStreams: DefaultMaxStreamsPerConnection,
+		backoffMin: backoff.DefaultMin,
+		backoffMax: backoff.DefaultMax,
+
+		backfillWeightLimit: DefaultBackfillWeightLimit,
+
+		pingInterval: DefaultPingInterval,
+		readTimeout:  DefaultReadTimeout,
+		maxConnAge:   DefaultMaxConnectionAge,
+
+		symbols: make(map[string]*shard),
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	c.Subscribe(symbols...)
+	return c
+}
+
+func (c *Client) Name() string {
+	return Exchange
 }
 
-func (c *Client) Connect(ctx context.Context, tickChan chan<- Tick) {
-	var wg sync.WaitGroup
-	for _, sym := range c.symbols {
-		wg.Add(1)
-		go func(symbol string) {
-			defer wg.Done()
-			c.connectSymbol(ctx, symbol, tickChan)
-		}(sym)
+func (c *Client) Connect(ctx context.Context, tickChan chan<- source.Tick) {
+	c.mu.Lock()
+	c.ctx = ctx
+	c.tickChan = tickChan
+	for _, sh := range c.shards {
+		c.startShard(sh)
 	}
-	wg.Wait()
-	close(tickChan)
+	c.mu.Unlock()
+
+	<-ctx.Done()
+	c.wg.Wait()
 }
 
-func (c *Client) connectSymbol(ctx context.Context, symbol string, tickChan chan<- Tick) {
-	url := fmt.Sprintf("%s/%s@aggTrade", c.wssURL, strings.ToLower(symbol))
+// Subscribe adds symbols at runtime. Symbols go to the first connection with
+// spare capacity; new connections are opened once all are full.
+func (c *Client) Subscribe(symbols ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	log.Printf("Connecting to %s", url)
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
+	added := make(map[*shard][]string)
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		if _, ok := c.symbols[symbol]; ok {
+			continue
 		}
-		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
-		if err != nil {
-			log.Printf("dial %s error: %v", symbol, err)
-			time.Sleep(time.Second)
+
+		sh := c.shardWithCapacity(added)
+		c.symbols[symbol] = sh
+		added[sh] = append(added[sh], symbol)
+	}
+
+	for sh, syms := range added {
+		sh.subscribe(syms)
+		if sh.cancel == nil && c.ctx != nil && c.ctx.Err() == nil {
+			c.startShard(sh)
+		}
+	}
+}
+
+// Unsubscribe drops symbols at runtime, closing connections left empty.
+func (c *Client) Unsubscribe(symbols ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := make(map[*shard][]string)
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		sh, ok := c.symbols[symbol]
+		if !ok {
 			continue
 		}
+		delete(c.symbols, symbol)
+		removed[sh] = append(removed[sh], symbol)
+	}
 
-		func() {
-			defer conn.Close()
-			for {
-				_, message, err := conn.ReadMessage()
-				if err != nil {
-					log.Printf("read %s error: %v", symbol, err)
-					return
-				}
-
-				var t struct {
-					Symbol    string `json:"s"`
-					Price     string `json:"p"`
-					Quantity  string `json:"q"`
-					Timestamp int64  `json:"T"`
-				}
-				if err := json.Unmarshal(message, &t); err != nil {
-					log.Printf("unmarshal %s error: %v", symbol, err)
-					continue
-				}
-
-				price, _ := strconv.ParseFloat(t.Price, 64)
-				qty, _ := strconv.ParseFloat(t.Quantity, 64)
-				tickChan <- Tick{
-					Symbol:    t.Symbol,
-					Price:     price,
-					Quantity:  qty,
-					Timestamp: time.Unix(0, t.Timestamp*int64(time.Millisecond)),
-				}
+	for sh, syms := range removed {
+		if sh.unsubscribe(syms) > 0 {
+			continue
+		}
+		if sh.cancel != nil {
+			sh.cancel()
+		}
+		for i, other := range c.shards {
+			if other == sh {
+				c.shards = append(c.shards[:i], c.shards[i+1:]...)
+				break
 			}
-		}()
+		}
+	}
+}
+
+// Symbols returns the currently subscribed symbols.
+func (c *Client) Symbols() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	symbols := make([]string, 0, len(c.symbols))
+	for symbol := range c.symbols {
+		symbols = append(symbols, symbol)
 	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Status reports the state of the connection carrying each symbol.
+func (c *Client) Status() []source.FeedStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	statuses := make([]source.FeedStatus, 0, len(c.symbols))
+	for symbol, sh := range c.symbols {
+		statuses = append(statuses, sh.state.Status(Exchange, symbol))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Symbol < statuses[j].Symbol
+	})
+	return statuses
+}
+
+func (c *Client) shardWithCapacity(pending map[*shard][]string) *shard {
+	for _, sh := range c.shards {
+		if sh.size()+len(pending[sh]) < c.maxStreams {
+			return sh
+		}
+	}
+
+	c.nextShardID++
+	sh := &shard{
+		id:        c.nextShardID,
+		client:    c,
+		streams:   make(map[string]time.Time),
+		lastTrade: make(map[string]int64),
+	}
+	c.shards = append(c.shards, sh)
+	return sh
+}
+
+func (c *Client) startShard(sh *shard) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	sh.cancel = cancel
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		sh.run(ctx, c.tickChan)
+	}()
+}
+
+func streamName(symbol string) string {
+	return strings.ToLower(symbol) + "@aggTrade"
 }
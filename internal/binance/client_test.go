@@ -5,50 +5,81 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/shubie/trading/internal/binance"
+	"github.com/shubie/trading/internal/source"
 
 	"github.com/gorilla/websocket"
 )
 
+var (
+	tradeIDsMu sync.Mutex
+	tradeIDs   = make(map[string]int64)
+)
+
+// aggTrade builds a combined-stream aggTrade message, numbering trades per
+// symbol as Binance does.
+func aggTrade(symbol, price, qty string) []byte {
+	tradeIDsMu.Lock()
+	tradeIDs[symbol]++
+	id := tradeIDs[symbol]
+	tradeIDsMu.Unlock()
+	return aggTradeWithID(id, symbol, price, qty)
+}
+
+func aggTradeWithID(id int64, symbol, price, qty string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"stream": strings.ToLower(symbol) + "@aggTrade",
+		"data": map[string]interface{}{
+			"e": "aggTrade",
+			"a": id,
+			"f": id * 10,
+			"l": id*10 + 2,
+			"m": true,
+			"M": false,
+			"s": symbol,
+			"p": price,
+			"q": qty,
+			"T": time.Now().UnixMilli(),
+		},
+	})
+	return data
+}
+
 func TestClient_Connect(t *testing.T) {
 	// Setup WebSocket mock server
 	symbol := "BTCUSDT"
 	upgrader := websocket.Upgrader{}
 
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !strings.Contains(r.URL.Path, strings.ToLower(symbol)+"@aggTrade") {
+		if r.URL.Path != "/stream" || !strings.Contains(r.URL.Query().Get("streams"), strings.ToLower(symbol)+"@aggTrade") {
 			http.Error(w, "invalid endpoint", http.StatusNotFound)
 			return
 		}
 
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
-			t.Fatalf("Failed to upgrade websocket: %v", err)
+			t.Errorf("Failed to upgrade websocket: %v", err)
+			return
 		}
 		defer conn.Close()
 
 		// Send a mock message
-		msg := map[string]interface{}{
-			"s": symbol,
-			"p": "45000.00",
-			"q": "0.001",
-			"T": time.Now().UnixMilli(),
-		}
-		data, _ := json.Marshal(msg)
 		time.Sleep(100 * time.Millisecond) // small delay to allow client to start reading
-		conn.WriteMessage(websocket.TextMessage, data)
+		conn.WriteMessage(websocket.TextMessage, aggTrade(symbol, "45000.00", "0.001"))
 		time.Sleep(200 * time.Millisecond)
 	}))
 	defer srv.Close()
 
 	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
 
-	client := binance.NewClient(wsURL, []string{symbol})
-	tickChan := make(chan binance.Tick)
+	client := binance.NewClient(wsURL, []string{symbol}, binance.WithRESTURL(srv.URL))
+	tickChan := make(chan source.Tick)
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
@@ -57,16 +88,547 @@ func TestClient_Connect(t *testing.T) {
 
 	select {
 	case tick := <-tickChan:
+		if tick.Exchange != binance.Exchange {
+			t.Errorf("expected exchange %s, got %s", binance.Exchange, tick.Exchange)
+		}
 		if tick.Symbol != symbol {
 			t.Errorf("expected symbol %s, got %s", symbol, tick.Symbol)
 		}
-		if tick.Price != 45000.00 {
-			t.Errorf("expected price 45000.00, got %f", tick.Price)
+		if tick.Price.String() != "45000" {
+			t.Errorf("expected price 45000.00, got %s", tick.Price)
 		}
-		if tick.Quantity != 0.001 {
-			t.Errorf("expected quantity 0.001, got %f", tick.Quantity)
+		if tick.Quantity.String() != "0.001" {
+			t.Errorf("expected quantity 0.001, got %s", tick.Quantity)
+		}
+		if tick.Side != source.SideSell || tick.Trades != 3 {
+			t.Errorf("expected 3 trades sold into the bid, got %d %s", tick.Trades, tick.Side)
 		}
 	case <-time.After(time.Second * 2):
 		t.Fatal("Timeout waiting for tick data")
 	}
 }
+
+type subscriptionRequest struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int      `json:"id"`
+}
+
+func TestClient_LiveSubscribeAndSharding(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	requests := make(chan subscriptionRequest, 10)
+
+	var mu sync.Mutex
+	var dialled []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		dialled = append(dialled, r.URL.Query().Get("streams"))
+		mu.Unlock()
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade websocket: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		for _, stream := range strings.Split(r.URL.Query().Get("streams"), "/") {
+			symbol := strings.ToUpper(strings.TrimSuffix(stream, "@aggTrade"))
+			conn.WriteMessage(websocket.TextMessage, aggTrade(symbol, "1.5", "2"))
+		}
+
+		for {
+			var req subscriptionRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			requests <- req
+			conn.WriteJSON(map[string]interface{}{"result": nil, "id": req.ID})
+			if req.Method == "SUBSCRIBE" {
+				for _, stream := range req.Params {
+					symbol := strings.ToUpper(strings.TrimSuffix(stream, "@aggTrade"))
+					conn.WriteMessage(websocket.TextMessage, aggTrade(symbol, "1.5", "2"))
+				}
+			}
+		}
+	}))
+	defer srv.Close()
+
+	client := binance.NewClient("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws",
+		[]string{"BTCUSDT", "ETHUSDT"}, binance.WithMaxStreamsPerConnection(2))
+	tickChan := make(chan source.Tick, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go client.Connect(ctx, tickChan)
+
+	// Give the first connection time to come up, then add a symbol at
+	// runtime; the first connection is full so it must land on a new one.
+	time.Sleep(200 * time.Millisecond)
+	client.Subscribe("pepeusdt")
+
+	for seen := false; !seen; {
+		select {
+		case tick := <-tickChan:
+			seen = tick.Symbol == "PEPEUSDT"
+		case <-ctx.Done():
+			t.Fatal("Timeout waiting for tick from runtime subscription")
+		}
+	}
+
+	mu.Lock()
+	if len(dialled) != 2 || dialled[0] != "btcusdt@aggTrade/ethusdt@aggTrade" || dialled[1] != "pepeusdt@aggTrade" {
+		t.Errorf("expected two shards, got %q", dialled)
+	}
+	mu.Unlock()
+
+	client.Subscribe("SOLUSDT")
+	client.Unsubscribe("ETHUSDT")
+
+	// The two requests go to different connections, so their order is not
+	// guaranteed.
+	want := map[string]bool{
+		"SUBSCRIBE solusdt@aggTrade":   true,
+		"UNSUBSCRIBE ethusdt@aggTrade": true,
+	}
+	for len(want) > 0 {
+		select {
+		case req := <-requests:
+			got := req.Method + " " + strings.Join(req.Params, ",")
+			if !want[got] {
+				t.Fatalf("unexpected request %q", got)
+			}
+			delete(want, got)
+		case <-ctx.Done():
+			t.Fatalf("Timeout waiting for requests %v", want)
+		}
+	}
+
+	if got := client.Symbols(); strings.Join(got, ",") != "BTCUSDT,PEPEUSDT,SOLUSDT" {
+		t.Errorf("unexpected symbols after changes: %v", got)
+	}
+}
+
+func TestClient_BackoffAndStatus(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	var mu sync.Mutex
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		n := attempts
+		mu.Unlock()
+
+		if n <= 2 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		time.Sleep(time.Second)
+	}))
+	defer srv.Close()
+
+	client := binance.NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), []string{"BTCUSDT"},
+		binance.WithBackoff(20*time.Millisecond, 50*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		client.Connect(ctx, make(chan source.Tick))
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	var st source.FeedStatus
+	for time.Now().Before(deadline) {
+		st = client.Status()[0]
+		if st.State == source.StateLive {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if st.State != source.StateLive {
+		t.Fatalf("expected feed to become live, got %s", st.State)
+	}
+	if st.Symbol != "BTCUSDT" || st.Exchange != binance.Exchange {
+		t.Errorf("unexpected feed %s:%s", st.Exchange, st.Symbol)
+	}
+	if st.LastError == "" {
+		t.Errorf("expected the failed dials to be recorded as the last error")
+	}
+
+	cancel()
+	<-done
+	if st := client.Status()[0]; st.State != source.StateStopped {
+		t.Errorf("expected stopped after shutdown, got %s", st.State)
+	}
+}
+
+func TestClient_RotatesWithoutGaps(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	// Every connection sees the same trade sequence from the moment it
+	// connects, as with the real exchange.
+	var mu sync.Mutex
+	var trades int64
+	dials := 0
+	ticker := time.NewTicker(2 * time.Millisecond)
+	defer ticker.Stop()
+	go func() {
+		for range ticker.C {
+			mu.Lock()
+			trades++
+			mu.Unlock()
+		}
+	}()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade websocket: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		mu.Lock()
+		dials++
+		next := trades + 1
+		mu.Unlock()
+
+		for {
+			mu.Lock()
+			last := trades
+			mu.Unlock()
+			for ; next <= last; next++ {
+				msg := aggTradeWithID(next, "BTCUSDT", strconv.FormatInt(next, 10), "1")
+				if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+					return
+				}
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	client := binance.NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), []string{"BTCUSDT"},
+		binance.WithMaxConnectionAge(150*time.Millisecond))
+	tickChan := make(chan source.Tick, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go client.Connect(ctx, tickChan)
+
+	var prev int64
+	for deadline := time.After(time.Second); ; {
+		select {
+		case tick := <-tickChan:
+			if prev != 0 && tick.Price.IntPart() != prev+1 {
+				t.Fatalf("trade %s followed trade %d across rotation", tick.Price, prev)
+			}
+			prev = tick.Price.IntPart()
+			continue
+		case <-deadline:
+		}
+		break
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if dials < 3 {
+		t.Errorf("expected the connection to rotate, got %d dials", dials)
+	}
+}
+
+func TestClient_ReconnectsStaleConnections(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  binance.Option
+		// serve keeps the connection open without delivering BTCUSDT trades.
+		serve func(conn *websocket.Conn)
+	}{
+		{
+			// The server neither sends nor reads, so pings go unanswered.
+			name:  "half-open",
+			opt:   binance.WithKeepalive(20*time.Millisecond, 100*time.Millisecond),
+			serve: func(conn *websocket.Conn) { time.Sleep(time.Second) },
+		},
+		{
+			// The connection is healthy but one symbol has gone quiet.
+			name: "silent symbol",
+			opt:  binance.WithStaleTimeout(100 * time.Millisecond),
+			serve: func(conn *websocket.Conn) {
+				for i := 0; i < 50; i++ {
+					if conn.WriteMessage(websocket.TextMessage, aggTrade("ETHUSDT", "1", "1")) != nil {
+						return
+					}
+					time.Sleep(20 * time.Millisecond)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgrader := websocket.Upgrader{}
+			dialled := make(chan struct{}, 10)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/v3/aggTrades" {
+					w.Write([]byte("[]"))
+					return
+				}
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					t.Errorf("Failed to upgrade websocket: %v", err)
+					return
+				}
+				defer conn.Close()
+				dialled <- struct{}{}
+				tt.serve(conn)
+			}))
+			defer srv.Close()
+
+			client := binance.NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), []string{"BTCUSDT", "ETHUSDT"},
+				binance.WithBackoff(10*time.Millisecond, 10*time.Millisecond), binance.WithRESTURL(srv.URL), tt.opt)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			tickChan := make(chan source.Tick, 100)
+			go client.Connect(ctx, tickChan)
+
+			for i := 0; i < 2; i++ {
+				select {
+				case <-dialled:
+				case <-ctx.Done():
+					t.Fatal("Timeout waiting for the stale connection to be replaced")
+				}
+			}
+			if st := client.Status()[0]; st.Reconnects == 0 {
+				t.Errorf("expected a reconnect to be recorded, got %+v", st)
+			}
+		})
+	}
+}
+
+func TestClient_BackfillsAfterOutage(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	var mu sync.Mutex
+	dials := 0
+	var fromIDs []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/aggTrades" {
+			mu.Lock()
+			fromIDs = append(fromIDs, r.URL.Query().Get("symbol")+"@"+r.URL.Query().Get("fromId"))
+			mu.Unlock()
+
+			// Trades 4 to 6 happened while the client was disconnected.
+			var trades []map[string]interface{}
+			from, _ := strconv.ParseInt(r.URL.Query().Get("fromId"), 10, 64)
+			for id := from; id <= 6; id++ {
+				trades = append(trades, map[string]interface{}{
+					"a": id,
+					"p": strconv.FormatInt(id, 10),
+					"q": "1",
+					"T": time.Now().UnixMilli(),
+				})
+			}
+			json.NewEncoder(w).Encode(trades)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade websocket: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		mu.Lock()
+		dials++
+		n := dials
+		mu.Unlock()
+
+		// The first connection carries trades 1 to 3 and then drops; the
+		// second resumes live at trade 6, overlapping the backfill.
+		ids := []int64{1, 2, 3}
+		if n > 1 {
+			ids = []int64{6, 7, 8}
+		}
+		for _, id := range ids {
+			conn.WriteMessage(websocket.TextMessage, aggTradeWithID(id, "BTCUSDT", strconv.FormatInt(id, 10), "1"))
+		}
+		if n > 1 {
+			time.Sleep(time.Second)
+		}
+	}))
+	defer srv.Close()
+
+	client := binance.NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), []string{"BTCUSDT"},
+		binance.WithBackoff(10*time.Millisecond, 10*time.Millisecond),
+		binance.WithRESTURL(srv.URL))
+	tickChan := make(chan source.Tick, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go client.Connect(ctx, tickChan)
+
+	for want := int64(1); want <= 8; want++ {
+		select {
+		case tick := <-tickChan:
+			if tick.Price.IntPart() != want {
+				t.Fatalf("expected trade %d, got %s", want, tick.Price)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Timeout waiting for trade %v", want)
+		}
+	}
+
+	select {
+	case tick := <-tickChan:
+		t.Errorf("unexpected extra trade %s", tick.Price)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(fromIDs) != 1 || fromIDs[0] != "BTCUSDT@4" {
+		t.Errorf("expected one backfill from trade 4, got %v", fromIDs)
+	}
+}
+
+func TestClient_BackfillsLongOutagesThroughRateLimits(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	// Trades 4 to 12003 were missed, twelve full pages and more than a
+	// backfill used to fetch.
+	const lastMissed = 12003
+	var mu sync.Mutex
+	dials, requests := 0, 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/aggTrades" {
+			mu.Lock()
+			requests++
+			n := requests
+			mu.Unlock()
+			if n == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			trades := []map[string]interface{}{}
+			from, _ := strconv.ParseInt(r.URL.Query().Get("fromId"), 10, 64)
+			for id := from; id <= lastMissed && id < from+1000; id++ {
+				trades = append(trades, map[string]interface{}{
+					"a": id,
+					"p": strconv.FormatInt(id, 10),
+					"q": "1",
+					"T": time.Now().UnixMilli(),
+				})
+			}
+			w.Header().Set("X-MBX-USED-WEIGHT-1M", "2")
+			json.NewEncoder(w).Encode(trades)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade websocket: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		mu.Lock()
+		dials++
+		n := dials
+		mu.Unlock()
+
+		ids := []int64{1, 2, 3}
+		if n > 1 {
+			ids = []int64{lastMissed + 1, lastMissed + 2}
+		}
+		for _, id := range ids {
+			conn.WriteMessage(websocket.TextMessage, aggTradeWithID(id, "BTCUSDT", strconv.FormatInt(id, 10), "1"))
+		}
+		if n > 1 {
+			time.Sleep(5 * time.Second)
+		}
+	}))
+	defer srv.Close()
+
+	client := binance.NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), []string{"BTCUSDT"},
+		binance.WithBackoff(10*time.Millisecond, 10*time.Millisecond),
+		binance.WithRESTURL(srv.URL))
+	tickChan := make(chan source.Tick, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go client.Connect(ctx, tickChan)
+
+	for want := int64(1); want <= lastMissed+2; want++ {
+		select {
+		case tick := <-tickChan:
+			if tick.Price.IntPart() != want {
+				t.Fatalf("expected trade %d, got %s", want, tick.Price)
+			}
+		case <-ctx.Done():
+			t.Fatalf("Timeout waiting for trade %v", want)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The rate limited request, then thirteen pages, the last one short.
+	if requests != 14 {
+		t.Errorf("expected 14 aggTrades requests, got %d", requests)
+	}
+}
+
+func TestClient_RejectsMalformedPrices(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade websocket: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, aggTrade("BTCUSDT", "not-a-price", "1"))
+		conn.WriteMessage(websocket.TextMessage, aggTrade("BTCUSDT", "45000.01", ""))
+		conn.WriteMessage(websocket.TextMessage, aggTrade("BTCUSDT", "0", "1"))
+		conn.WriteMessage(websocket.TextMessage, aggTrade("BTCUSDT", "-45000.01", "1"))
+		conn.WriteMessage(websocket.TextMessage, aggTrade("BTCUSDT", "45000.01", "0"))
+		conn.WriteMessage(websocket.TextMessage, aggTrade("BTCUSDT", "45000.01", "-1"))
+		conn.WriteMessage(websocket.TextMessage, aggTrade("BTCUSDT", "0.00000001", "1"))
+		time.Sleep(time.Second)
+	}))
+	defer srv.Close()
+
+	client := binance.NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), []string{"BTCUSDT"})
+	tickChan := make(chan source.Tick, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go client.Connect(ctx, tickChan)
+
+	select {
+	case tick := <-tickChan:
+		if tick.Price.String() != "0.00000001" {
+			t.Errorf("expected malformed trades to be dropped, got price %s", tick.Price)
+		}
+	case <-ctx.Done():
+		t.Fatal("Timeout waiting for tick data")
+	}
+}
@@ -0,0 +1,505 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/backoff"
+	"github.com/shubie/trading/internal/orderbook"
+	"github.com/shubie/trading/internal/source"
+)
+
+const (
+	// DefaultDepthLimit is how many levels per side the REST snapshot a
+	// book starts from holds.
+	DefaultDepthLimit = 1000
+
+	// maxBufferedDiffs bounds the diffs held per symbol while its snapshot
+	// is fetched. Dropping the oldest only forces a fresh snapshot.
+	maxBufferedDiffs = 1000
+)
+
+type DepthOption func(*DepthClient)
+
+// WithDepthLimit sets how many levels per side the REST snapshot holds,
+// one of the limits Binance accepts: 5, 10, 20, 50, 100, 500, 1000 or 5000.
+func WithDepthLimit(n int) DepthOption {
+	return func(c *DepthClient) {
+		if n > 0 {
+			c.limit = n
+		}
+	}
+}
+
+// WithDepthRESTURL sets the REST API host snapshots are fetched from.
+func WithDepthRESTURL(restURL string) DepthOption {
+	return func(c *DepthClient) {
+		if restURL != "" {
+			c.restURL = strings.TrimSuffix(restURL, "/")
+		}
+	}
+}
+
+// WithDepthBackoff sets the bounds of the reconnect and snapshot retry
+// delays.
+func WithDepthBackoff(min, max time.Duration) DepthOption {
+	return func(c *DepthClient) {
+		if min > 0 {
+			c.backoffMin = min
+		}
+		if max > 0 {
+			c.backoffMax = max
+		}
+	}
+}
+
+// DepthClient keeps a local order book per symbol from the @depth@100ms diff
+// streams, following Binance's documented procedure: buffer diffs, fetch a
+// REST snapshot, drop the diffs it covers, then apply the rest as long as
+// each starts right after the previous one ended. A gap in the update IDs
+// discards the book and starts over from a new snapshot.
+type DepthClient struct {
+	baseURL    string
+	restURL    string
+	httpClient *http.Client
+	symbols    []string
+	limit      int
+	backoffMin time.Duration
+	backoffMax time.Duration
+
+	pingInterval time.Duration
+	readTimeout  time.Duration
+
+	mu    sync.RWMutex
+	books map[string]*depthState
+}
+
+// depthState is the sync state of one symbol's book.
+type depthState struct {
+	book     *orderbook.Book
+	synced   bool
+	buffer   []depthDiff
+	snapshot *depthSnapshot // fetched, waiting for a diff that follows it
+	fetching bool
+}
+
+func NewDepthClient(wssURL string, symbols []string, opts ...DepthOption) *DepthClient {
+	base := strings.TrimSuffix(wssURL, "/")
+	base = strings.TrimSuffix(base, "/ws")
+	base = strings.TrimSuffix(base, "/stream")
+
+	c := &DepthClient{
+		baseURL:    base,
+		restURL:    DefaultRESTURL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		limit:      DefaultDepthLimit,
+		backoffMin: backoff.DefaultMin,
+		backoffMax: backoff.DefaultMax,
+
+		pingInterval: DefaultPingInterval,
+		readTimeout:  DefaultReadTimeout,
+
+		books: make(map[string]*depthState),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		if _, ok := c.books[symbol]; ok {
+			continue
+		}
+		c.symbols = append(c.symbols, symbol)
+		c.books[symbol] = &depthState{book: orderbook.NewBook(Exchange, symbol)}
+	}
+	return c
+}
+
+// Book returns the top depth levels of a symbol's book, or false while the
+// book is not in sync with the exchange.
+func (c *DepthClient) Book(inst source.Instrument, depth int) (orderbook.Snapshot, bool) {
+	if inst.Exchange != Exchange {
+		return orderbook.Snapshot{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	st, ok := c.books[strings.ToUpper(inst.Symbol)]
+	if !ok || !st.synced {
+		return orderbook.Snapshot{}, false
+	}
+	return st.book.Snapshot(depth), true
+}
+
+// Snapshots returns the top depth levels of every book in sync.
+func (c *DepthClient) Snapshots(depth int) []orderbook.Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	snapshots := make([]orderbook.Snapshot, 0, len(c.books))
+	for _, symbol := range c.symbols {
+		if st := c.books[symbol]; st.synced {
+			snapshots = append(snapshots, st.book.Snapshot(depth))
+		}
+	}
+	return snapshots
+}
+
+// Run maintains the books until ctx is done, reconnecting with backoff.
+func (c *DepthClient) Run(ctx context.Context) {
+	if len(c.symbols) == 0 {
+		return
+	}
+	b := backoff.New(c.backoffMin, c.backoffMax)
+	streams := make([]string, 0, len(c.symbols))
+	for _, symbol := range c.symbols {
+		streams = append(streams, depthStreamName(symbol))
+	}
+	streamURL := fmt.Sprintf("%s/stream?streams=%s", c.baseURL, strings.Join(streams, "/"))
+
+	for {
+		log.Printf("Connecting binance depth stream for %d symbols", len(c.symbols))
+		ws, _, err := websocket.DefaultDialer.DialContext(ctx, streamURL, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("dial binance depth error: %v", err)
+			if !b.Wait(ctx) {
+				return
+			}
+			continue
+		}
+
+		connected := time.Now()
+		err = c.session(ctx, ws)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("binance depth stream error: %v", err)
+		if time.Since(connected) >= source.StableConnection {
+			b.Reset()
+		}
+		if !b.Wait(ctx) {
+			return
+		}
+	}
+}
+
+type depthFrame struct {
+	diff depthDiff
+	err  error
+}
+
+type snapshotResult struct {
+	symbol   string
+	snapshot *depthSnapshot
+}
+
+// session applies the diffs of one connection. Every book starts over from
+// a fresh snapshot, as diffs may have been missed while disconnected.
+func (c *DepthClient) session(ctx context.Context, ws *websocket.Conn) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	conn := &connection{ws: ws, connected: time.Now(), done: make(chan struct{})}
+	defer conn.close()
+	frames := make(chan depthFrame)
+	snapshots := make(chan snapshotResult)
+	go c.read(ctx, conn, frames)
+
+	c.mu.Lock()
+	for _, symbol := range c.symbols {
+		st := c.books[symbol]
+		st.synced, st.buffer, st.snapshot, st.fetching = false, nil, nil, false
+		c.fetch(ctx, symbol, st, snapshots)
+	}
+	c.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case f := <-frames:
+			if f.err != nil {
+				return f.err
+			}
+			c.mu.Lock()
+			if st, ok := c.books[f.diff.symbol]; ok {
+				c.apply(ctx, st, f.diff, snapshots)
+			}
+			c.mu.Unlock()
+
+		case r := <-snapshots:
+			c.mu.Lock()
+			st := c.books[r.symbol]
+			st.fetching = false
+			st.snapshot = r.snapshot
+			c.sync(ctx, st, snapshots)
+			c.mu.Unlock()
+		}
+	}
+}
+
+// apply handles one diff. c.mu must be held.
+func (c *DepthClient) apply(ctx context.Context, st *depthState, d depthDiff, snapshots chan<- snapshotResult) {
+	if !st.synced {
+		st.buffer = append(st.buffer, d)
+		if len(st.buffer) > maxBufferedDiffs {
+			st.buffer = st.buffer[len(st.buffer)-maxBufferedDiffs:]
+		}
+		c.sync(ctx, st, snapshots)
+		return
+	}
+
+	if d.finalID <= st.book.LastUpdateID {
+		return
+	}
+	if d.firstID > st.book.LastUpdateID+1 {
+		log.Printf("binance %s depth gap: update %d follows %d, resyncing", d.symbol, d.firstID, st.book.LastUpdateID)
+		st.synced = false
+		st.buffer = []depthDiff{d}
+		st.snapshot = nil
+		c.fetch(ctx, d.symbol, st, snapshots)
+		return
+	}
+	st.book.Apply(d.finalID, d.bids, d.asks, d.eventTime)
+}
+
+// sync tries to build the book from a fetched snapshot and the buffered
+// diffs. c.mu must be held.
+func (c *DepthClient) sync(ctx context.Context, st *depthState, snapshots chan<- snapshotResult) {
+	snap := st.snapshot
+	if snap == nil {
+		c.fetch(ctx, st.book.Symbol, st, snapshots)
+		return
+	}
+
+	// Drop the diffs the snapshot already includes.
+	i := 0
+	for i < len(st.buffer) && st.buffer[i].finalID <= snap.lastUpdateID {
+		i++
+	}
+	st.buffer = st.buffer[i:]
+	if len(st.buffer) == 0 {
+		return
+	}
+	if st.buffer[0].firstID > snap.lastUpdateID+1 {
+		// The stream has moved past the snapshot.
+		st.snapshot = nil
+		c.fetch(ctx, st.book.Symbol, st, snapshots)
+		return
+	}
+
+	st.book.Reset(snap.lastUpdateID, snap.bids, snap.asks, snap.fetched)
+	for _, d := range st.buffer {
+		if d.firstID > st.book.LastUpdateID+1 {
+			st.snapshot = nil
+			c.fetch(ctx, st.book.Symbol, st, snapshots)
+			return
+		}
+		st.book.Apply(d.finalID, d.bids, d.asks, d.eventTime)
+	}
+	st.synced, st.buffer, st.snapshot = true, nil, nil
+	log.Printf("binance %s order book in sync at update %d", st.book.Symbol, st.book.LastUpdateID)
+}
+
+// fetch starts fetching a snapshot for symbol unless one is on its way,
+// retrying with backoff until ctx is done. c.mu must be held.
+func (c *DepthClient) fetch(ctx context.Context, symbol string, st *depthState, snapshots chan<- snapshotResult) {
+	if st.fetching {
+		return
+	}
+	st.fetching = true
+	go func() {
+		b := backoff.New(c.backoffMin, c.backoffMax)
+		for {
+			snap, err := c.fetchDepth(ctx, symbol)
+			if err == nil {
+				select {
+				case snapshots <- snapshotResult{symbol: symbol, snapshot: snap}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("binance %s depth snapshot error: %v", symbol, err)
+			if !b.Wait(ctx) {
+				return
+			}
+		}
+	}()
+}
+
+// read delivers diffs from conn until it fails, with the same keepalive and
+// read deadline as the trade streams.
+func (c *DepthClient) read(ctx context.Context, conn *connection, frames chan<- depthFrame) {
+	go conn.keepalive(ctx, c.pingInterval)
+
+	timeout := c.readTimeout
+	conn.ws.SetPongHandler(func(string) error {
+		return conn.ws.SetReadDeadline(time.Now().Add(timeout))
+	})
+	conn.ws.SetPingHandler(func(data string) error {
+		conn.ws.SetReadDeadline(time.Now().Add(timeout))
+		err := conn.ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(writeWait))
+		var netErr net.Error
+		if errors.Is(err, websocket.ErrCloseSent) || errors.As(err, &netErr) && netErr.Timeout() {
+			return nil
+		}
+		return err
+	})
+
+	for {
+		conn.ws.SetReadDeadline(time.Now().Add(timeout))
+		_, message, err := conn.ws.ReadMessage()
+		if err != nil {
+			conn.close()
+			select {
+			case frames <- depthFrame{err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+
+		d, err := parseDepthDiff(message)
+		if err != nil {
+			// The gap this leaves is caught by the next diff.
+			log.Printf("binance depth message rejected: %v", err)
+			continue
+		}
+		select {
+		case frames <- depthFrame{diff: d}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// depthDiff is one @depth event: the absolute quantities of the levels that
+// changed between update IDs firstID and finalID.
+type depthDiff struct {
+	symbol    string
+	firstID   int64
+	finalID   int64
+	eventTime time.Time
+	bids      []orderbook.Level
+	asks      []orderbook.Level
+}
+
+type depthSnapshot struct {
+	lastUpdateID int64
+	fetched      time.Time
+	bids         []orderbook.Level
+	asks         []orderbook.Level
+}
+
+func parseDepthDiff(message []byte) (depthDiff, error) {
+	var m struct {
+		Stream string `json:"stream"`
+		Data   struct {
+			// Declared so the event type "e" is not matched to "E".
+			Event     string      `json:"e"`
+			EventTime int64       `json:"E"`
+			Symbol    string      `json:"s"`
+			FirstID   int64       `json:"U"`
+			FinalID   int64       `json:"u"`
+			Bids      [][2]string `json:"b"`
+			Asks      [][2]string `json:"a"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(message, &m); err != nil {
+		return depthDiff{}, err
+	}
+	if m.Stream == "" {
+		return depthDiff{}, errors.New("not a stream event")
+	}
+	bids, err := parseLevels(m.Data.Bids)
+	if err != nil {
+		return depthDiff{}, fmt.Errorf("%s bids: %w", m.Data.Symbol, err)
+	}
+	asks, err := parseLevels(m.Data.Asks)
+	if err != nil {
+		return depthDiff{}, fmt.Errorf("%s asks: %w", m.Data.Symbol, err)
+	}
+	return depthDiff{
+		symbol:    strings.ToUpper(m.Data.Symbol),
+		firstID:   m.Data.FirstID,
+		finalID:   m.Data.FinalID,
+		eventTime: time.UnixMilli(m.Data.EventTime),
+		bids:      bids,
+		asks:      asks,
+	}, nil
+}
+
+func parseLevels(raw [][2]string) ([]orderbook.Level, error) {
+	levels := make([]orderbook.Level, 0, len(raw))
+	for _, r := range raw {
+		price, err := decimal.NewFromString(r[0])
+		if err != nil {
+			return nil, fmt.Errorf("price %q: %w", r[0], err)
+		}
+		qty, err := decimal.NewFromString(r[1])
+		if err != nil {
+			return nil, fmt.Errorf("quantity %q: %w", r[1], err)
+		}
+		levels = append(levels, orderbook.Level{Price: price, Quantity: qty})
+	}
+	return levels, nil
+}
+
+func (c *DepthClient) fetchDepth(ctx context.Context, symbol string) (*depthSnapshot, error) {
+	q := url.Values{}
+	q.Set("symbol", symbol)
+	q.Set("limit", strconv.Itoa(c.limit))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.restURL+"/api/v3/depth?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("depth %s: %s", symbol, resp.Status)
+	}
+	var raw struct {
+		LastUpdateID int64       `json:"lastUpdateId"`
+		Bids         [][2]string `json:"bids"`
+		Asks         [][2]string `json:"asks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	snap := &depthSnapshot{lastUpdateID: raw.LastUpdateID, fetched: time.Now()}
+	if snap.bids, err = parseLevels(raw.Bids); err != nil {
+		return nil, fmt.Errorf("depth %s bids: %w", symbol, err)
+	}
+	if snap.asks, err = parseLevels(raw.Asks); err != nil {
+		return nil, fmt.Errorf("depth %s asks: %w", symbol, err)
+	}
+	return snap, nil
+}
+
+func depthStreamName(symbol string) string {
+	return strings.ToLower(symbol) + "@depth@100ms"
+}
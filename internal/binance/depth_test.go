@@ -0,0 +1,126 @@
+package binance_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/shubie/trading/internal/binance"
+	"github.com/shubie/trading/internal/orderbook"
+	"github.com/shubie/trading/internal/source"
+)
+
+func depthDiff(symbol string, first, final int64, bids, asks [][2]string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"stream": strings.ToLower(symbol) + "@depth@100ms",
+		"data": map[string]interface{}{
+			"e": "depthUpdate",
+			"E": time.Now().UnixMilli(),
+			"s": symbol,
+			"U": first,
+			"u": final,
+			"b": bids,
+			"a": asks,
+		},
+	})
+	return data
+}
+
+// waitForBook polls until the book reaches the given update ID.
+func waitForBook(t *testing.T, client *binance.DepthClient, updateID int64) orderbook.Snapshot {
+	t.Helper()
+	inst := source.Instrument{Exchange: binance.Exchange, Symbol: "BTCUSDT"}
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if snap, ok := client.Book(inst, 10); ok && snap.LastUpdateID == updateID {
+			return snap
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	snap, ok := client.Book(inst, 10)
+	t.Fatalf("Book never reached update %d; at %d, in sync %v", updateID, snap.LastUpdateID, ok)
+	return orderbook.Snapshot{}
+}
+
+func TestDepthClient_SyncsAndResyncsOnGap(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	proceed := make(chan struct{})
+	var snapshotCalls atomic.Int32
+	snapshots := []string{
+		`{"lastUpdateId":100,"bids":[["100.00","1"],["99.00","2"]],"asks":[["101.00","1"],["102.00","2"]]}`,
+		`{"lastUpdateId":111,"bids":[["98.00","1"]],"asks":[["103.00","4"]]}`,
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/depth":
+			if r.URL.Query().Get("symbol") != "BTCUSDT" || r.URL.Query().Get("limit") != "20" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			n := int(snapshotCalls.Add(1)) - 1
+			w.Write([]byte(snapshots[min(n, len(snapshots)-1)]))
+
+		case "/stream":
+			if r.URL.Query().Get("streams") != "btcusdt@depth@100ms" {
+				http.Error(w, "invalid streams", http.StatusNotFound)
+				return
+			}
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				t.Errorf("Failed to upgrade websocket: %v", err)
+				return
+			}
+			defer conn.Close()
+
+			// Covered by the snapshot, straddling it, then following on.
+			conn.WriteMessage(websocket.TextMessage, depthDiff("BTCUSDT", 95, 98, [][2]string{{"100.00", "9"}}, nil))
+			conn.WriteMessage(websocket.TextMessage, depthDiff("BTCUSDT", 99, 102, [][2]string{{"100.00", "3"}}, nil))
+			conn.WriteMessage(websocket.TextMessage, depthDiff("BTCUSDT", 103, 103, nil, [][2]string{{"101.00", "0"}}))
+
+			<-proceed
+			// Updates 104 to 109 are lost.
+			conn.WriteMessage(websocket.TextMessage, depthDiff("BTCUSDT", 110, 111, [][2]string{{"50.00", "1"}}, nil))
+			conn.WriteMessage(websocket.TextMessage, depthDiff("BTCUSDT", 112, 112, [][2]string{{"98.00", "2"}}, nil))
+			time.Sleep(time.Second)
+		}
+	}))
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+	client := binance.NewDepthClient(wsURL, []string{"btcusdt"},
+		binance.WithDepthRESTURL(srv.URL),
+		binance.WithDepthLimit(20),
+		binance.WithDepthBackoff(10*time.Millisecond, 10*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go client.Run(ctx)
+
+	snap := waitForBook(t, client, 103)
+	bid, _ := snap.BestBid()
+	ask, _ := snap.BestAsk()
+	spread, _ := snap.Spread()
+	if bid.Price.String() != "100" || bid.Quantity.String() != "3" || ask.Price.String() != "102" || spread.String() != "2" {
+		t.Errorf("Got bid %v, ask %v, spread %s; want 3 at 100, 2 at 102, spread 2", bid, ask, spread)
+	}
+
+	close(proceed)
+	snap = waitForBook(t, client, 112)
+	if len(snap.Bids) != 1 || snap.Bids[0].Price.String() != "98" || snap.Bids[0].Quantity.String() != "2" {
+		t.Errorf("Bids after resync = %v, want 2 at 98", snap.Bids)
+	}
+	if len(snap.Asks) != 1 || snap.Asks[0].Price.String() != "103" {
+		t.Errorf("Asks after resync = %v, want 103", snap.Asks)
+	}
+	if n := snapshotCalls.Load(); n != 2 {
+		t.Errorf("Fetched %d snapshots, want 2", n)
+	}
+}
@@ -0,0 +1,157 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/shubie/trading/internal/backoff"
+)
+
+const (
+	// aggTradesLimit is the largest page /api/v3/aggTrades returns.
+	aggTradesLimit = 1000
+
+	// DefaultBackfillWeightLimit is the request weight used in a minute
+	// past which backfills wait for the next one. Binance allows an IP
+	// 6000; the rest is left to order book snapshots and anything else
+	// sharing the address.
+	DefaultBackfillWeightLimit = 3000
+
+	// backfillAttempts is how many times in a row a page may fail before
+	// the backfill gives up on the rest of the gap.
+	backfillAttempts = 5
+)
+
+type backfillResult struct {
+	symbol string
+	trades []trade
+	// done marks the last result of a backfill; err is why it stopped
+	// before reaching the present, if it did.
+	done bool
+	err  error
+}
+
+// rateLimitError is a request Binance refused for exceeding its rate limit.
+type rateLimitError struct {
+	status     string
+	retryAfter time.Duration
+}
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("rate limited (%s), retry after %s", e.status, e.retryAfter)
+}
+
+// fetchAggTrades pages through the aggregate trades for symbol from fromID
+// up to the present, handing each page to deliver until it returns false.
+// Requests are paced by the weight Binance reports the IP has used this
+// minute, and a page refused for rate limiting is retried once Binance
+// allows it, so a long outage is filled completely, only more slowly.
+func (c *Client) fetchAggTrades(ctx context.Context, symbol string, fromID int64, deliver func([]trade) bool) error {
+	b := backoff.New(c.backoffMin, c.backoffMax)
+	failures := 0
+	for {
+		batch, usedWeight, err := c.aggTradesPage(ctx, symbol, fromID)
+		var limited *rateLimitError
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.As(err, &limited):
+			log.Printf("backfill binance %s: %v", symbol, limited)
+			if !sleep(ctx, limited.retryAfter) {
+				return ctx.Err()
+			}
+			continue
+		case err != nil:
+			if failures++; failures >= backfillAttempts {
+				return fmt.Errorf("%s trades from %d not backfilled: %w", symbol, fromID, err)
+			}
+			if !b.Wait(ctx) {
+				return ctx.Err()
+			}
+			continue
+		}
+		failures = 0
+		b.Reset()
+
+		trades := make([]trade, 0, len(batch))
+		for _, at := range batch {
+			t, err := at.trade(symbol)
+			if err != nil {
+				deliver(trades)
+				return fmt.Errorf("%s trade %d: %w", symbol, at.TradeID, err)
+			}
+			trades = append(trades, t)
+		}
+		if !deliver(trades) || len(batch) < aggTradesLimit {
+			return nil
+		}
+		fromID = batch[len(batch)-1].TradeID + 1
+
+		if usedWeight >= c.backfillWeightLimit && !sleep(ctx, untilNextMinute()) {
+			return ctx.Err()
+		}
+	}
+}
+
+// aggTradesPage fetches up to aggTradesLimit trades from fromID, along with
+// the request weight the IP has used this minute.
+func (c *Client) aggTradesPage(ctx context.Context, symbol string, fromID int64) ([]aggTrade, int, error) {
+	q := url.Values{}
+	q.Set("symbol", symbol)
+	q.Set("fromId", strconv.FormatInt(fromID, 10))
+	q.Set("limit", strconv.Itoa(aggTradesLimit))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.restURL+"/api/v3/aggTrades?"+q.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	usedWeight, _ := strconv.Atoi(resp.Header.Get("X-MBX-USED-WEIGHT-1M"))
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests, http.StatusTeapot:
+		// 418 means the IP was banned for ignoring 429s.
+		retryAfter := untilNextMinute()
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+		return nil, usedWeight, &rateLimitError{status: resp.Status, retryAfter: retryAfter}
+	default:
+		return nil, usedWeight, fmt.Errorf("aggTrades %s: %s", symbol, resp.Status)
+	}
+	var batch []aggTrade
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		return nil, usedWeight, err
+	}
+	return batch, usedWeight, nil
+}
+
+// untilNextMinute is how long until Binance's per-minute weight resets.
+func untilNextMinute() time.Duration {
+	now := time.Now()
+	return now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+}
+
+// sleep waits for d, returning false if ctx ends first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
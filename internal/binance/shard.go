@@ -0,0 +1,662 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/backoff"
+	"github.com/shubie/trading/internal/source"
+)
+
+const (
+	// handoverTimeout bounds how long a rotating shard runs two connections
+	// when the new one cannot be proven to have caught up with the old one.
+	handoverTimeout = 10 * time.Second
+
+	// rotationRetry is the delay before trying again when a replacement
+	// connection fails to dial.
+	rotationRetry = time.Minute
+
+	writeWait = 10 * time.Second
+)
+
+// shard is one combined stream connection and the streams it carries.
+type shard struct {
+	id     int
+	client *Client
+	cancel context.CancelFunc
+	state  source.ConnState
+
+	mu      sync.Mutex
+	streams map[string]time.Time // stream -> time subscribed
+	conn    *websocket.Conn
+	nextID  int
+
+	// lastTrade holds the last aggregate trade ID forwarded per symbol. It
+	// drops the duplicates delivered while two connections overlap and tells
+	// a backfill where to resume after an outage.
+	lastTrade map[string]int64
+}
+
+// connection is one dialled WebSocket carrying a shard's streams.
+type connection struct {
+	ws        *websocket.Conn
+	streams   []string
+	connected time.Time
+
+	once sync.Once
+	done chan struct{}
+}
+
+func (c *connection) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.ws.Close()
+	})
+}
+
+// keepalive pings the server so a half-open connection trips the read
+// deadline instead of hanging, and closes the connection on ctx cancel.
+func (c *connection) keepalive(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			c.close()
+			return
+		case <-c.done:
+			return
+		}
+	}
+}
+
+type trade struct {
+	id   int64
+	tick source.Tick
+}
+
+// frame is a trade or terminal read error from one of the shard's
+// connections.
+type frame struct {
+	conn  *connection
+	trade trade
+	err   error
+}
+
+func (s *shard) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams)
+}
+
+func (s *shard) subscribe(symbols []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	streams := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		stream := streamName(symbol)
+		s.streams[stream] = time.Now()
+		streams = append(streams, stream)
+	}
+	s.send("SUBSCRIBE", streams)
+}
+
+func (s *shard) unsubscribe(symbols []string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	streams := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		stream := streamName(symbol)
+		delete(s.streams, stream)
+		delete(s.lastTrade, symbol)
+		streams = append(streams, stream)
+	}
+	if len(s.streams) > 0 {
+		s.send("UNSUBSCRIBE", streams)
+	}
+	return len(s.streams)
+}
+
+// send issues a live subscription change. It must be called with s.mu held;
+// while disconnected the change is picked up by the next dial instead.
+func (s *shard) send(method string, streams []string) {
+	if s.conn == nil || len(streams) == 0 {
+		return
+	}
+	s.nextID++
+	err := s.conn.WriteJSON(map[string]interface{}{
+		"method": method,
+		"params": streams,
+		"id":     s.nextID,
+	})
+	if err != nil {
+		log.Printf("binance shard %d %s error: %v", s.id, method, err)
+	}
+}
+
+func (s *shard) streamList() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	streams := make([]string, 0, len(s.streams))
+	for stream := range s.streams {
+		streams = append(streams, stream)
+	}
+	sort.Strings(streams)
+	return streams
+}
+
+// attach makes c the live connection and reconciles any subscription
+// changes made while it was being dialled.
+func (s *shard) attach(c *connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.conn = c.ws
+	inURL := make(map[string]struct{}, len(c.streams))
+	for _, stream := range c.streams {
+		inURL[stream] = struct{}{}
+	}
+
+	var added, removed []string
+	for stream := range s.streams {
+		if _, ok := inURL[stream]; !ok {
+			added = append(added, stream)
+		}
+	}
+	for stream := range inURL {
+		if _, ok := s.streams[stream]; !ok {
+			removed = append(removed, stream)
+		}
+	}
+	s.send("SUBSCRIBE", added)
+	s.send("UNSUBSCRIBE", removed)
+}
+
+func (s *shard) detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn = nil
+}
+
+func (s *shard) dial(ctx context.Context) (*connection, error) {
+	streams := s.streamList()
+	url := fmt.Sprintf("%s/stream?streams=%s", s.client.baseURL, strings.Join(streams, "/"))
+	log.Printf("Connecting binance shard %d with %d streams", s.id, len(streams))
+
+	ws, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &connection{
+		ws:        ws,
+		streams:   streams,
+		connected: time.Now(),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// run owns the shard's connections. Normally there is one; ahead of
+// Binance's 24 hour disconnect a replacement is dialled and runs alongside
+// the old connection until it has provably caught up, so the switch loses
+// no trades. After an outage, or whenever trade IDs jump, the missing
+// trades are fetched over REST and forwarded ahead of the held back live
+// ones.
+func (s *shard) run(ctx context.Context, tickChan chan<- source.Tick) {
+	b := backoff.New(s.client.backoffMin, s.client.backoffMax)
+	defer s.state.Set(source.StateStopped, nil)
+
+	frames := make(chan frame)
+	replacements := make(chan *connection)
+	lastSeen := make(map[string]time.Time)
+	backfills := make(chan backfillResult)
+	// filling holds live trades back per symbol while a backfill runs,
+	// and backfilled counts the trades it has forwarded so far.
+	filling := make(map[string][]trade)
+	backfilled := make(map[string]int)
+
+	var (
+		primary, pending *connection
+		buffered         []trade
+		rotate, handover <-chan time.Time
+		watchdog         <-chan time.Time
+	)
+	if s.client.staleAfter > 0 {
+		ticker := time.NewTicker(s.client.staleAfter / 4)
+		defer ticker.Stop()
+		watchdog = ticker.C
+	}
+
+	startBackfill := func(symbol string, fromID int64, held []trade) {
+		filling[symbol] = held
+		go func() {
+			send := func(r backfillResult) bool {
+				select {
+				case backfills <- r:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			}
+			err := s.client.fetchAggTrades(ctx, symbol, fromID, func(trades []trade) bool {
+				return send(backfillResult{symbol: symbol, trades: trades})
+			})
+			send(backfillResult{symbol: symbol, done: true, err: err})
+		}()
+	}
+
+	deliver := func(t trade) {
+		symbol := t.tick.Symbol
+		if held, ok := filling[symbol]; ok {
+			filling[symbol] = append(held, t)
+			return
+		}
+		if last, ok := s.lastTradeID(symbol); ok && t.id > last+1 {
+			log.Printf("binance shard %d: %s jumped from trade %d to %d, backfilling", s.id, symbol, last, t.id)
+			startBackfill(symbol, last+1, []trade{t})
+			return
+		}
+		s.emit(ctx, tickChan, t)
+	}
+
+	promote := func() {
+		if primary != nil {
+			primary.close()
+		}
+		primary, pending = pending, nil
+		handover = nil
+		rotate = time.After(s.client.maxConnAge)
+		s.attach(primary)
+		for _, t := range buffered {
+			deliver(t)
+		}
+		buffered = nil
+	}
+
+	// drop abandons the primary connection and waits out the backoff.
+	drop := func(err error) bool {
+		primary.close()
+		s.detach()
+		if pending != nil {
+			promote()
+			return true
+		}
+
+		// Only a connection that stayed up for a while earns a fast retry;
+		// one that keeps dropping right after connecting keeps backing off.
+		if time.Since(primary.connected) >= source.StableConnection {
+			b.Reset()
+		}
+		primary = nil
+		s.state.Set(source.StateBackoff, err)
+		return b.Wait(ctx)
+	}
+
+	for {
+		if primary == nil {
+			s.state.Set(source.StateConnecting, nil)
+			conn, err := s.dial(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("dial binance shard %d error: %v", s.id, err)
+				s.state.Set(source.StateBackoff, err)
+				if !b.Wait(ctx) {
+					return
+				}
+				continue
+			}
+
+			primary = conn
+			s.attach(conn)
+			s.state.Set(source.StateLive, nil)
+			go s.read(ctx, conn, frames)
+			rotate = time.After(s.client.maxConnAge)
+
+			// Anything traded while disconnected is fetched before the
+			// symbol's live trades are let through.
+			for symbol, fromID := range s.resumePoints() {
+				if _, ok := filling[symbol]; !ok {
+					startBackfill(symbol, fromID, nil)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case f := <-frames:
+			switch {
+			case f.conn != primary && f.conn != pending:
+				// Straggler from a connection that has been retired.
+			case f.err != nil && f.conn == pending:
+				log.Printf("binance shard %d replacement connection error: %v", s.id, f.err)
+				pending, buffered, handover = nil, nil, nil
+				rotate = time.After(rotationRetry)
+			case f.err != nil:
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("read binance shard %d error: %v", s.id, f.err)
+				if !drop(f.err) {
+					return
+				}
+			case f.conn == primary:
+				lastSeen[streamName(f.trade.tick.Symbol)] = time.Now()
+				deliver(f.trade)
+				if pending != nil && s.caughtUp(buffered) {
+					promote()
+				}
+			default:
+				buffered = append(buffered, f.trade)
+				if s.caughtUp(buffered) {
+					promote()
+				}
+			}
+
+		case r := <-backfills:
+			// Pages are forwarded as they arrive; the held back live
+			// trades follow the last one.
+			for _, t := range r.trades {
+				s.emit(ctx, tickChan, t)
+			}
+			backfilled[r.symbol] += len(r.trades)
+			if !r.done {
+				continue
+			}
+			held, n := filling[r.symbol], backfilled[r.symbol]
+			delete(filling, r.symbol)
+			delete(backfilled, r.symbol)
+			if r.err != nil {
+				log.Printf("backfill binance %s error after %d trades: %v", r.symbol, n, r.err)
+			} else if n > 0 {
+				log.Printf("Backfilled %d binance %s trades", n, r.symbol)
+			}
+			for _, t := range held {
+				s.emit(ctx, tickChan, t)
+			}
+
+		case <-rotate:
+			rotate = nil
+			log.Printf("Rotating binance shard %d ahead of the 24h connection limit", s.id)
+			go func() {
+				conn, err := s.dial(ctx)
+				if err != nil {
+					log.Printf("dial binance shard %d replacement error: %v", s.id, err)
+				}
+				select {
+				case replacements <- conn:
+				case <-ctx.Done():
+					if conn != nil {
+						conn.close()
+					}
+				}
+			}()
+
+		case conn := <-replacements:
+			if conn == nil {
+				rotate = time.After(rotationRetry)
+				continue
+			}
+			pending = conn
+			go s.read(ctx, conn, frames)
+			handover = time.After(handoverTimeout)
+
+		case <-handover:
+			log.Printf("binance shard %d replacement did not catch up in %s, switching anyway", s.id, handoverTimeout)
+			promote()
+
+		case now := <-watchdog:
+			if stream, ok := s.silent(primary, lastSeen, now); ok {
+				err := fmt.Errorf("no trades on %s for %s", stream, s.client.staleAfter)
+				log.Printf("binance shard %d: %v, reconnecting", s.id, err)
+				if !drop(err) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// emit forwards a trade unless an overlapping connection or a backfill
+// already did, or its symbol has since been unsubscribed.
+func (s *shard) emit(ctx context.Context, tickChan chan<- source.Tick, t trade) {
+	if !s.advance(t) {
+		return
+	}
+
+	select {
+	case tickChan <- t.tick:
+	case <-ctx.Done():
+	}
+}
+
+func (s *shard) advance(t trade) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.streams[streamName(t.tick.Symbol)]; !ok {
+		return false
+	}
+	if t.id <= s.lastTrade[t.tick.Symbol] {
+		return false
+	}
+	s.lastTrade[t.tick.Symbol] = t.id
+	return true
+}
+
+// resumePoints returns the ID each subscribed symbol should resume from.
+func (s *shard) resumePoints() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	points := make(map[string]int64, len(s.lastTrade))
+	for symbol, id := range s.lastTrade {
+		points[symbol] = id + 1
+	}
+	return points
+}
+
+func (s *shard) lastTradeID(symbol string) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.lastTrade[symbol]
+	return id, ok
+}
+
+// caughtUp reports whether the trades buffered from a replacement connection
+// continue on directly from what the old connection has already forwarded,
+// i.e. switching over now would leave no gap.
+func (s *shard) caughtUp(buffered []trade) bool {
+	if len(buffered) == 0 {
+		return false
+	}
+
+	first := make(map[string]int64)
+	for _, t := range buffered {
+		if _, ok := first[t.tick.Symbol]; !ok {
+			first[t.tick.Symbol] = t.id
+		}
+	}
+	for symbol, id := range first {
+		if last, ok := s.lastTradeID(symbol); ok && id > last+1 {
+			return false
+		}
+	}
+	return true
+}
+
+// silent returns a stream that has had no trades on c for longer than the
+// stale timeout. Streams are given the full timeout from when they were
+// subscribed or the connection was made, whichever is later.
+func (s *shard) silent(c *connection, lastSeen map[string]time.Time, now time.Time) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for stream, subscribed := range s.streams {
+		seen := lastSeen[stream]
+		if c.connected.After(seen) {
+			seen = c.connected
+		}
+		if subscribed.After(seen) {
+			seen = subscribed
+		}
+		if now.Sub(seen) > s.client.staleAfter {
+			return stream, true
+		}
+	}
+	return "", false
+}
+
+// read delivers trades from c to the run loop until the connection fails.
+// Every message, ping and pong pushes the read deadline out, so a
+// connection that goes quiet at the TCP level is detected within
+// readTimeout.
+func (s *shard) read(ctx context.Context, c *connection, frames chan<- frame) {
+	go c.keepalive(ctx, s.client.pingInterval)
+
+	timeout := s.client.readTimeout
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(timeout))
+	})
+	c.ws.SetPingHandler(func(data string) error {
+		c.ws.SetReadDeadline(time.Now().Add(timeout))
+		err := c.ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(writeWait))
+		var netErr net.Error
+		if errors.Is(err, websocket.ErrCloseSent) || errors.As(err, &netErr) && netErr.Timeout() {
+			return nil
+		}
+		return err
+	})
+
+	for {
+		c.ws.SetReadDeadline(time.Now().Add(timeout))
+		_, message, err := c.ws.ReadMessage()
+		if err != nil {
+			c.close()
+			select {
+			case frames <- frame{conn: c, err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+
+		t, ok := s.parse(message)
+		if !ok {
+			continue
+		}
+		select {
+		case frames <- frame{conn: c, trade: t}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *shard) parse(message []byte) (trade, bool) {
+	var m struct {
+		Stream string          `json:"stream"`
+		Data   json.RawMessage `json:"data"`
+		ID     int             `json:"id"`
+		Error  *struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(message, &m); err != nil {
+		log.Printf("unmarshal binance shard %d error: %v", s.id, err)
+		return trade{}, false
+	}
+	if m.Error != nil {
+		log.Printf("binance shard %d request %d error %d: %s", s.id, m.ID, m.Error.Code, m.Error.Msg)
+		return trade{}, false
+	}
+	if m.Stream == "" {
+		// Result of a SUBSCRIBE/UNSUBSCRIBE request.
+		return trade{}, false
+	}
+
+	var at aggTrade
+	if err := json.Unmarshal(m.Data, &at); err != nil {
+		log.Printf("unmarshal %s error: %v", m.Stream, err)
+		return trade{}, false
+	}
+	t, err := at.trade(at.Symbol)
+	if err != nil {
+		log.Printf("binance %s trade %d rejected: %v", at.Symbol, at.TradeID, err)
+		return trade{}, false
+	}
+	return t, true
+}
+
+// aggTrade is an aggregate trade as sent on the aggTrade stream and returned
+// by /api/v3/aggTrades. The REST form has no symbol.
+type aggTrade struct {
+	TradeID      int64  `json:"a"`
+	Symbol       string `json:"s"`
+	Price        string `json:"p"`
+	Quantity     string `json:"q"`
+	FirstTradeID int64  `json:"f"`
+	LastTradeID  int64  `json:"l"`
+	Timestamp    int64  `json:"T"`
+	BuyerIsMaker bool   `json:"m"`
+	// Declared so the unrelated "M" flag is not matched to "m".
+	BestMatch bool `json:"M"`
+}
+
+func (t aggTrade) trade(symbol string) (trade, error) {
+	price, err := decimal.NewFromString(t.Price)
+	if err != nil {
+		return trade{}, fmt.Errorf("price %q: %w", t.Price, err)
+	}
+	qty, err := decimal.NewFromString(t.Quantity)
+	if err != nil {
+		return trade{}, fmt.Errorf("quantity %q: %w", t.Quantity, err)
+	}
+	// A zero or negative trade would pass through OHLC, VWAP and order
+	// flow as an exact, and wrong, value.
+	if !price.IsPositive() {
+		return trade{}, fmt.Errorf("price %q is not positive", t.Price)
+	}
+	if !qty.IsPositive() {
+		return trade{}, fmt.Errorf("quantity %q is not positive", t.Quantity)
+	}
+	// The buyer resting as maker means the seller crossed the spread.
+	side := source.SideBuy
+	if t.BuyerIsMaker {
+		side = source.SideSell
+	}
+	return trade{
+		id: t.TradeID,
+		tick: source.Tick{
+			Exchange:  Exchange,
+			Symbol:    symbol,
+			Price:     price,
+			Quantity:  qty,
+			Timestamp: time.Unix(0, t.Timestamp*int64(time.Millisecond)),
+			Side:      side,
+			Trades:    t.LastTradeID - t.FirstTradeID + 1,
+		},
+	}, nil
+}
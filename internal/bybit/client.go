@@ -0,0 +1,138 @@
+package bybit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/source"
+)
+
+const (
+	Exchange = "bybit"
+
+	// Bybit accepts at most 10 topics per spot subscribe request and drops
+	// connections that have not sent a ping for 20 seconds.
+	maxTopicsPerRequest = 10
+	pingInterval        = 20 * time.Second
+)
+
+// Client consumes Bybit v5 public "publicTrade" topics.
+type Client struct {
+	symbols []string
+	feed    *source.WebSocketFeed
+}
+
+func NewClient(wssURL string, symbols []string) *Client {
+	c := &Client{symbols: symbols}
+	c.feed = &source.WebSocketFeed{
+		Name:      Exchange,
+		URL:       wssURL,
+		Symbols:   symbols,
+		Subscribe: c.subscribe,
+		Parse:     parseMessage,
+
+		Keepalive:         ping,
+		KeepaliveInterval: pingInterval,
+	}
+	return c
+}
+
+func (c *Client) Name() string {
+	return Exchange
+}
+
+func (c *Client) Connect(ctx context.Context, tickChan chan<- source.Tick) {
+	c.feed.Run(ctx, tickChan)
+}
+
+func (c *Client) Status() []source.FeedStatus {
+	return c.feed.Status()
+}
+
+func (c *Client) subscribe(conn *websocket.Conn) error {
+	topics := make([]string, 0, len(c.symbols))
+	for _, symbol := range c.symbols {
+		topics = append(topics, "publicTrade."+symbol)
+	}
+	for len(topics) > 0 {
+		n := min(len(topics), maxTopicsPerRequest)
+		if err := conn.WriteJSON(map[string]interface{}{
+			"op":   "subscribe",
+			"args": topics[:n],
+		}); err != nil {
+			return err
+		}
+		topics = topics[n:]
+	}
+	return nil
+}
+
+func ping(conn *websocket.Conn) error {
+	return conn.WriteJSON(map[string]string{"op": "ping"})
+}
+
+func parseMessage(message []byte) ([]source.Tick, error) {
+	var m struct {
+		Topic   string `json:"topic"`
+		Op      string `json:"op"`
+		Success *bool  `json:"success"`
+		RetMsg  string `json:"ret_msg"`
+		Data    []struct {
+			Timestamp int64  `json:"T"`
+			Symbol    string `json:"s"`
+			Side      string `json:"S"` // taker side; also keeps "S" from matching "s"
+			Price     string `json:"p"`
+			Volume    string `json:"v"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(message, &m); err != nil {
+		return nil, err
+	}
+
+	if m.Success != nil && !*m.Success {
+		return nil, fmt.Errorf("%s failed: %s", m.Op, m.RetMsg)
+	}
+	if !strings.HasPrefix(m.Topic, "publicTrade.") {
+		return nil, nil
+	}
+
+	ticks := make([]source.Tick, 0, len(m.Data))
+	for _, t := range m.Data {
+		price, err := decimal.NewFromString(t.Price)
+		if err != nil {
+			return nil, fmt.Errorf("price %q: %w", t.Price, err)
+		}
+		qty, err := decimal.NewFromString(t.Volume)
+		if err != nil {
+			return nil, fmt.Errorf("volume %q: %w", t.Volume, err)
+		}
+		ticks = append(ticks, source.Tick{
+			Exchange:  Exchange,
+			Symbol:    t.Symbol,
+			Price:     price,
+			Quantity:  qty,
+			Timestamp: time.UnixMilli(t.Timestamp),
+			Side:      takerSide(t.Side),
+			Trades:    1,
+		})
+	}
+	return ticks, nil
+}
+
+// takerSide parses the side of the taker order Bybit reports on a trade.
+func takerSide(side string) source.Side {
+	switch side {
+	case "Buy":
+		return source.SideBuy
+	case "Sell":
+		return source.SideSell
+	default:
+		return source.SideUnknown
+	}
+}
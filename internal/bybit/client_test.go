@@ -0,0 +1,83 @@
+package bybit_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/shubie/trading/internal/bybit"
+	"github.com/shubie/trading/internal/source"
+)
+
+func TestClient_Connect(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	symbols := make([]string, 12)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("SYM%dUSDT", i)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade websocket: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		// 12 topics must arrive as two requests of at most 10.
+		topics := 0
+		for i := 0; i < 2; i++ {
+			var sub struct {
+				Op   string   `json:"op"`
+				Args []string `json:"args"`
+			}
+			if err := conn.ReadJSON(&sub); err != nil {
+				t.Errorf("Failed to read subscribe request: %v", err)
+				return
+			}
+			if sub.Op != "subscribe" || len(sub.Args) > 10 {
+				t.Errorf("Unexpected subscribe request: %+v", sub)
+			}
+			topics += len(sub.Args)
+		}
+		if topics != len(symbols) {
+			t.Errorf("expected %d topics, got %d", len(symbols), topics)
+		}
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"success":true,"op":"subscribe"}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"topic":"publicTrade.SYM0USDT","type":"snapshot","ts":1700000000001,"data":[{"T":1700000000000,"s":"SYM0USDT","S":"Buy","v":"0.5","p":"123.45","i":"1","BT":false}]}`))
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	client := bybit.NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), symbols)
+	tickChan := make(chan source.Tick)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go client.Connect(ctx, tickChan)
+
+	select {
+	case tick := <-tickChan:
+		if tick.Exchange != bybit.Exchange || tick.Symbol != "SYM0USDT" {
+			t.Errorf("unexpected instrument %s", tick.Instrument())
+		}
+		if tick.Price.String() != "123.45" || tick.Quantity.String() != "0.5" {
+			t.Errorf("expected 123.45 x 0.5, got %s x %s", tick.Price, tick.Quantity)
+		}
+		if tick.Side != source.SideBuy {
+			t.Errorf("expected a buy, got %s", tick.Side)
+		}
+		if tick.Timestamp.UnixMilli() != 1700000000000 {
+			t.Errorf("unexpected timestamp %v", tick.Timestamp)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for tick data")
+	}
+}
@@ -0,0 +1,115 @@
+package coinbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/source"
+)
+
+const Exchange = "coinbase"
+
+// Client consumes the Coinbase Exchange "matches" channel, which carries one
+// message per filled trade.
+type Client struct {
+	symbols []string
+	feed    *source.WebSocketFeed
+}
+
+func NewClient(wssURL string, symbols []string) *Client {
+	c := &Client{symbols: symbols}
+	c.feed = &source.WebSocketFeed{
+		Name:      Exchange,
+		URL:       wssURL,
+		Symbols:   symbols,
+		Subscribe: c.subscribe,
+		Parse:     parseMessage,
+	}
+	return c
+}
+
+func (c *Client) Name() string {
+	return Exchange
+}
+
+func (c *Client) Connect(ctx context.Context, tickChan chan<- source.Tick) {
+	c.feed.Run(ctx, tickChan)
+}
+
+func (c *Client) Status() []source.FeedStatus {
+	return c.feed.Status()
+}
+
+func (c *Client) subscribe(conn *websocket.Conn) error {
+	return conn.WriteJSON(map[string]interface{}{
+		"type":        "subscribe",
+		"product_ids": c.symbols,
+		"channels":    []string{"matches"},
+	})
+}
+
+func parseMessage(message []byte) ([]source.Tick, error) {
+	var m struct {
+		Type      string    `json:"type"`
+		ProductID string    `json:"product_id"`
+		Price     string    `json:"price"`
+		Size      string    `json:"size"`
+		Side      string    `json:"side"`
+		Time      time.Time `json:"time"`
+		Message   string    `json:"message"`
+		Reason    string    `json:"reason"`
+	}
+	if err := json.Unmarshal(message, &m); err != nil {
+		return nil, err
+	}
+
+	switch m.Type {
+	case "match":
+	case "error":
+		return nil, fmt.Errorf("%s: %s", m.Message, m.Reason)
+	default:
+		// subscriptions acks, and last_match which repeats a trade from
+		// before the subscription started.
+		if m.Type != "subscriptions" && m.Type != "last_match" {
+			log.Printf("coinbase: ignoring %q message", m.Type)
+		}
+		return nil, nil
+	}
+
+	price, err := decimal.NewFromString(m.Price)
+	if err != nil {
+		return nil, fmt.Errorf("price %q: %w", m.Price, err)
+	}
+	qty, err := decimal.NewFromString(m.Size)
+	if err != nil {
+		return nil, fmt.Errorf("size %q: %w", m.Size, err)
+	}
+	return []source.Tick{{
+		Exchange:  Exchange,
+		Symbol:    m.ProductID,
+		Price:     price,
+		Quantity:  qty,
+		Timestamp: m.Time,
+		Side:      takerSide(m.Side),
+		Trades:    1,
+	}}, nil
+}
+
+// takerSide converts the side of the maker order, which is what Coinbase
+// reports on a match, to the taker's side.
+func takerSide(makerSide string) source.Side {
+	switch makerSide {
+	case "buy":
+		return source.SideSell
+	case "sell":
+		return source.SideBuy
+	default:
+		return source.SideUnknown
+	}
+}
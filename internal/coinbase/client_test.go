@@ -0,0 +1,74 @@
+package coinbase_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/coinbase"
+	"github.com/shubie/trading/internal/source"
+)
+
+func TestClient_Connect(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade websocket: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		var sub struct {
+			Type       string   `json:"type"`
+			ProductIDs []string `json:"product_ids"`
+			Channels   []string `json:"channels"`
+		}
+		if err := conn.ReadJSON(&sub); err != nil {
+			t.Errorf("Failed to read subscribe request: %v", err)
+			return
+		}
+		if sub.Type != "subscribe" || len(sub.ProductIDs) != 1 || sub.ProductIDs[0] != "BTC-USD" {
+			t.Errorf("Unexpected subscribe request: %+v", sub)
+		}
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"subscriptions","channels":[]}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"last_match","product_id":"BTC-USD","price":"1.00","size":"1","time":"2024-01-01T00:00:00Z"}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"match","product_id":"BTC-USD","price":"45000.10","size":"0.25","side":"buy","time":"2024-01-01T00:00:01.5Z"}`))
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	client := coinbase.NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), []string{"BTC-USD"})
+	tickChan := make(chan source.Tick)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go client.Connect(ctx, tickChan)
+
+	select {
+	case tick := <-tickChan:
+		want := source.Tick{
+			Exchange:  coinbase.Exchange,
+			Symbol:    "BTC-USD",
+			Price:     decimal.RequireFromString("45000.10"),
+			Quantity:  decimal.RequireFromString("0.25"),
+			Timestamp: time.Date(2024, 1, 1, 0, 0, 1, 5e8, time.UTC),
+			// A resting buy order was hit, so the taker sold.
+			Side: source.SideSell,
+		}
+		if tick.Exchange != want.Exchange || tick.Symbol != want.Symbol || !tick.Price.Equal(want.Price) ||
+			!tick.Quantity.Equal(want.Quantity) || !tick.Timestamp.Equal(want.Timestamp) || tick.Side != want.Side {
+			t.Errorf("expected %+v, got %+v", want, tick)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for tick data")
+	}
+}
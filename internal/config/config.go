@@ -1,22 +1,132 @@
 package config
 
 import (
+	"log"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
+	// Sources lists the exchange connectors to run, e.g. [binance, kraken].
+	Sources []string `mapstructure:"sources"`
 	Binance struct {
-		WSSURL  string   `mapstructure:"wss_url"`
-		Symbols []string `mapstructure:"symbols"`
+		WSSURL                  string   `mapstructure:"wss_url"`
+		RESTURL                 string   `mapstructure:"rest_url"`
+		Symbols                 []string `mapstructure:"symbols"`
+		MaxStreamsPerConnection int      `mapstructure:"max_streams_per_connection"`
+		Backoff                 struct {
+			Min time.Duration `mapstructure:"min"`
+			Max time.Duration `mapstructure:"max"`
+		}
+		Keepalive struct {
+			PingInterval time.Duration `mapstructure:"ping_interval"`
+			ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+		}
+		// StaleAfter reconnects when a symbol has had no trades for this
+		// long; zero disables the check.
+		StaleAfter       time.Duration `mapstructure:"stale_after"`
+		MaxConnectionAge time.Duration `mapstructure:"max_connection_age"`
+		// Backfill fetches trades missed during an outage over REST,
+		// waiting for the next minute once the IP has used WeightLimit
+		// of Binance's per-minute request weight.
+		Backfill struct {
+			WeightLimit int `mapstructure:"weight_limit"`
+		}
+		// Depth keeps local order books from the @depth@100ms streams.
+		Depth struct {
+			Symbols []string `mapstructure:"symbols"`
+			// Limit is the number of levels per side in the REST
+			// snapshot a book starts from.
+			Limit int `mapstructure:"limit"`
+			// SnapshotInterval stores the top SnapshotLevels of every
+			// book this often; zero stores none.
+			SnapshotInterval time.Duration `mapstructure:"snapshot_interval"`
+			SnapshotLevels   int           `mapstructure:"snapshot_levels"`
+		}
+	}
+	Coinbase   ExchangeConfig `mapstructure:"coinbase"`
+	Kraken     ExchangeConfig `mapstructure:"kraken"`
+	Bybit      ExchangeConfig `mapstructure:"bybit"`
+	Aggregator struct {
+		Intervals []string       `mapstructure:"intervals"`
+		Symbols   []SymbolConfig `mapstructure:"symbols"`
+		// AllowedLateness is how far a tick may trail the newest tick of
+		// its symbol and still reach an open candle.
+		AllowedLateness time.Duration `mapstructure:"allowed_lateness"`
+		IdleTimeout     time.Duration `mapstructure:"idle_timeout"`
+		// LatePolicy is "drop" or "amend".
+		LatePolicy string `mapstructure:"late_policy"`
+	}
+	Derived struct {
+		HeikinAshi bool `mapstructure:"heikin_ashi"`
+		Renko      struct {
+			// BoxPercent sizes every symbol's bricks as a percentage of
+			// its first price; zero builds bricks only for Symbols.
+			BoxPercent float64       `mapstructure:"box_percent"`
+			Symbols    []RenkoConfig `mapstructure:"symbols"`
+		}
+	}
+	Indicators []IndicatorConfig `mapstructure:"indicators"`
+	Alerts     struct {
+		// DefaultCooldown is the minimum time between two triggers of an
+		// alert that sets no cooldown of its own.
+		DefaultCooldown time.Duration `mapstructure:"default_cooldown"`
 	}
 	GRPC struct {
 		Port int `mapstructure:"port"`
 	}
 	Storage struct {
+		// Backend is postgres (the default), sqlite or memory. Only
+		// postgres stores derived series, indicators, alerts and order
+		// books, and rolls up candles.
+		Backend string `mapstructure:"backend"`
+		// MergeMode is how a candle written over a stored bar with the
+		// same start combines with it: revision (the default) keeps the
+		// higher revision, combine merges the two.
+		MergeMode string `mapstructure:"merge_mode"`
+		// Batch is how many candles are written at once, how often a
+		// smaller batch is flushed, and how long a write may take before
+		// the batch is spooled instead.
+		Batch struct {
+			Size          int           `mapstructure:"size"`
+			FlushInterval time.Duration `mapstructure:"flush_interval"`
+			WriteTimeout  time.Duration `mapstructure:"write_timeout"`
+		}
 		Postgres struct {
 			DSN string `mapstructure:"dsn"`
+			// CopyThreshold is the smallest batch written with COPY
+			// through a staging table rather than a multi-row insert;
+			// zero or less never uses COPY.
+			CopyThreshold int `mapstructure:"copy_threshold"`
+		}
+		SQLite struct {
+			Path string `mapstructure:"path"`
+		}
+		// Rollup lists intervals derived from stored 1m candles, e.g.
+		// [5m, 1h, 1d].
+		Rollup struct {
+			Intervals []string `mapstructure:"intervals"`
+		}
+		// Retention sets how Postgres chunks, compresses and expires
+		// candles. Ages are intervals such as 7d; Keep maps an interval
+		// label to how long its bars are kept, Default covers the rest,
+		// and empty ages keep bars forever.
+		Retention struct {
+			ChunkInterval string            `mapstructure:"chunk_interval"`
+			CompressAfter string            `mapstructure:"compress_after"`
+			Default       string            `mapstructure:"default"`
+			Keep          map[string]string `mapstructure:"keep"`
+		}
+		// Spool keeps candle batches that fail to insert in a file at
+		// Path and replays them with backoff; an empty path drops them.
+		Spool struct {
+			Path    string `mapstructure:"path"`
+			Backoff struct {
+				Min time.Duration `mapstructure:"min"`
+				Max time.Duration `mapstructure:"max"`
+			}
 		}
 	}
 	Buffers struct {
@@ -29,6 +139,37 @@ type Config struct {
 	}
 }
 
+type ExchangeConfig struct {
+	WSSURL  string   `mapstructure:"wss_url"`
+	Symbols []string `mapstructure:"symbols"`
+}
+
+// SymbolConfig holds per-symbol aggregation overrides. Symbol is either a
+// bare symbol or "exchange:symbol".
+type SymbolConfig struct {
+	Symbol    string   `mapstructure:"symbol"`
+	Intervals []string `mapstructure:"intervals"`
+	// GapFill emits flat candles for intervals without trades.
+	GapFill bool `mapstructure:"gap_fill"`
+	// Bars lists information driven bars such as "tick:1000",
+	// "volume:50", "dollar:1000000" or "range:25".
+	Bars []string `mapstructure:"bars"`
+}
+
+// RenkoConfig fixes the Renko box size of a symbol, e.g. "50".
+type RenkoConfig struct {
+	Symbol string `mapstructure:"symbol"`
+	Box    string `mapstructure:"box"`
+}
+
+// IndicatorConfig lists the indicators computed for one symbol and
+// interval, e.g. ["rsi:14", "macd:12:26:9", "bb:20:2"].
+type IndicatorConfig struct {
+	Symbol     string   `mapstructure:"symbol"`
+	Interval   string   `mapstructure:"interval"`
+	Indicators []string `mapstructure:"indicators"`
+}
+
 func LoadConfig(path string) (*Config, error) {
 	viper.SetConfigFile(path)
 	viper.AutomaticEnv()
@@ -36,6 +177,26 @@ func LoadConfig(path string) (*Config, error) {
 	viper.SetDefault("health.data_timeout", 5*time.Minute)
 	viper.SetDefault("buffers.tick_chan", 1000)
 	viper.SetDefault("buffers.candle_chan", 500)
+	viper.SetDefault("storage.batch.size", 100)
+	viper.SetDefault("storage.batch.flush_interval", time.Second)
+	viper.SetDefault("storage.batch.write_timeout", 10*time.Second)
+	viper.SetDefault("storage.postgres.copy_threshold", 500)
+	viper.SetDefault("aggregator.intervals", []string{"1m"})
+	viper.SetDefault("aggregator.allowed_lateness", time.Second)
+	viper.SetDefault("aggregator.idle_timeout", 5*time.Second)
+	viper.SetDefault("aggregator.late_policy", "drop")
+	viper.SetDefault("sources", []string{"binance"})
+	viper.SetDefault("binance.rest_url", "https://api.binance.com")
+	viper.SetDefault("binance.max_streams_per_connection", 200)
+	viper.SetDefault("binance.backoff.min", time.Second)
+	viper.SetDefault("binance.backoff.max", time.Minute)
+	viper.SetDefault("binance.keepalive.ping_interval", 20*time.Second)
+	viper.SetDefault("binance.keepalive.read_timeout", time.Minute)
+	viper.SetDefault("binance.max_connection_age", 23*time.Hour)
+	viper.SetDefault("binance.backfill.weight_limit", 3000)
+	viper.SetDefault("coinbase.wss_url", "wss://ws-feed.exchange.coinbase.com")
+	viper.SetDefault("kraken.wss_url", "wss://ws.kraken.com/v2")
+	viper.SetDefault("bybit.wss_url", "wss://stream.bybit.com/v5/public/spot")
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
@@ -48,3 +209,17 @@ func LoadConfig(path string) (*Config, error) {
 
 	return &cfg, nil
 }
+
+// WatchConfig re-reads the config file whenever it changes on disk and
+// passes the new config to onChange.
+func WatchConfig(onChange func(*Config)) {
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		var cfg Config
+		if err := viper.Unmarshal(&cfg); err != nil {
+			log.Printf("Config reload error: %v", err)
+			return
+		}
+		onChange(&cfg)
+	})
+	viper.WatchConfig()
+}
@@ -0,0 +1,53 @@
+package derived
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/aggregator"
+)
+
+var (
+	two  = decimal.NewFromInt(2)
+	four = decimal.NewFromInt(4)
+)
+
+// heikinAshiFor turns a finalized candle into the next Heikin-Ashi candle of
+// its series:
+//
+//	close = (open + high + low + close) / 4
+//	open  = (previous HA open + previous HA close) / 2
+//	high  = max(high, HA open, HA close)
+//	low   = min(low, HA open, HA close)
+//
+// The first candle of a series, unless WarmUp restored the one before it,
+// opens at (open + close) / 2. In-progress
+// candles and corrections are skipped, as the series cannot be rewritten.
+func (s *Stage) heikinAshiFor(c aggregator.Candle) (Bar, bool) {
+	if !s.heikinAshi || !c.Finalized || c.Revision > 0 {
+		return Bar{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := haKey(c)
+	ha := c
+	ha.Close = c.Open.Add(c.High).Add(c.Low).Add(c.Close).DivRound(four, pricePlaces)
+	if prev, ok := s.ha[key]; ok {
+		if !c.StartTime.After(prev.StartTime) {
+			return Bar{}, false
+		}
+		ha.Open = prev.Open.Add(prev.Close).DivRound(two, pricePlaces)
+	} else {
+		ha.Open = c.Open.Add(c.Close).DivRound(two, pricePlaces)
+	}
+	ha.High = decimal.Max(c.High, ha.Open, ha.Close)
+	ha.Low = decimal.Min(c.Low, ha.Open, ha.Close)
+	s.ha[key] = ha
+
+	return Bar{Kind: HeikinAshi, Candle: ha}, true
+}
+
+func haKey(c aggregator.Candle) string {
+	return c.Instrument().String() + "|" + c.Interval
+}
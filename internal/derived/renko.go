@@ -0,0 +1,126 @@
+package derived
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// brickState is the Renko state of one instrument: the top and bottom of
+// the last brick, and the trading gathered since it was laid.
+type brickState struct {
+	box       decimal.Decimal
+	high, low decimal.Decimal
+	pending   aggregator.Candle
+	lastStart time.Time
+}
+
+func (s *Stage) boxFor(inst source.Instrument, price decimal.Decimal) decimal.Decimal {
+	if box, ok := s.fixedBox(inst); ok {
+		return box
+	}
+	return price.Mul(s.renkoPercent).Div(hundred)
+}
+
+// fixedBox returns the box set for an instrument by WithSymbolRenko.
+func (s *Stage) fixedBox(inst source.Instrument) (decimal.Decimal, bool) {
+	if box, ok := s.symbolBoxes[inst.String()]; ok {
+		return box, true
+	}
+	box, ok := s.symbolBoxes[inst.Symbol]
+	return box, ok
+}
+
+// renko feeds a tick to its instrument's bricks and returns the bricks it
+// completes. A brick is laid each time the price closes a full box beyond
+// the last brick in its direction, or two boxes against it. Volume traded
+// since the last brick goes to the first new one; bricks are flat, with high
+// and low at their open and close.
+func (s *Stage) renko(tick source.Tick) []Bar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inst := tick.Instrument()
+	st, ok := s.bricks[inst]
+	if !ok {
+		box := s.boxFor(inst, tick.Price)
+		if !box.IsPositive() {
+			return nil
+		}
+		st = &brickState{box: box, high: tick.Price, low: tick.Price}
+		s.bricks[inst] = st
+	}
+
+	if st.pending.StartTime.IsZero() {
+		st.pending = aggregator.Candle{
+			Exchange:  tick.Exchange,
+			Symbol:    tick.Symbol,
+			Interval:  RenkoInterval,
+			StartTime: tick.Timestamp,
+		}
+	}
+	accumulate(&st.pending, tick)
+
+	var bricks []Bar
+	lay := func(open, close decimal.Decimal) {
+		brick := st.pending
+		brick.Open, brick.Close = open, close
+		brick.High, brick.Low = decimal.Max(open, close), decimal.Min(open, close)
+		if !brick.StartTime.After(st.lastStart) {
+			// Keep start times unique when one tick lays several bricks.
+			brick.StartTime = st.lastStart.Add(time.Microsecond)
+		}
+		brick.EndTime = tick.Timestamp
+		if brick.EndTime.Before(brick.StartTime) {
+			brick.EndTime = brick.StartTime
+		}
+		brick.Finalized = true
+		st.lastStart = brick.StartTime
+		bricks = append(bricks, Bar{Kind: Renko, Candle: brick, Box: st.box})
+
+		st.pending = aggregator.Candle{
+			Exchange:  tick.Exchange,
+			Symbol:    tick.Symbol,
+			Interval:  RenkoInterval,
+			StartTime: tick.Timestamp,
+		}
+	}
+
+	for !tick.Price.LessThan(st.high.Add(st.box)) {
+		lay(st.high, st.high.Add(st.box))
+		st.low, st.high = st.high, st.high.Add(st.box)
+	}
+	for !tick.Price.GreaterThan(st.low.Sub(st.box)) {
+		lay(st.low, st.low.Sub(st.box))
+		st.high, st.low = st.low, st.low.Sub(st.box)
+	}
+	if len(bricks) > 0 {
+		st.pending = aggregator.Candle{}
+	}
+	return bricks
+}
+
+// accumulate adds a tick's volume to a pending brick.
+func accumulate(c *aggregator.Candle, tick source.Tick) {
+	c.Volume = c.Volume.Add(tick.Quantity)
+	c.QuoteVolume = c.QuoteVolume.Add(tick.Price.Mul(tick.Quantity))
+	if c.Volume.IsPositive() {
+		c.VWAP = c.QuoteVolume.DivRound(c.Volume, pricePlaces)
+	}
+	switch tick.Side {
+	case source.SideBuy:
+		c.TakerBuyVolume = c.TakerBuyVolume.Add(tick.Quantity)
+	case source.SideSell:
+		c.TakerSellVolume = c.TakerSellVolume.Add(tick.Quantity)
+	}
+	if tick.Trades > 0 {
+		c.TradeCount += tick.Trades
+	} else {
+		c.TradeCount++
+	}
+}
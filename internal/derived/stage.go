@@ -0,0 +1,208 @@
+// Package derived builds series computed from the regular pipeline:
+// Heikin-Ashi candles from finalized candles and Renko bricks from ticks.
+package derived
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+)
+
+// Kind identifies a derived series.
+type Kind int
+
+const (
+	HeikinAshi Kind = iota + 1
+	Renko
+)
+
+func (k Kind) String() string {
+	switch k {
+	case HeikinAshi:
+		return "heikin_ashi"
+	case Renko:
+		return "renko"
+	default:
+		return "unknown"
+	}
+}
+
+// RenkoInterval is the interval label Renko bricks carry; an instrument has
+// one brick series.
+const RenkoInterval = "renko"
+
+// pricePlaces is the number of decimal places derived prices are rounded to.
+const pricePlaces = 18
+
+// Bar is one candle of a derived series.
+type Bar struct {
+	Kind Kind `db:"-"`
+	aggregator.Candle
+	// Box is the brick size of a Renko brick.
+	Box decimal.Decimal `db:"box_size"`
+}
+
+type Option func(*Stage)
+
+// WithHeikinAshi turns Heikin-Ashi candles on or off for every finalized
+// candle series.
+func WithHeikinAshi(enabled bool) Option {
+	return func(s *Stage) {
+		s.heikinAshi = enabled
+	}
+}
+
+// WithRenkoPercent builds Renko bricks for every instrument, sized as a
+// percentage of its first traded price. Zero builds bricks only for symbols
+// given a box by WithSymbolRenko.
+func WithRenkoPercent(percent decimal.Decimal) Option {
+	return func(s *Stage) {
+		s.renkoPercent = percent
+	}
+}
+
+// WithSymbolRenko sets a fixed Renko box size for a symbol. The symbol may be
+// qualified as "exchange:symbol" to apply to one venue only.
+func WithSymbolRenko(symbol string, box decimal.Decimal) Option {
+	return func(s *Stage) {
+		s.symbolBoxes[symbol] = box
+	}
+}
+
+// Stage sits between the feeds, the aggregator and storage: it passes ticks
+// and candles through unchanged and builds derived series from them on the
+// way.
+type Stage struct {
+	heikinAshi   bool
+	renkoPercent decimal.Decimal
+	symbolBoxes  map[string]decimal.Decimal
+	hub          *aggregator.Hub
+
+	mu     sync.Mutex
+	ha     map[string]aggregator.Candle // previous Heikin-Ashi candle per series
+	bricks map[source.Instrument]*brickState
+}
+
+func NewStage(opts ...Option) *Stage {
+	s := &Stage{
+		symbolBoxes: make(map[string]decimal.Decimal),
+		hub:         aggregator.NewHub(),
+		ha:          make(map[string]aggregator.Candle),
+		bricks:      make(map[source.Instrument]*brickState),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// BarSource supplies the derived series already stored.
+type BarSource interface {
+	// LatestDerived returns the newest Heikin-Ashi candle of every series
+	// and the newest Renko brick of every instrument.
+	LatestDerived(ctx context.Context) ([]Bar, error)
+}
+
+// WarmUp continues the series in src instead of starting them afresh, so
+// after a restart the next Heikin-Ashi candle opens from the previous one,
+// and bricks keep their box and are laid from the edges of the last brick.
+// A symbol given a fixed box by WithSymbolRenko uses that box.
+func (s *Stage) WarmUp(ctx context.Context, src BarSource) error {
+	bars, err := src.LatestDerived(ctx)
+	if err != nil {
+		return fmt.Errorf("warm up derived series: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, bar := range bars {
+		switch bar.Kind {
+		case HeikinAshi:
+			if s.heikinAshi {
+				s.ha[haKey(bar.Candle)] = bar.Candle
+			}
+		case Renko:
+			inst := bar.Instrument()
+			box, ok := s.fixedBox(inst)
+			if !ok {
+				if !s.renkoPercent.IsPositive() {
+					continue
+				}
+				box = bar.Box
+			}
+			s.bricks[inst] = &brickState{
+				box:       box,
+				high:      decimal.Max(bar.Open, bar.Close),
+				low:       decimal.Min(bar.Open, bar.Close),
+				lastStart: bar.StartTime,
+			}
+		}
+	}
+	log.Printf("Warmed up %d derived series", len(bars))
+	return nil
+}
+
+// Run forwards tickIn to tickOut and candleIn to candleOut, sending derived
+// bars to barChan. It returns once both inputs are closed, closing all three
+// outputs.
+func (s *Stage) Run(ctx context.Context,
+	tickIn <-chan source.Tick, tickOut chan<- source.Tick,
+	candleIn <-chan aggregator.Candle, candleOut chan<- aggregator.Candle,
+	barChan chan<- Bar) {
+	defer close(barChan)
+	log.Println("Derived series stage started")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer close(tickOut)
+		for tick := range tickIn {
+			for _, brick := range s.renko(tick) {
+				s.emit(brick, barChan)
+			}
+			select {
+			case tickOut <- tick:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		defer close(candleOut)
+		// The aggregator flushes its last candles after ctx is done, so
+		// keep draining until it closes the channel, and pass every
+		// candle on: downstream drains until this closes candleOut.
+		for candle := range candleIn {
+			if bar, ok := s.heikinAshiFor(candle); ok {
+				s.emit(bar, barChan)
+			}
+			candleOut <- candle
+		}
+	}()
+	wg.Wait()
+	log.Println("Derived series stage stopped")
+}
+
+// emit publishes bar and sends it to barChan. The send blocks even after
+// ctx is done: the persister drains barChan until Run closes it, and the
+// bars of the candles flushed at shutdown must reach it.
+func (s *Stage) emit(bar Bar, barChan chan<- Bar) {
+	s.hub.Publish(bar.Candle)
+	barChan <- bar
+}
+
+// Subscribe returns a subscription to one derived series of the instruments:
+// Heikin-Ashi candles of an interval label, or Renko bricks with
+// RenkoInterval.
+func (s *Stage) Subscribe(insts []source.Instrument, interval string, throttle time.Duration) *aggregator.Subscription {
+	return s.hub.Subscribe(insts, interval, throttle)
+}
@@ -0,0 +1,205 @@
+package derived_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/derived"
+	"github.com/shubie/trading/internal/source"
+)
+
+// run pushes ticks and candles through a stage and returns what came out.
+func run(t *testing.T, stage *derived.Stage, ticks []source.Tick, candles []aggregator.Candle) ([]source.Tick, []aggregator.Candle, []derived.Bar) {
+	t.Helper()
+	tickIn := make(chan source.Tick)
+	tickOut := make(chan source.Tick, len(ticks))
+	candleIn := make(chan aggregator.Candle)
+	candleOut := make(chan aggregator.Candle, len(candles))
+	barChan := make(chan derived.Bar, 100)
+
+	done := make(chan struct{})
+	go func() {
+		stage.Run(context.Background(), tickIn, tickOut, candleIn, candleOut, barChan)
+		close(done)
+	}()
+	for _, tick := range ticks {
+		tickIn <- tick
+	}
+	close(tickIn)
+	for _, candle := range candles {
+		candleIn <- candle
+	}
+	close(candleIn)
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timeout waiting for the stage to stop")
+	}
+
+	var gotTicks []source.Tick
+	for tick := range tickOut {
+		gotTicks = append(gotTicks, tick)
+	}
+	var gotCandles []aggregator.Candle
+	for candle := range candleOut {
+		gotCandles = append(gotCandles, candle)
+	}
+	var bars []derived.Bar
+	for bar := range barChan {
+		bars = append(bars, bar)
+	}
+	return gotTicks, gotCandles, bars
+}
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestStage_HeikinAshi(t *testing.T) {
+	base := time.Now().Truncate(time.Minute)
+	candle := func(i int, open, high, low, close string) aggregator.Candle {
+		return aggregator.Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Interval: "1m",
+			Open: dec(open), High: dec(high), Low: dec(low), Close: dec(close), Volume: dec("1"),
+			StartTime: base.Add(time.Duration(i) * time.Minute), EndTime: base.Add(time.Duration(i+1) * time.Minute),
+			Finalized: true,
+		}
+	}
+	inProgress := candle(2, "1", "1", "1", "1")
+	inProgress.Finalized = false
+	candles := []aggregator.Candle{
+		candle(0, "10", "14", "9", "12"),
+		candle(1, "12", "13", "8", "9"),
+		inProgress,
+	}
+
+	_, passed, bars := run(t, derived.NewStage(derived.WithHeikinAshi(true)), nil, candles)
+	if len(passed) != len(candles) {
+		t.Errorf("Expected every candle passed through, got %d", len(passed))
+	}
+	if len(bars) != 2 {
+		t.Fatalf("Expected 2 Heikin-Ashi candles, got %d: %+v", len(bars), bars)
+	}
+
+	want := []struct{ open, high, low, close string }{
+		// close (10+14+9+12)/4, open (10+12)/2
+		{"11", "14", "9", "11.25"},
+		// close (12+13+8+9)/4, open (11+11.25)/2
+		{"11.125", "13", "8", "10.5"},
+	}
+	for i, w := range want {
+		ha := bars[i]
+		if ha.Kind != derived.HeikinAshi || ha.Interval != "1m" {
+			t.Errorf("Candle %d: expected a 1m Heikin-Ashi candle, got %+v", i, ha)
+		}
+		if !ha.Open.Equal(dec(w.open)) || !ha.High.Equal(dec(w.high)) || !ha.Low.Equal(dec(w.low)) || !ha.Close.Equal(dec(w.close)) {
+			t.Errorf("Candle %d: expected OHLC %s/%s/%s/%s, got %s/%s/%s/%s", i, w.open, w.high, w.low, w.close, ha.Open, ha.High, ha.Low, ha.Close)
+		}
+	}
+}
+
+func TestStage_Renko(t *testing.T) {
+	base := time.Now().Truncate(time.Minute)
+	var ticks []source.Tick
+	for i, price := range []string{"100", "104", "110", "121", "115", "109", "100"} {
+		ticks = append(ticks, source.Tick{
+			Exchange: "binance", Symbol: "BTCUSDT", Price: dec(price), Quantity: dec("1"),
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+		})
+	}
+	// Another symbol without a box gets no bricks.
+	ticks = append(ticks, source.Tick{Exchange: "binance", Symbol: "ETHUSDT", Price: dec("1"), Quantity: dec("1"), Timestamp: base})
+
+	stage := derived.NewStage(derived.WithSymbolRenko("BTCUSDT", dec("10")))
+	passed, _, bars := run(t, stage, ticks, nil)
+	if len(passed) != len(ticks) {
+		t.Errorf("Expected every tick passed through, got %d", len(passed))
+	}
+
+	// Up 100->110 on the third tick and 110->120 on the fourth. The
+	// reversal needs two boxes from the top, so 109 lays nothing and 100
+	// lays 110->100.
+	want := []struct {
+		open, close string
+		volume      string
+	}{
+		{"100", "110", "3"},
+		{"110", "120", "1"},
+		{"110", "100", "3"},
+	}
+	if len(bars) != len(want) {
+		t.Fatalf("Expected %d bricks, got %d: %+v", len(want), len(bars), bars)
+	}
+	for i, w := range want {
+		brick := bars[i]
+		if brick.Kind != derived.Renko || brick.Interval != derived.RenkoInterval || !brick.Box.Equal(dec("10")) {
+			t.Errorf("Brick %d: expected a Renko brick of 10, got %+v", i, brick)
+		}
+		if !brick.Open.Equal(dec(w.open)) || !brick.Close.Equal(dec(w.close)) || !brick.Volume.Equal(dec(w.volume)) {
+			t.Errorf("Brick %d: expected %s->%s volume %s, got %s->%s volume %s", i, w.open, w.close, w.volume, brick.Open, brick.Close, brick.Volume)
+		}
+		if i > 0 && !brick.StartTime.After(bars[i-1].StartTime) {
+			t.Errorf("Brick %d: start %v not after %v", i, brick.StartTime, bars[i-1].StartTime)
+		}
+	}
+}
+
+type barSource []derived.Bar
+
+func (b barSource) LatestDerived(context.Context) ([]derived.Bar, error) {
+	return b, nil
+}
+
+func TestStage_WarmUp(t *testing.T) {
+	base := time.Now().Truncate(time.Minute)
+	stored := barSource{
+		{Kind: derived.HeikinAshi, Candle: aggregator.Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Interval: "1m",
+			Open: dec("11"), High: dec("14"), Low: dec("9"), Close: dec("11.25"),
+			StartTime: base, EndTime: base.Add(time.Minute), Finalized: true,
+		}},
+		{Kind: derived.Renko, Box: dec("10"), Candle: aggregator.Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Interval: derived.RenkoInterval,
+			Open: dec("100"), High: dec("110"), Low: dec("100"), Close: dec("110"),
+			StartTime: base, EndTime: base, Finalized: true,
+		}},
+	}
+	// A percentage box would be 1.2 at the first price after the restart.
+	stage := derived.NewStage(derived.WithHeikinAshi(true), derived.WithRenkoPercent(dec("1")))
+	if err := stage.WarmUp(context.Background(), stored); err != nil {
+		t.Fatalf("WarmUp: %v", err)
+	}
+
+	candle := func(i int, open, high, low, close string) aggregator.Candle {
+		return aggregator.Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Interval: "1m",
+			Open: dec(open), High: dec(high), Low: dec(low), Close: dec(close), Volume: dec("1"),
+			StartTime: base.Add(time.Duration(i) * time.Minute), EndTime: base.Add(time.Duration(i+1) * time.Minute),
+			Finalized: true,
+		}
+	}
+	ticks := []source.Tick{{
+		Exchange: "binance", Symbol: "BTCUSDT", Price: dec("120"), Quantity: dec("1"),
+		Timestamp: base.Add(time.Minute),
+	}}
+	// The stored candle comes round again, as the feed replays it.
+	candles := []aggregator.Candle{candle(0, "10", "14", "9", "12"), candle(1, "12", "13", "8", "9")}
+
+	_, _, bars := run(t, stage, ticks, candles)
+	if len(bars) != 2 {
+		t.Fatalf("Expected a brick and a Heikin-Ashi candle, got %d: %+v", len(bars), bars)
+	}
+	brick, ha := bars[0], bars[1]
+	if brick.Kind != derived.Renko || !brick.Box.Equal(dec("10")) || !brick.Open.Equal(dec("110")) || !brick.Close.Equal(dec("120")) {
+		t.Errorf("Expected a 110->120 brick of 10, got %+v", brick)
+	}
+	// open (11+11.25)/2, from the stored candle rather than the first one.
+	if ha.Kind != derived.HeikinAshi || !ha.StartTime.Equal(base.Add(time.Minute)) || !ha.Open.Equal(dec("11.125")) || !ha.Close.Equal(dec("10.5")) {
+		t.Errorf("Expected the second candle to open at 11.125 and close at 10.5, got %+v", ha)
+	}
+}
@@ -0,0 +1,66 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	candlestickpb "github.com/shubie/trading/api/protos/candlestick"
+	"github.com/shubie/trading/internal/storage"
+)
+
+// StorageStatusReader reports the state of the storage backend.
+type StorageStatusReader interface {
+	StorageStatus(ctx context.Context) (storage.StorageStatus, error)
+}
+
+// WithStorageStatus serves GetStorageStatus from r.
+func WithStorageStatus(r StorageStatusReader) Option {
+	return func(s *Server) {
+		s.storageStatus = r
+	}
+}
+
+func (s *Server) GetStorageStatus(ctx context.Context, req *candlestickpb.StorageStatusRequest) (*candlestickpb.StorageStatus, error) {
+	if s.storageStatus == nil {
+		return nil, status.Error(codes.Unavailable, "storage status needs the postgres backend")
+	}
+	st, err := s.storageStatus.StorageStatus(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "storage status: %v", err)
+	}
+
+	resp := &candlestickpb.StorageStatus{
+		ChunkIntervalMs:        st.ChunkInterval.Milliseconds(),
+		Chunks:                 int32(st.Chunks),
+		CompressedChunks:       int32(st.CompressedChunks),
+		CompressionEnabled:     st.CompressionEnabled,
+		CompressAfterMs:        st.CompressAfter.Milliseconds(),
+		DropAfterMs:            st.DropAfter.Milliseconds(),
+		TotalBytes:             st.TotalBytes,
+		BeforeCompressionBytes: st.BeforeCompressionBytes,
+		AfterCompressionBytes:  st.AfterCompressionBytes,
+		ReconciledAtMs:         unixMilli(st.ReconciledAt),
+		LastError:              st.LastError,
+	}
+	for _, r := range st.Retention {
+		resp.Retention = append(resp.Retention, &candlestickpb.IntervalRetention{
+			Interval:  r.Interval,
+			KeepMs:    r.Keep.Milliseconds(),
+			OldestMs:  unixMilli(r.Oldest),
+			Deleted:   r.Deleted,
+			LastRunMs: unixMilli(r.LastRun),
+		})
+	}
+	return resp, nil
+}
+
+// unixMilli is zero for the zero time.
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
@@ -0,0 +1,170 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	candlestickpb "github.com/shubie/trading/api/protos/candlestick"
+	"github.com/shubie/trading/internal/alerts"
+	"github.com/shubie/trading/internal/source"
+)
+
+var (
+	kindToProto = map[alerts.Kind]candlestickpb.Alert_Kind{
+		alerts.PriceCross:  candlestickpb.Alert_PRICE_CROSS,
+		alerts.CandleRange: candlestickpb.Alert_CANDLE_RANGE,
+		alerts.VolumeSpike: candlestickpb.Alert_VOLUME_SPIKE,
+	}
+	directionToProto = map[alerts.Direction]candlestickpb.Alert_Direction{
+		alerts.Any:  candlestickpb.Alert_ANY,
+		alerts.Up:   candlestickpb.Alert_UP,
+		alerts.Down: candlestickpb.Alert_DOWN,
+	}
+)
+
+func (s *Server) CreateAlert(ctx context.Context, req *candlestickpb.CreateAlertRequest) (*candlestickpb.Alert, error) {
+	if s.alerts == nil {
+		return nil, status.Error(codes.Unavailable, "alerts not enabled")
+	}
+	a, err := alertFromProto(req.Alert)
+	if err != nil {
+		return nil, err
+	}
+	if a, err = s.alerts.Create(ctx, a); err != nil {
+		return nil, alertError(err)
+	}
+	return alertToProto(a), nil
+}
+
+func (s *Server) UpdateAlert(ctx context.Context, req *candlestickpb.UpdateAlertRequest) (*candlestickpb.Alert, error) {
+	if s.alerts == nil {
+		return nil, status.Error(codes.Unavailable, "alerts not enabled")
+	}
+	a, err := alertFromProto(req.Alert)
+	if err != nil {
+		return nil, err
+	}
+	if a, err = s.alerts.Update(ctx, a); err != nil {
+		return nil, alertError(err)
+	}
+	return alertToProto(a), nil
+}
+
+func (s *Server) DeleteAlert(ctx context.Context, req *candlestickpb.DeleteAlertRequest) (*candlestickpb.DeleteAlertResponse, error) {
+	if s.alerts == nil {
+		return nil, status.Error(codes.Unavailable, "alerts not enabled")
+	}
+	if err := s.alerts.Delete(ctx, req.Id); err != nil {
+		return nil, alertError(err)
+	}
+	return &candlestickpb.DeleteAlertResponse{}, nil
+}
+
+func (s *Server) ListAlerts(ctx context.Context, req *candlestickpb.ListAlertsRequest) (*candlestickpb.ListAlertsResponse, error) {
+	if s.alerts == nil {
+		return nil, status.Error(codes.Unavailable, "alerts not enabled")
+	}
+	insts := make([]source.Instrument, 0, len(req.Symbols))
+	for _, symbol := range req.Symbols {
+		insts = append(insts, source.ParseInstrument(symbol))
+	}
+	resp := &candlestickpb.ListAlertsResponse{}
+	for _, a := range s.alerts.List(insts) {
+		resp.Alerts = append(resp.Alerts, alertToProto(a))
+	}
+	return resp, nil
+}
+
+func (s *Server) StreamAlerts(req *candlestickpb.StreamAlertsRequest, stream candlestickpb.AlertService_StreamAlertsServer) error {
+	if s.alerts == nil {
+		return status.Error(codes.Unavailable, "alerts not enabled")
+	}
+	sub := s.alerts.Subscribe(req.AlertIds)
+	defer sub.Close()
+
+	for {
+		events, err := sub.Next(stream.Context())
+		if err != nil {
+			return nil
+		}
+		for _, e := range events {
+			if err := stream.Send(&candlestickpb.AlertEvent{
+				Id:          e.ID,
+				AlertId:     e.AlertID,
+				Exchange:    e.Exchange,
+				Symbol:      e.Symbol,
+				Kind:        kindToProto[e.Kind],
+				Value:       e.Value.String(),
+				Message:     e.Message,
+				TriggeredAt: e.TriggeredAt.UnixMilli(),
+			}); err != nil {
+				return status.Errorf(codes.Aborted, "stream error: %v", err)
+			}
+		}
+	}
+}
+
+func alertError(err error) error {
+	switch {
+	case errors.Is(err, alerts.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, alerts.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "alert storage: %v", err)
+	}
+}
+
+func alertFromProto(pb *candlestickpb.Alert) (alerts.Alert, error) {
+	if pb == nil {
+		return alerts.Alert{}, status.Error(codes.InvalidArgument, "alert is required")
+	}
+	threshold, err := decimal.NewFromString(pb.Threshold)
+	if err != nil {
+		return alerts.Alert{}, status.Errorf(codes.InvalidArgument, "invalid threshold %q", pb.Threshold)
+	}
+	a := alerts.Alert{
+		ID:         pb.Id,
+		Symbol:     pb.Symbol,
+		Interval:   pb.Interval,
+		Threshold:  threshold,
+		Lookback:   int(pb.Lookback),
+		Cooldown:   time.Duration(pb.CooldownMs) * time.Millisecond,
+		WebhookURL: pb.WebhookUrl,
+	}
+	for kind, k := range kindToProto {
+		if k == pb.Kind {
+			a.Kind = kind
+		}
+	}
+	for dir, d := range directionToProto {
+		if d == pb.Direction {
+			a.Direction = dir
+		}
+	}
+	return a, nil
+}
+
+func alertToProto(a alerts.Alert) *candlestickpb.Alert {
+	pb := &candlestickpb.Alert{
+		Id:         a.ID,
+		Symbol:     source.Instrument{Exchange: a.Exchange, Symbol: a.Symbol}.String(),
+		Kind:       kindToProto[a.Kind],
+		Interval:   a.Interval,
+		Threshold:  a.Threshold.String(),
+		Direction:  directionToProto[a.Direction],
+		Lookback:   int32(a.Lookback),
+		CooldownMs: a.Cooldown.Milliseconds(),
+		WebhookUrl: a.WebhookURL,
+		CreatedAt:  a.CreatedAt.UnixMilli(),
+	}
+	if !a.LastTriggered.IsZero() {
+		pb.LastTriggeredAt = a.LastTriggered.UnixMilli()
+	}
+	return pb
+}
@@ -0,0 +1,100 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	candlestickpb "github.com/shubie/trading/api/protos/candlestick"
+	"github.com/shubie/trading/internal/orderbook"
+	"github.com/shubie/trading/internal/source"
+)
+
+const (
+	defaultBookDepth    = 10
+	defaultBookInterval = 100 * time.Millisecond
+)
+
+func (s *Server) GetOrderBook(ctx context.Context, req *candlestickpb.OrderBookRequest) (*candlestickpb.OrderBook, error) {
+	if s.books == nil {
+		return nil, status.Error(codes.Unavailable, "order books not enabled")
+	}
+	snap, ok := s.books.Book(source.ParseInstrument(req.Symbol), bookDepth(req.Depth))
+	if !ok {
+		return nil, status.Errorf(codes.Unavailable, "no order book in sync for %s", req.Symbol)
+	}
+	return bookToProto(snap), nil
+}
+
+// StreamOrderBook sends the book every interval_ms while it changes. While
+// the book is resyncing nothing is sent.
+func (s *Server) StreamOrderBook(req *candlestickpb.OrderBookRequest, stream candlestickpb.CandlestickService_StreamOrderBookServer) error {
+	if s.books == nil {
+		return status.Error(codes.Unavailable, "order books not enabled")
+	}
+	inst := source.ParseInstrument(req.Symbol)
+	depth := bookDepth(req.Depth)
+	interval := defaultBookInterval
+	if req.IntervalMs > 0 {
+		interval = time.Duration(req.IntervalMs) * time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var lastSent int64 = -1
+	for {
+		if snap, ok := s.books.Book(inst, depth); ok && snap.LastUpdateID != lastSent {
+			if err := stream.Send(bookToProto(snap)); err != nil {
+				return status.Errorf(codes.Aborted, "stream error: %v", err)
+			}
+			lastSent = snap.LastUpdateID
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func bookDepth(depth int32) int {
+	switch {
+	case depth == 0:
+		return defaultBookDepth
+	case depth < 0:
+		return 0
+	default:
+		return int(depth)
+	}
+}
+
+func bookToProto(snap orderbook.Snapshot) *candlestickpb.OrderBook {
+	pb := &candlestickpb.OrderBook{
+		Exchange:     snap.Exchange,
+		Symbol:       snap.Symbol,
+		LastUpdateId: snap.LastUpdateID,
+		Time:         snap.Time.UnixMilli(),
+		Bids:         levelsToProto(snap.Bids),
+		Asks:         levelsToProto(snap.Asks),
+	}
+	if len(pb.Bids) > 0 {
+		pb.BestBid = pb.Bids[0]
+	}
+	if len(pb.Asks) > 0 {
+		pb.BestAsk = pb.Asks[0]
+	}
+	if spread, ok := snap.Spread(); ok {
+		pb.Spread = spread.String()
+	}
+	return pb
+}
+
+func levelsToProto(levels []orderbook.Level) []*candlestickpb.PriceLevel {
+	out := make([]*candlestickpb.PriceLevel, 0, len(levels))
+	for _, l := range levels {
+		out = append(out, &candlestickpb.PriceLevel{Price: l.Price.String(), Quantity: l.Quantity.String()})
+	}
+	return out
+}
@@ -2,9 +2,13 @@ package grpcserver
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"net"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,26 +18,108 @@ import (
 
 	candlestickpb "github.com/shubie/trading/api/protos/candlestick"
 	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/alerts"
+	"github.com/shubie/trading/internal/derived"
+	"github.com/shubie/trading/internal/indicators"
+	"github.com/shubie/trading/internal/orderbook"
+	"github.com/shubie/trading/internal/source"
+	"github.com/shubie/trading/internal/storage"
 )
 
+const (
+	defaultCandleLimit = 500
+	maxCandleLimit     = 5000
+)
+
+// CandleReader is the read side of candle storage used by GetCandles.
+type CandleReader interface {
+	QueryCandles(ctx context.Context, q storage.CandleQuery) ([]aggregator.Candle, error)
+}
+
+// DerivedReader is implemented by stores that can replay derived series.
+type DerivedReader interface {
+	QueryDerived(ctx context.Context, kind derived.Kind, q storage.CandleQuery) ([]aggregator.Candle, error)
+}
+
+type Option func(*Server)
+
+// WithFeeds exposes the connection state of exchange feeds via FeedStatus.
+func WithFeeds(feeds ...source.StatusReporter) Option {
+	return func(s *Server) {
+		s.feeds = append(s.feeds, feeds...)
+	}
+}
+
+// IndicatorReader is implemented by stores that can replay indicator values.
+type IndicatorReader interface {
+	QueryIndicators(ctx context.Context, inst source.Instrument, interval string, since time.Time) ([]indicators.Value, error)
+}
+
+// WithIndicators streams indicator values from engine.
+func WithIndicators(engine *indicators.Engine) Option {
+	return func(s *Server) {
+		s.indicators = engine
+	}
+}
+
+// BookReader serves local order books.
+type BookReader interface {
+	Book(inst source.Instrument, depth int) (orderbook.Snapshot, bool)
+}
+
+// WithOrderBooks serves GetOrderBook and StreamOrderBook from books.
+func WithOrderBooks(books BookReader) Option {
+	return func(s *Server) {
+		s.books = books
+	}
+}
+
+// WithAlerts serves the AlertService from engine.
+func WithAlerts(engine *alerts.Engine) Option {
+	return func(s *Server) {
+		s.alerts = engine
+	}
+}
+
+// WithDerived streams Heikin-Ashi candles and Renko bricks from stage.
+func WithDerived(stage *derived.Stage) Option {
+	return func(s *Server) {
+		s.derived = stage
+	}
+}
+
 type Server struct {
 	candlestickpb.UnimplementedCandlestickServiceServer
 	candlestickpb.UnimplementedHealthCheckServiceServer
-	agg          *aggregator.Aggregator
-	grpcServer   *grpc.Server
-	port         int
-	healthMu     sync.RWMutex
-	lastDataTime time.Time
-	startupTime  time.Time
+	candlestickpb.UnimplementedAlertServiceServer
+	candlestickpb.UnimplementedAdminServiceServer
+	agg           *aggregator.Aggregator
+	store         CandleReader
+	feeds         []source.StatusReporter
+	derived       *derived.Stage
+	indicators    *indicators.Engine
+	alerts        *alerts.Engine
+	books         BookReader
+	storageStatus StorageStatusReader
+	grpcServer    *grpc.Server
+	port          int
+	healthMu      sync.RWMutex
+	lastDataTime  time.Time
+	startupTime   time.Time
 }
 
-func NewServer(port int, agg *aggregator.Aggregator) *Server {
-	return &Server{
+func NewServer(port int, agg *aggregator.Aggregator, store CandleReader, opts ...Option) *Server {
+	s := &Server{
 		port:         port,
 		agg:          agg,
+		store:        store,
 		startupTime:  time.Now(),
 		lastDataTime: time.Now(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *Server) Start() {
@@ -45,6 +131,8 @@ func (s *Server) Start() {
 	s.grpcServer = grpc.NewServer()
 	candlestickpb.RegisterCandlestickServiceServer(s.grpcServer, s)
 	candlestickpb.RegisterHealthCheckServiceServer(s.grpcServer, s)
+	candlestickpb.RegisterAlertServiceServer(s.grpcServer, s)
+	candlestickpb.RegisterAdminServiceServer(s.grpcServer, s)
 
 	log.Printf("gRPC server starting on port %d", s.port)
 	if err := s.grpcServer.Serve(lis); err != nil {
@@ -53,42 +141,391 @@ func (s *Server) Start() {
 }
 
 func (s *Server) StreamCandlesticks(req *candlestickpb.StreamRequest, stream candlestickpb.CandlestickService_StreamCandlesticksServer) error {
+	interval := derived.RenkoInterval
+	if req.Series != candlestickpb.Series_RENKO {
+		var err error
+		if interval, err = intervalLabel(req.Interval); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	// Subscribe before replaying so bars finalized during the replay are
+	// buffered rather than lost; replayed bars are skipped when they
+	// reappear on the live side.
+	insts := make([]source.Instrument, 0, len(req.Symbols))
+	for _, symbol := range req.Symbols {
+		insts = append(insts, source.ParseInstrument(symbol))
+	}
+
+	throttle := time.Duration(req.ThrottleMs) * time.Millisecond
+	var sub *aggregator.Subscription
+	switch req.Series {
+	case candlestickpb.Series_CANDLES:
+		sub = s.agg.Subscribe(insts, interval, throttle)
+	case candlestickpb.Series_HEIKIN_ASHI, candlestickpb.Series_RENKO:
+		if s.derived == nil {
+			return status.Error(codes.Unavailable, "derived series not enabled")
+		}
+		sub = s.derived.Subscribe(insts, interval, throttle)
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown series %v", req.Series)
+	}
+	defer sub.Close()
+
+	send := func(candle *aggregator.Candle) error {
+		msg := toProto(candle)
+		msg.Series = req.Series
+		return stream.Send(msg)
+	}
+
+	// replayed holds the revision each replayed bar was sent at, so the
+	// live side skips the same versions but passes corrections on.
+	replayed := make(map[replayedBar]int)
+	if req.Since > 0 {
+		since := time.UnixMilli(req.Since)
+		for _, inst := range insts {
+			candles, err := s.replay(stream.Context(), req.Series, inst, interval, since)
+			if err != nil {
+				log.Printf("backfill %s %s: %v", inst, interval, err)
+				return status.Error(codes.Internal, "failed to replay candles")
+			}
+			for i := range candles {
+				if err := send(&candles[i]); err != nil {
+					return status.Errorf(codes.Aborted, "stream error: %v", err)
+				}
+				replayed[replayedBar{inst, candles[i].StartTime.UnixNano()}] = candles[i].Revision
+			}
+		}
+	}
+
 	for {
-		select {
-		case <-stream.Context().Done():
+		candles, err := sub.Next(stream.Context())
+		if err != nil {
 			return nil
+		}
 
-		case <-time.After(1 * time.Second):
-			for _, symbol := range req.Symbols {
-				candle := s.agg.GetCurrentCandle(symbol)
-				if candle == nil {
+		for i := range candles {
+			key := replayedBar{candles[i].Instrument(), candles[i].StartTime.UnixNano()}
+			if revision, ok := replayed[key]; ok && candles[i].Revision <= revision {
+				continue
+			}
+			if err := send(&candles[i]); err != nil {
+				return status.Errorf(codes.Aborted, "stream error: %v", err)
+			}
+		}
+		s.updateDataTime(time.Now())
+	}
+}
+
+// replayedBar identifies a bar replayed to a stream by its start in Unix
+// nanoseconds, as stored and live times may differ in location.
+type replayedBar struct {
+	inst  source.Instrument
+	start int64
+}
+
+func (s *Server) StreamIndicators(req *candlestickpb.IndicatorRequest, stream candlestickpb.CandlestickService_StreamIndicatorsServer) error {
+	if s.indicators == nil {
+		return status.Error(codes.Unavailable, "indicators not enabled")
+	}
+	interval, err := intervalLabel(req.Interval)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	names := make([]string, 0, len(req.Indicators))
+	for _, name := range req.Indicators {
+		spec, err := indicators.Parse(name)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		names = append(names, spec.String())
+	}
+	insts := make([]source.Instrument, 0, len(req.Symbols))
+	for _, symbol := range req.Symbols {
+		insts = append(insts, source.ParseInstrument(symbol))
+	}
+
+	// As with candles, subscribe first and skip replayed values that
+	// reappear live.
+	sub := s.indicators.Subscribe(insts, interval, names)
+	defer sub.Close()
+
+	replayed := make(map[source.Instrument]time.Time)
+	if reader, ok := s.store.(IndicatorReader); ok && req.Since > 0 {
+		since := time.UnixMilli(req.Since)
+		for _, inst := range insts {
+			values, err := reader.QueryIndicators(stream.Context(), inst, interval, since)
+			if err != nil {
+				log.Printf("replay indicators %s %s: %v", inst, interval, err)
+				return status.Error(codes.Internal, "failed to replay indicators")
+			}
+			for _, v := range values {
+				if !sub.Matches(v) {
 					continue
 				}
-
-				err := stream.Send(&candlestickpb.Candlestick{
-					Symbol:    candle.Symbol,
-					Open:      candle.Open,
-					High:      candle.High,
-					Low:       candle.Low,
-					Close:     candle.Close,
-					Volume:    candle.Volume,
-					StartTime: candle.StartTime.UnixMilli(),
-					EndTime:   candle.EndTime.UnixMilli(),
-					IsFinal:   candle.Finalized,
-				})
-
-				log.Printf("executed GRPC")
-
-				if err != nil {
+				if err := stream.Send(indicatorToProto(v)); err != nil {
 					return status.Errorf(codes.Aborted, "stream error: %v", err)
 				}
+				replayed[inst] = v.StartTime
+			}
+		}
+	}
 
-				s.updateDataTime(time.Now())
+	for {
+		values, err := sub.Next(stream.Context())
+		if err != nil {
+			return nil
+		}
+		for _, v := range values {
+			if last, ok := replayed[v.Instrument()]; ok && !v.StartTime.After(last) {
+				continue
+			}
+			if err := stream.Send(indicatorToProto(v)); err != nil {
+				return status.Errorf(codes.Aborted, "stream error: %v", err)
 			}
 		}
 	}
 }
 
+func indicatorToProto(v indicators.Value) *candlestickpb.IndicatorValue {
+	return &candlestickpb.IndicatorValue{
+		Exchange:  v.Exchange,
+		Symbol:    v.Symbol,
+		Interval:  v.Interval,
+		Indicator: v.Indicator,
+		StartTime: v.StartTime.UnixMilli(),
+		EndTime:   v.EndTime.UnixMilli(),
+		Values:    v.Values,
+	}
+}
+
+// replay returns the finalized candles of a series to send before live
+// updates. Derived series are replayed from storage only.
+func (s *Server) replay(ctx context.Context, series candlestickpb.Series, inst source.Instrument, interval string, since time.Time) ([]aggregator.Candle, error) {
+	switch series {
+	case candlestickpb.Series_HEIKIN_ASHI, candlestickpb.Series_RENKO:
+		reader, ok := s.store.(DerivedReader)
+		if !ok {
+			return nil, nil
+		}
+		kind := derived.HeikinAshi
+		if series == candlestickpb.Series_RENKO {
+			kind = derived.Renko
+		}
+		return queryAll(ctx, inst, interval, since, func(ctx context.Context, q storage.CandleQuery) ([]aggregator.Candle, error) {
+			return reader.QueryDerived(ctx, kind, q)
+		})
+	default:
+		return s.backfill(ctx, inst, interval, since)
+	}
+}
+
+// queryAll pages through query for every candle that started at or after
+// since.
+func queryAll(ctx context.Context, inst source.Instrument, interval string, since time.Time,
+	query func(context.Context, storage.CandleQuery) ([]aggregator.Candle, error)) ([]aggregator.Candle, error) {
+	q := storage.CandleQuery{
+		Exchange: inst.Exchange,
+		Symbol:   inst.Symbol,
+		Interval: interval,
+		From:     since,
+		To:       time.Now(),
+		Limit:    maxCandleLimit,
+	}
+	var all []aggregator.Candle
+	for {
+		candles, err := query(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, candles...)
+		if len(candles) < q.Limit {
+			return all, nil
+		}
+		q.After = candles[len(candles)-1].StartTime
+	}
+}
+
+// backfill merges finalized candles from storage with those the aggregator
+// still holds in memory, which may not have been persisted yet.
+func (s *Server) backfill(ctx context.Context, inst source.Instrument, interval string, since time.Time) ([]aggregator.Candle, error) {
+	// Keyed by microsecond, the resolution of storage, as information driven
+	// bars may start within the same millisecond.
+	byStart := make(map[int64]aggregator.Candle)
+
+	if s.store != nil {
+		candles, err := queryAll(ctx, inst, interval, since, s.store.QueryCandles)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range candles {
+			byStart[c.StartTime.UnixMicro()] = c
+		}
+	}
+
+	for _, c := range s.agg.RecentFinalized(inst, interval, since) {
+		byStart[c.StartTime.UnixMicro()] = c
+	}
+
+	candles := make([]aggregator.Candle, 0, len(byStart))
+	for _, c := range byStart {
+		candles = append(candles, c)
+	}
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].StartTime.Before(candles[j].StartTime)
+	})
+	return candles, nil
+}
+
+func (s *Server) GetCandles(ctx context.Context, req *candlestickpb.GetCandlesRequest) (*candlestickpb.GetCandlesResponse, error) {
+	if s.store == nil {
+		return nil, status.Error(codes.Unavailable, "candle storage not configured")
+	}
+	if req.Symbol == "" {
+		return nil, status.Error(codes.InvalidArgument, "symbol is required")
+	}
+	interval, err := intervalLabel(req.Interval)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	limit := int(req.Limit)
+	switch {
+	case limit <= 0:
+		limit = defaultCandleLimit
+	case limit > maxCandleLimit:
+		limit = maxCandleLimit
+	}
+
+	to := time.Now()
+	if req.To > 0 {
+		to = time.UnixMilli(req.To)
+	}
+	inst := source.ParseInstrument(req.Symbol)
+	q := storage.CandleQuery{
+		Exchange: inst.Exchange,
+		Symbol:   inst.Symbol,
+		Interval: interval,
+		From:     time.UnixMilli(req.From),
+		To:       to,
+		Limit:    limit + 1,
+	}
+	if req.PageToken != "" {
+		after, err := decodePageToken(req.PageToken)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+		q.After = after
+	}
+
+	candles, err := s.store.QueryCandles(ctx, q)
+	if err != nil {
+		log.Printf("GetCandles %s %s: %v", inst, interval, err)
+		return nil, status.Error(codes.Internal, "failed to query candles")
+	}
+
+	resp := &candlestickpb.GetCandlesResponse{}
+	if len(candles) > limit {
+		candles = candles[:limit]
+		resp.NextPageToken = encodePageToken(candles[limit-1].StartTime)
+	}
+	resp.Candles = make([]*candlestickpb.Candlestick, 0, len(candles))
+	for i := range candles {
+		resp.Candles = append(resp.Candles, toProto(&candles[i]))
+	}
+	return resp, nil
+}
+
+// pageTokenV2 prefixes page tokens holding the last start time in
+// microseconds, so paging never skips information driven bars that share a
+// millisecond. Tokens without it hold milliseconds, as they did before, and
+// are still accepted from clients paging across an upgrade.
+const pageTokenV2 = "v2:"
+
+func encodePageToken(after time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(pageTokenV2 + strconv.FormatInt(after.UnixMicro(), 10)))
+}
+
+func decodePageToken(token string) (time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if us, ok := strings.CutPrefix(string(raw), pageTokenV2); ok {
+		n, err := strconv.ParseInt(us, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.UnixMicro(n), nil
+	}
+	ms, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(ms), nil
+}
+
+func toProto(candle *aggregator.Candle) *candlestickpb.Candlestick {
+	return &candlestickpb.Candlestick{
+		Exchange:  candle.Exchange,
+		Symbol:    candle.Symbol,
+		Interval:  candle.Interval,
+		Open:      candle.Open.String(),
+		High:      candle.High.String(),
+		Low:       candle.Low.String(),
+		Close:     candle.Close.String(),
+		Volume:    candle.Volume.String(),
+		StartTime: candle.StartTime.UnixMilli(),
+		EndTime:   candle.EndTime.UnixMilli(),
+		IsFinal:   candle.Finalized,
+
+		Vwap:            candle.VWAP.String(),
+		QuoteVolume:     candle.QuoteVolume.String(),
+		TradeCount:      candle.TradeCount,
+		TakerBuyVolume:  candle.TakerBuyVolume.String(),
+		TakerSellVolume: candle.TakerSellVolume.String(),
+		Revision:        int32(candle.Revision),
+		Synthetic:       candle.Synthetic,
+		BarType:         barType(candle.BarType),
+		UpdatedAt:       unixMilli(candle.UpdatedAt),
+	}
+}
+
+func barType(t aggregator.BarType) candlestickpb.Candlestick_BarType {
+	switch t {
+	case aggregator.BarTick:
+		return candlestickpb.Candlestick_TICK
+	case aggregator.BarVolume:
+		return candlestickpb.Candlestick_VOLUME
+	case aggregator.BarDollar:
+		return candlestickpb.Candlestick_DOLLAR
+	case aggregator.BarRange:
+		return candlestickpb.Candlestick_RANGE
+	default:
+		return candlestickpb.Candlestick_TIME
+	}
+}
+
+// intervalLabel normalises a client supplied interval or bar spec to the
+// label the aggregator uses, falling back to the default interval when empty.
+func intervalLabel(interval string) (string, error) {
+	if interval == "" {
+		return aggregator.FormatInterval(aggregator.DefaultInterval), nil
+	}
+	if strings.Contains(interval, ":") {
+		spec, err := aggregator.ParseBarSpec(interval)
+		if err != nil {
+			return "", err
+		}
+		return spec.String(), nil
+	}
+	d, err := aggregator.ParseInterval(interval)
+	if err != nil {
+		return "", err
+	}
+	return aggregator.FormatInterval(d), nil
+}
+
 func (s *Server) Health(ctx context.Context, req *candlestickpb.HealthRequest) (*candlestickpb.HealthResponse, error) {
 	s.healthMu.RLock()
 	defer s.healthMu.RUnlock()
@@ -109,6 +546,43 @@ func (s *Server) Health(ctx context.Context, req *candlestickpb.HealthRequest) (
 	}, nil
 }
 
+func (s *Server) FeedStatus(ctx context.Context, req *candlestickpb.FeedStatusRequest) (*candlestickpb.FeedStatusResponse, error) {
+	statuses := source.CollectStatus(s.feeds)
+
+	resp := &candlestickpb.FeedStatusResponse{
+		Feeds: make([]*candlestickpb.FeedStatus, 0, len(statuses)),
+	}
+	for _, st := range statuses {
+		feed := &candlestickpb.FeedStatus{
+			Exchange:   st.Exchange,
+			Symbol:     st.Symbol,
+			State:      feedState(st.State),
+			LastError:  st.LastError,
+			Reconnects: int32(st.Reconnects),
+		}
+		if !st.Since.IsZero() {
+			feed.SinceMs = st.Since.UnixMilli()
+		}
+		resp.Feeds = append(resp.Feeds, feed)
+	}
+	return resp, nil
+}
+
+func feedState(state source.State) candlestickpb.FeedStatus_State {
+	switch state {
+	case source.StateConnecting:
+		return candlestickpb.FeedStatus_CONNECTING
+	case source.StateLive:
+		return candlestickpb.FeedStatus_LIVE
+	case source.StateBackoff:
+		return candlestickpb.FeedStatus_BACKOFF
+	case source.StateStopped:
+		return candlestickpb.FeedStatus_STOPPED
+	default:
+		return candlestickpb.FeedStatus_UNKNOWN
+	}
+}
+
 func (s *Server) updateDataTime(t time.Time) {
 	s.healthMu.Lock()
 	defer s.healthMu.Unlock()
@@ -2,16 +2,27 @@ package grpcserver_test
 
 import (
 	"context"
+	"encoding/base64"
 	"log"
 	"net"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/alerts"
+	"github.com/shubie/trading/internal/derived"
 	"github.com/shubie/trading/internal/grpcserver"
+	"github.com/shubie/trading/internal/indicators"
+	"github.com/shubie/trading/internal/orderbook"
+	"github.com/shubie/trading/internal/source"
 
+	"github.com/shopspring/decimal"
 	candlestickpb "github.com/shubie/trading/api/protos/candlestick"
+	"github.com/shubie/trading/internal/storage"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 )
 
@@ -19,13 +30,15 @@ const bufSize = 1024 * 1024
 
 var lis *bufconn.Listener
 
-func startTestGRPCServer(t *testing.T, agg *aggregator.Aggregator) *grpcserver.Server {
+func startTestGRPCServer(t *testing.T, agg *aggregator.Aggregator, store grpcserver.CandleReader, opts ...grpcserver.Option) *grpcserver.Server {
 	lis = bufconn.Listen(bufSize)
 	server := grpc.NewServer()
 
-	s := grpcserver.NewServer(0, agg)
+	s := grpcserver.NewServer(0, agg, store, opts...)
 	candlestickpb.RegisterCandlestickServiceServer(server, s)
 	candlestickpb.RegisterHealthCheckServiceServer(server, s)
+	candlestickpb.RegisterAlertServiceServer(server, s)
+	candlestickpb.RegisterAdminServiceServer(server, s)
 
 	go func() {
 		if err := server.Serve(lis); err != nil {
@@ -44,7 +57,7 @@ func TestHealth_Healthy(t *testing.T) {
 	// I am seeting the last data time to healthy which recent time
 	agg.SetLastDataTimeForTesting(time.Now())
 
-	_ = startTestGRPCServer(t, agg)
+	_ = startTestGRPCServer(t, agg, nil)
 
 	ctx := context.Background()
 	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
@@ -70,7 +83,7 @@ func TestHealth_Unhealthy(t *testing.T) {
 	//  I am seeting the last data time to unhealthy which is the old time
 	agg.SetLastDataTimeForTesting(time.Now().Add(-10 * time.Minute))
 
-	_ = startTestGRPCServer(t, agg)
+	_ = startTestGRPCServer(t, agg, nil)
 
 	ctx := context.Background()
 	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
@@ -90,3 +103,662 @@ func TestHealth_Unhealthy(t *testing.T) {
 		t.Errorf("Expected UNHEALTHY, got %v", resp.Status)
 	}
 }
+
+type fakeStore struct {
+	candles []aggregator.Candle
+}
+
+func (f *fakeStore) QueryCandles(ctx context.Context, q storage.CandleQuery) ([]aggregator.Candle, error) {
+	var out []aggregator.Candle
+	for _, c := range f.candles {
+		if c.Exchange != q.Exchange || c.Symbol != q.Symbol || c.Interval != q.Interval {
+			continue
+		}
+		if c.StartTime.Before(q.From) || !c.StartTime.Before(q.To) || !c.StartTime.After(q.After) {
+			continue
+		}
+		out = append(out, c)
+		if len(out) == q.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func TestGetCandles_Pagination(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	store := &fakeStore{}
+	for i := 0; i < 5; i++ {
+		start := base.Add(time.Duration(i) * time.Minute)
+		store.candles = append(store.candles, aggregator.Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Interval: "1m", Open: decimal.NewFromInt(int64(i)), Close: decimal.NewFromInt(int64(i)),
+			StartTime: start, EndTime: start.Add(time.Minute), Finalized: true,
+		})
+	}
+
+	_ = startTestGRPCServer(t, aggregator.NewAggregator(), store)
+
+	ctx := context.Background()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+
+	client := candlestickpb.NewCandlestickServiceClient(conn)
+
+	var got []*candlestickpb.Candlestick
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("Pagination did not terminate")
+		}
+		resp, err := client.GetCandles(ctx, &candlestickpb.GetCandlesRequest{
+			Symbol:    "BTCUSDT",
+			Interval:  "1m",
+			From:      base.UnixMilli(),
+			Limit:     2,
+			PageToken: token,
+		})
+		if err != nil {
+			t.Fatalf("GetCandles failed: %v", err)
+		}
+		got = append(got, resp.Candles...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		token = resp.NextPageToken
+	}
+
+	if len(got) != 5 {
+		t.Fatalf("Expected 5 candles, got %d", len(got))
+	}
+	for i, c := range got {
+		if c.StartTime != base.Add(time.Duration(i)*time.Minute).UnixMilli() {
+			t.Errorf("Candle %d has unexpected start time %d", i, c.StartTime)
+		}
+		if !c.IsFinal {
+			t.Errorf("Candle %d expected to be final", i)
+		}
+	}
+}
+
+func TestGetCandles_AcceptsMillisecondPageTokens(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	store := &fakeStore{}
+	for i := 0; i < 3; i++ {
+		start := base.Add(time.Duration(i) * time.Minute)
+		store.candles = append(store.candles, aggregator.Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Interval: "1m", Open: decimal.NewFromInt(int64(i)), Close: decimal.NewFromInt(int64(i)),
+			StartTime: start, EndTime: start.Add(time.Minute), Finalized: true,
+		})
+	}
+
+	_ = startTestGRPCServer(t, aggregator.NewAggregator(), store)
+
+	ctx := context.Background()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+	client := candlestickpb.NewCandlestickServiceClient(conn)
+
+	// A token issued before tokens were versioned holds the start of the
+	// last bar of its page in milliseconds.
+	old := base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(base.UnixMilli(), 10)))
+	resp, err := client.GetCandles(ctx, &candlestickpb.GetCandlesRequest{
+		Symbol: "BTCUSDT", Interval: "1m", From: base.UnixMilli(), Limit: 10, PageToken: old,
+	})
+	if err != nil {
+		t.Fatalf("GetCandles failed: %v", err)
+	}
+	if len(resp.Candles) != 2 || resp.Candles[0].StartTime != base.Add(time.Minute).UnixMilli() {
+		t.Errorf("Expected the 2 candles after the first, got %v", resp.Candles)
+	}
+
+	_, err = client.GetCandles(ctx, &candlestickpb.GetCandlesRequest{
+		Symbol: "BTCUSDT", Interval: "1m", PageToken: base64.RawURLEncoding.EncodeToString([]byte("v2:x")),
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a malformed token, got %v", err)
+	}
+}
+
+func TestStreamCandlesticks_PushesOnTick(t *testing.T) {
+	agg := aggregator.NewAggregator()
+	tickChan := make(chan source.Tick)
+	candleChan := make(chan aggregator.Candle, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go agg.Run(ctx, tickChan, candleChan)
+
+	_ = startTestGRPCServer(t, agg, nil)
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+
+	client := candlestickpb.NewCandlestickServiceClient(conn)
+	stream, err := client.StreamCandlesticks(ctx, &candlestickpb.StreamRequest{Symbols: []string{"BTCUSDT"}})
+	if err != nil {
+		t.Fatalf("StreamCandlesticks failed: %v", err)
+	}
+	// Give the server a moment to register the subscription.
+	time.Sleep(50 * time.Millisecond)
+
+	for _, price := range []string{"100.5", "101.25"} {
+		sent := time.Now()
+		tickChan <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.RequireFromString(price), Quantity: decimal.NewFromInt(1), Timestamp: time.Now()}
+
+		candle, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		if candle.Close != price {
+			t.Errorf("Expected close %s, got %s", price, candle.Close)
+		}
+		if elapsed := time.Since(sent); elapsed > 500*time.Millisecond {
+			t.Errorf("Expected update to be pushed promptly, took %v", elapsed)
+		}
+	}
+}
+
+func TestStreamCandlesticks_SendsFinalizedBar(t *testing.T) {
+	agg := aggregator.NewAggregator(aggregator.WithIdleTimeout(100 * time.Millisecond))
+	tickChan := make(chan source.Tick)
+	candleChan := make(chan aggregator.Candle, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go agg.Run(ctx, tickChan, candleChan)
+
+	_ = startTestGRPCServer(t, agg, nil)
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+
+	client := candlestickpb.NewCandlestickServiceClient(conn)
+	stream, err := client.StreamCandlesticks(ctx, &candlestickpb.StreamRequest{Symbols: []string{"BTCUSDT"}})
+	if err != nil {
+		t.Fatalf("StreamCandlesticks failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// A tick for an already elapsed minute is finalized once the symbol idles.
+	start := time.Now().Add(-2 * time.Minute).Truncate(time.Minute)
+	tickChan <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(100), Quantity: decimal.NewFromInt(1), Timestamp: start}
+
+	for {
+		c, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed before a finalized candle arrived: %v", err)
+		}
+		if !c.IsFinal {
+			continue
+		}
+		if c.StartTime != start.UnixMilli() || c.Close != "100" {
+			t.Errorf("Unexpected final candle: %+v", c)
+		}
+		return
+	}
+}
+
+func TestStreamCandlesticks_ResumeSince(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	bar := func(i int) aggregator.Candle {
+		start := base.Add(time.Duration(i) * time.Minute)
+		return aggregator.Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Interval: "1m", Open: decimal.NewFromInt(int64(i)), Close: decimal.NewFromInt(int64(i)),
+			StartTime: start, EndTime: start.Add(time.Minute), Finalized: true,
+		}
+	}
+	// Bars 0 and 1 are persisted, bars 1 and 2 are still in memory.
+	store := &fakeStore{candles: []aggregator.Candle{bar(0), bar(1)}}
+
+	agg := aggregator.NewAggregator(aggregator.WithIdleTimeout(100 * time.Millisecond))
+	tickChan := make(chan source.Tick)
+	candleChan := make(chan aggregator.Candle, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go agg.Run(ctx, tickChan, candleChan)
+
+	for i := 1; i <= 2; i++ {
+		tickChan <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(int64(i)), Quantity: decimal.NewFromInt(1), Timestamp: bar(i).StartTime}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-candleChan:
+		case <-ctx.Done():
+			t.Fatal("Timeout waiting for in-memory bars to finalize")
+		}
+	}
+
+	_ = startTestGRPCServer(t, agg, store)
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+
+	client := candlestickpb.NewCandlestickServiceClient(conn)
+	stream, err := client.StreamCandlesticks(ctx, &candlestickpb.StreamRequest{
+		Symbols: []string{"BTCUSDT"},
+		Since:   base.UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("StreamCandlesticks failed: %v", err)
+	}
+
+	for i := 0; i <= 2; i++ {
+		c, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		if !c.IsFinal || c.StartTime != bar(i).StartTime.UnixMilli() {
+			t.Fatalf("Expected replayed bar %d, got %+v", i, c)
+		}
+	}
+
+	tickChan <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(42), Quantity: decimal.NewFromInt(1), Timestamp: time.Now()}
+	c, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if c.IsFinal || c.Close != "42" {
+		t.Errorf("Expected live in-progress candle after replay, got %+v", c)
+	}
+}
+
+func TestStreamCandlesticks_ResumePassesCorrections(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	tick := func(i int, price int64) source.Tick {
+		return source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(price), Quantity: decimal.NewFromInt(1),
+			Timestamp: base.Add(time.Duration(i) * time.Minute)}
+	}
+	stored := aggregator.Candle{
+		Exchange: "binance", Symbol: "BTCUSDT", Interval: "1m", Open: decimal.NewFromInt(1), Close: decimal.NewFromInt(1),
+		StartTime: base, EndTime: base.Add(time.Minute), Finalized: true,
+	}
+	store := &fakeStore{candles: []aggregator.Candle{stored}}
+
+	agg := aggregator.NewAggregator(aggregator.WithIdleTimeout(100*time.Millisecond), aggregator.WithLatePolicy(aggregator.LateAmend))
+	tickChan := make(chan source.Tick)
+	candleChan := make(chan aggregator.Candle, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go agg.Run(ctx, tickChan, candleChan)
+
+	tickChan <- tick(1, 10)
+	select {
+	case <-candleChan:
+	case <-ctx.Done():
+		t.Fatal("Timeout waiting for the in-memory bar to finalize")
+	}
+
+	_ = startTestGRPCServer(t, agg, store)
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+
+	client := candlestickpb.NewCandlestickServiceClient(conn)
+	stream, err := client.StreamCandlesticks(ctx, &candlestickpb.StreamRequest{
+		Symbols: []string{"BTCUSDT"},
+		Since:   base.UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("StreamCandlesticks failed: %v", err)
+	}
+	for i := 0; i <= 1; i++ {
+		c, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		if c.StartTime != base.Add(time.Duration(i)*time.Minute).UnixMilli() || c.Revision != 0 {
+			t.Fatalf("Expected replayed bar %d, got %+v", i, c)
+		}
+	}
+
+	// A late trade amends the replayed bar, and the correction goes out
+	// even though the bar starts before the end of the replay.
+	tickChan <- tick(1, 12)
+	c, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if c.StartTime != base.Add(time.Minute).UnixMilli() || c.Revision != 1 || c.High != "12" {
+		t.Errorf("Expected the corrected bar at revision 1, got %+v", c)
+	}
+}
+
+type fakeFeeds []source.FeedStatus
+
+func (f fakeFeeds) Status() []source.FeedStatus {
+	return f
+}
+
+func TestStreamCandlesticks_DerivedSeries(t *testing.T) {
+	stage := derived.NewStage(derived.WithHeikinAshi(true), derived.WithSymbolRenko("BTCUSDT", decimal.NewFromInt(10)))
+	tickIn := make(chan source.Tick)
+	candleIn := make(chan aggregator.Candle)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go stage.Run(ctx, tickIn, make(chan source.Tick, 10), candleIn, make(chan aggregator.Candle, 10), make(chan derived.Bar, 10))
+
+	_ = startTestGRPCServer(t, aggregator.NewAggregator(), nil, grpcserver.WithDerived(stage))
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+
+	client := candlestickpb.NewCandlestickServiceClient(conn)
+	renko, err := client.StreamCandlesticks(ctx, &candlestickpb.StreamRequest{Symbols: []string{"BTCUSDT"}, Series: candlestickpb.Series_RENKO})
+	if err != nil {
+		t.Fatalf("StreamCandlesticks failed: %v", err)
+	}
+	heikinAshi, err := client.StreamCandlesticks(ctx, &candlestickpb.StreamRequest{Symbols: []string{"BTCUSDT"}, Interval: "1m", Series: candlestickpb.Series_HEIKIN_ASHI})
+	if err != nil {
+		t.Fatalf("StreamCandlesticks failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	now := time.Now()
+	tickIn <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(100), Quantity: decimal.NewFromInt(1), Timestamp: now}
+	tickIn <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(110), Quantity: decimal.NewFromInt(1), Timestamp: now}
+	start := now.Truncate(time.Minute)
+	candleIn <- aggregator.Candle{
+		Exchange: "binance", Symbol: "BTCUSDT", Interval: "1m",
+		Open: decimal.NewFromInt(100), High: decimal.NewFromInt(110), Low: decimal.NewFromInt(100), Close: decimal.NewFromInt(110),
+		StartTime: start, EndTime: start.Add(time.Minute), Finalized: true,
+	}
+
+	brick, err := renko.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if brick.Series != candlestickpb.Series_RENKO || brick.Interval != derived.RenkoInterval || brick.Open != "100" || brick.Close != "110" {
+		t.Errorf("Unexpected Renko brick: %+v", brick)
+	}
+
+	ha, err := heikinAshi.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if ha.Series != candlestickpb.Series_HEIKIN_ASHI || ha.Open != "105" || ha.Close != "105" || ha.High != "110" {
+		t.Errorf("Unexpected Heikin-Ashi candle: %+v", ha)
+	}
+}
+
+func TestStreamIndicators(t *testing.T) {
+	sma, _ := indicators.Parse("sma:2")
+	rsi, _ := indicators.Parse("rsi:2")
+	engine := indicators.NewEngine(indicators.WithSeries("BTCUSDT", "1m", sma, rsi))
+	candleIn := make(chan aggregator.Candle)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go engine.Run(ctx, candleIn, make(chan aggregator.Candle, 10), make(chan indicators.Value, 10))
+
+	_ = startTestGRPCServer(t, aggregator.NewAggregator(), nil, grpcserver.WithIndicators(engine))
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+
+	client := candlestickpb.NewCandlestickServiceClient(conn)
+	stream, err := client.StreamIndicators(ctx, &candlestickpb.IndicatorRequest{Symbols: []string{"BTCUSDT"}, Interval: "1m", Indicators: []string{"sma:2"}})
+	if err != nil {
+		t.Fatalf("StreamIndicators failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now().Truncate(time.Minute)
+	for i, price := range []int64{100, 110} {
+		candleIn <- aggregator.Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Interval: "1m",
+			Open: decimal.NewFromInt(price), High: decimal.NewFromInt(price), Low: decimal.NewFromInt(price), Close: decimal.NewFromInt(price),
+			StartTime: start.Add(time.Duration(i) * time.Minute), EndTime: start.Add(time.Duration(i+1) * time.Minute), Finalized: true,
+		}
+	}
+
+	v, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if v.Indicator != "sma:2" || v.Values["value"] != 105 || v.StartTime != start.Add(time.Minute).UnixMilli() {
+		t.Errorf("Unexpected indicator value: %+v", v)
+	}
+}
+
+func TestAlerts_CRUDAndStream(t *testing.T) {
+	engine := alerts.NewEngine()
+	tickIn := make(chan source.Tick)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go engine.Run(ctx, tickIn, make(chan source.Tick, 10), make(chan aggregator.Candle), make(chan aggregator.Candle))
+
+	_ = startTestGRPCServer(t, aggregator.NewAggregator(), nil, grpcserver.WithAlerts(engine))
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+	client := candlestickpb.NewAlertServiceClient(conn)
+
+	_, err = client.CreateAlert(ctx, &candlestickpb.CreateAlertRequest{Alert: &candlestickpb.Alert{Symbol: "BTCUSDT", Kind: candlestickpb.Alert_VOLUME_SPIKE, Threshold: "5"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CreateAlert without interval: got %v, want InvalidArgument", err)
+	}
+	created, err := client.CreateAlert(ctx, &candlestickpb.CreateAlertRequest{Alert: &candlestickpb.Alert{
+		Symbol: "BTCUSDT", Kind: candlestickpb.Alert_PRICE_CROSS, Threshold: "70000", Direction: candlestickpb.Alert_UP,
+	}})
+	if err != nil {
+		t.Fatalf("CreateAlert failed: %v", err)
+	}
+	if created.Id == "" || created.Symbol != "binance:BTCUSDT" || created.CreatedAt == 0 {
+		t.Errorf("Unexpected created alert: %+v", created)
+	}
+
+	created.Threshold = "71000"
+	updated, err := client.UpdateAlert(ctx, &candlestickpb.UpdateAlertRequest{Alert: created})
+	if err != nil {
+		t.Fatalf("UpdateAlert failed: %v", err)
+	}
+	if updated.Threshold != "71000" || updated.CreatedAt != created.CreatedAt {
+		t.Errorf("Unexpected updated alert: %+v", updated)
+	}
+
+	list, err := client.ListAlerts(ctx, &candlestickpb.ListAlertsRequest{Symbols: []string{"BTCUSDT"}})
+	if err != nil || len(list.Alerts) != 1 {
+		t.Fatalf("ListAlerts = %v, %v; want one alert", list, err)
+	}
+
+	stream, err := client.StreamAlerts(ctx, &candlestickpb.StreamAlertsRequest{AlertIds: []string{created.Id}})
+	if err != nil {
+		t.Fatalf("StreamAlerts failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	now := time.Now()
+	tickIn <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(70500), Timestamp: now}
+	tickIn <- source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(71500), Timestamp: now}
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if event.AlertId != created.Id || event.Kind != candlestickpb.Alert_PRICE_CROSS || event.Value != "71500" || event.TriggeredAt != now.UnixMilli() {
+		t.Errorf("Unexpected alert event: %+v", event)
+	}
+
+	if _, err := client.DeleteAlert(ctx, &candlestickpb.DeleteAlertRequest{Id: created.Id}); err != nil {
+		t.Fatalf("DeleteAlert failed: %v", err)
+	}
+	if _, err := client.DeleteAlert(ctx, &candlestickpb.DeleteAlertRequest{Id: created.Id}); status.Code(err) != codes.NotFound {
+		t.Errorf("Second DeleteAlert: got %v, want NotFound", err)
+	}
+}
+
+type fakeBooks map[source.Instrument]orderbook.Snapshot
+
+func (f fakeBooks) Book(inst source.Instrument, depth int) (orderbook.Snapshot, bool) {
+	snap, ok := f[inst]
+	if depth > 0 && len(snap.Bids) > depth {
+		snap.Bids = snap.Bids[:depth]
+	}
+	if depth > 0 && len(snap.Asks) > depth {
+		snap.Asks = snap.Asks[:depth]
+	}
+	return snap, ok
+}
+
+func TestGetOrderBook(t *testing.T) {
+	level := func(price, qty int64) orderbook.Level {
+		return orderbook.Level{Price: decimal.NewFromInt(price), Quantity: decimal.NewFromInt(qty)}
+	}
+	books := fakeBooks{
+		{Exchange: "binance", Symbol: "BTCUSDT"}: {
+			Exchange: "binance", Symbol: "BTCUSDT", LastUpdateID: 42, Time: time.UnixMilli(1700000000000),
+			Bids: []orderbook.Level{level(100, 1), level(99, 2), level(98, 3)},
+			Asks: []orderbook.Level{level(102, 4), level(103, 5)},
+		},
+	}
+	_ = startTestGRPCServer(t, aggregator.NewAggregator(), nil, grpcserver.WithOrderBooks(books))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+	client := candlestickpb.NewCandlestickServiceClient(conn)
+
+	book, err := client.GetOrderBook(ctx, &candlestickpb.OrderBookRequest{Symbol: "BTCUSDT", Depth: 2})
+	if err != nil {
+		t.Fatalf("GetOrderBook failed: %v", err)
+	}
+	if book.LastUpdateId != 42 || book.Time != 1700000000000 || book.Spread != "2" ||
+		book.BestBid.Price != "100" || book.BestAsk.Quantity != "4" || len(book.Bids) != 2 || len(book.Asks) != 2 {
+		t.Errorf("Unexpected order book: %+v", book)
+	}
+
+	if _, err := client.GetOrderBook(ctx, &candlestickpb.OrderBookRequest{Symbol: "ETHUSDT"}); status.Code(err) != codes.Unavailable {
+		t.Errorf("GetOrderBook of a book not in sync: got %v, want Unavailable", err)
+	}
+
+	stream, err := client.StreamOrderBook(ctx, &candlestickpb.OrderBookRequest{Symbol: "BTCUSDT", Depth: -1, IntervalMs: 10})
+	if err != nil {
+		t.Fatalf("StreamOrderBook failed: %v", err)
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if len(first.Bids) != 3 {
+		t.Errorf("Streamed %d bids, want the whole book of 3", len(first.Bids))
+	}
+}
+
+func TestFeedStatus(t *testing.T) {
+	since := time.Now().Add(-time.Minute)
+	feeds := fakeFeeds{
+		{Exchange: "binance", Symbol: "BTCUSDT", State: source.StateLive, Since: since},
+		{Exchange: "kraken", Symbol: "BTC/USD", State: source.StateBackoff, Since: since, LastError: "dial timeout", Reconnects: 3},
+	}
+
+	lis = bufconn.Listen(bufSize)
+	server := grpc.NewServer()
+	candlestickpb.RegisterHealthCheckServiceServer(server, grpcserver.NewServer(0, aggregator.NewAggregator(), nil, grpcserver.WithFeeds(feeds)))
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx := context.Background()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := candlestickpb.NewHealthCheckServiceClient(conn).FeedStatus(ctx, &candlestickpb.FeedStatusRequest{})
+	if err != nil {
+		t.Fatalf("FeedStatus failed: %v", err)
+	}
+	if len(resp.Feeds) != 2 {
+		t.Fatalf("Expected 2 feeds, got %d", len(resp.Feeds))
+	}
+	down := resp.Feeds[1]
+	if down.State != candlestickpb.FeedStatus_BACKOFF || down.LastError != "dial timeout" || down.Reconnects != 3 {
+		t.Errorf("Unexpected status for kraken feed: %+v", down)
+	}
+	if resp.Feeds[0].State != candlestickpb.FeedStatus_LIVE || resp.Feeds[0].SinceMs != since.UnixMilli() {
+		t.Errorf("Unexpected status for binance feed: %+v", resp.Feeds[0])
+	}
+}
+
+type fakeStorageStatus storage.StorageStatus
+
+func (f fakeStorageStatus) StorageStatus(ctx context.Context) (storage.StorageStatus, error) {
+	return storage.StorageStatus(f), nil
+}
+
+func TestGetStorageStatus(t *testing.T) {
+	reconciled := time.UnixMilli(1700000000000)
+	st := fakeStorageStatus{
+		ChunkInterval:    24 * time.Hour,
+		Chunks:           30,
+		CompressedChunks: 23,
+		CompressAfter:    7 * 24 * time.Hour,
+		TotalBytes:       1 << 20,
+		Retention: []storage.IntervalRetention{
+			{Interval: "1s", Keep: 7 * 24 * time.Hour, Deleted: 86400, LastRun: reconciled},
+			{Interval: "1m", Keep: 730 * 24 * time.Hour},
+		},
+		ReconciledAt: reconciled,
+	}
+	_ = startTestGRPCServer(t, aggregator.NewAggregator(), nil, grpcserver.WithStorageStatus(st))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := candlestickpb.NewAdminServiceClient(conn).GetStorageStatus(ctx, &candlestickpb.StorageStatusRequest{})
+	if err != nil {
+		t.Fatalf("GetStorageStatus failed: %v", err)
+	}
+	if resp.ChunkIntervalMs != 86400000 || resp.Chunks != 30 || resp.CompressedChunks != 23 ||
+		resp.CompressAfterMs != 604800000 || resp.DropAfterMs != 0 || resp.ReconciledAtMs != 1700000000000 {
+		t.Errorf("Unexpected storage status: %+v", resp)
+	}
+	if len(resp.Retention) != 2 {
+		t.Fatalf("Got %d interval retentions, want 2", len(resp.Retention))
+	}
+	if r := resp.Retention[0]; r.Interval != "1s" || r.Deleted != 86400 || r.LastRunMs != 1700000000000 {
+		t.Errorf("Unexpected 1s retention: %+v", r)
+	}
+	if r := resp.Retention[1]; r.KeepMs != 730*86400000 || r.LastRunMs != 0 || r.OldestMs != 0 {
+		t.Errorf("Unexpected 1m retention: %+v", r)
+	}
+}
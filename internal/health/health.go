@@ -1,32 +1,83 @@
 package health
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+	"github.com/shubie/trading/internal/storage"
 )
 
+// SpoolReporter reports candle batches waiting to be written to the
+// database.
+type SpoolReporter interface {
+	Stats() storage.SpoolStats
+}
+
 type Handler struct {
 	agg     *aggregator.Aggregator
 	timeout time.Duration
+	feeds   []source.StatusReporter
+	spool   SpoolReporter
 }
 
-func NewHandler(agg *aggregator.Aggregator, timeout time.Duration) *Handler {
+func NewHandler(agg *aggregator.Aggregator, timeout time.Duration, feeds ...source.StatusReporter) *Handler {
 	return &Handler{
 		agg:     agg,
 		timeout: timeout,
+		feeds:   feeds,
 	}
 }
 
+// SetSpool adds the depth and age of a persistence spool to the report.
+func (h *Handler) SetSpool(spool SpoolReporter) {
+	h.spool = spool
+}
+
+// ServeHTTP reports UNHEALTHY when no data has arrived within the timeout
+// and lists the connection state of every feed so operators can see which
+// ones are down.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body strings.Builder
+
+	code := http.StatusOK
 	lastData := h.agg.GetLastDataTime()
 	if time.Since(lastData) > h.timeout {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		w.Write([]byte("UNHEALTHY: No recent data"))
-		return
+		code = http.StatusServiceUnavailable
+		body.WriteString("UNHEALTHY: No recent data")
+	} else {
+		body.WriteString("OK")
+	}
+
+	if late := h.agg.LateStats(); late.Dropped > 0 || late.Amended > 0 {
+		fmt.Fprintf(&body, "\nlate ticks: %d dropped, %d amended", late.Dropped, late.Amended)
+	}
+
+	if h.spool != nil {
+		if st := h.spool.Stats(); st.Batches > 0 {
+			fmt.Fprintf(&body, "\nspool: %d batches, %d candles, oldest %s",
+				st.Batches, st.Candles, time.Since(st.Oldest).Truncate(time.Second))
+		} else {
+			body.WriteString("\nspool: empty")
+		}
+	}
+
+	for _, st := range source.CollectStatus(h.feeds) {
+		fmt.Fprintf(&body, "\n%s:%s %s", st.Exchange, st.Symbol, st.State)
+		if !st.Since.IsZero() {
+			fmt.Fprintf(&body, " for %s", time.Since(st.Since).Truncate(time.Second))
+		}
+		if st.Reconnects > 0 {
+			fmt.Fprintf(&body, ", %d reconnects", st.Reconnects)
+		}
+		if st.State != source.StateLive && st.LastError != "" {
+			fmt.Fprintf(&body, " (last error: %s)", st.LastError)
+		}
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("OK"))
+	w.WriteHeader(code)
+	w.Write([]byte(body.String()))
 }
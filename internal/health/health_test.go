@@ -0,0 +1,77 @@
+package health_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/health"
+	"github.com/shubie/trading/internal/source"
+	"github.com/shubie/trading/internal/storage"
+)
+
+type fakeFeeds []source.FeedStatus
+
+func (f fakeFeeds) Status() []source.FeedStatus {
+	return f
+}
+
+func TestHandler_ReportsFeeds(t *testing.T) {
+	agg := aggregator.NewAggregator()
+	agg.SetLastDataTimeForTesting(time.Now())
+
+	feeds := fakeFeeds{
+		{Exchange: "binance", Symbol: "BTCUSDT", State: source.StateLive, Since: time.Now()},
+		{Exchange: "binance", Symbol: "ETHUSDT", State: source.StateBackoff, Since: time.Now(), LastError: "connection reset"},
+	}
+	h := health.NewHandler(agg, time.Minute, feeds)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"OK", "binance:BTCUSDT live", "binance:ETHUSDT backoff", "connection reset"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected body to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+type fakeSpool storage.SpoolStats
+
+func (f fakeSpool) Stats() storage.SpoolStats {
+	return storage.SpoolStats(f)
+}
+
+func TestHandler_ReportsSpool(t *testing.T) {
+	agg := aggregator.NewAggregator()
+	agg.SetLastDataTimeForTesting(time.Now())
+
+	h := health.NewHandler(agg, time.Minute)
+	h.SetSpool(fakeSpool{Batches: 2, Candles: 150, Oldest: time.Now().Add(-90 * time.Second)})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if want := "spool: 2 batches, 150 candles, oldest 1m30s"; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("Expected body to contain %q, got:\n%s", want, rec.Body.String())
+	}
+}
+
+func TestHandler_Unhealthy(t *testing.T) {
+	agg := aggregator.NewAggregator()
+	agg.SetLastDataTimeForTesting(time.Now().Add(-10 * time.Minute))
+
+	rec := httptest.NewRecorder()
+	health.NewHandler(agg, 5*time.Minute).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,281 @@
+package indicators
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+)
+
+// subscriptionBuffer bounds the values a subscriber may fall behind by; the
+// oldest are dropped beyond it.
+const subscriptionBuffer = 1000
+
+// Value is the output of one indicator for one finalized candle.
+type Value struct {
+	Exchange  string
+	Symbol    string
+	Interval  string
+	Indicator string // the spec, e.g. "rsi:14"
+	StartTime time.Time
+	EndTime   time.Time
+	Values    map[string]float64
+}
+
+func (v Value) Instrument() source.Instrument {
+	return source.Instrument{Exchange: v.Exchange, Symbol: v.Symbol}
+}
+
+// CandleSource supplies history to warm indicators up with.
+type CandleSource interface {
+	// RecentCandles returns up to n of the newest finalized candles of a
+	// series, oldest first.
+	RecentCandles(ctx context.Context, inst source.Instrument, interval string, n int) ([]aggregator.Candle, error)
+}
+
+type Option func(*Engine)
+
+// WithSeries computes the indicators for one instrument and interval label.
+// The symbol is "exchange:symbol"; a bare symbol refers to Binance.
+func WithSeries(symbol, interval string, specs ...Spec) Option {
+	return func(e *Engine) {
+		key := seriesKey(source.ParseInstrument(symbol), interval)
+		s, ok := e.series[key]
+		if !ok {
+			s = &series{inst: source.ParseInstrument(symbol), interval: interval}
+			e.series[key] = s
+		}
+		for _, spec := range specs {
+			s.specs = append(s.specs, spec)
+			s.indicators = append(s.indicators, spec.New())
+		}
+	}
+}
+
+type series struct {
+	inst       source.Instrument
+	interval   string
+	specs      []Spec
+	indicators []Indicator
+	last       time.Time // start of the newest candle folded in
+}
+
+func seriesKey(inst source.Instrument, interval string) string {
+	return inst.String() + "|" + interval
+}
+
+// Engine feeds finalized candles to the configured indicators and publishes
+// their values.
+type Engine struct {
+	mu     sync.Mutex
+	series map[string]*series
+
+	subsMu sync.RWMutex
+	subs   map[*Subscription]struct{}
+}
+
+func NewEngine(opts ...Option) *Engine {
+	e := &Engine{
+		series: make(map[string]*series),
+		subs:   make(map[*Subscription]struct{}),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// WarmUp replays recent history from src through every indicator without
+// publishing, so values are right from the first live candle after a
+// restart.
+func (e *Engine) WarmUp(ctx context.Context, src CandleSource) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, s := range e.series {
+		n := 0
+		for _, spec := range s.specs {
+			n = max(n, spec.History())
+		}
+		candles, err := src.RecentCandles(ctx, s.inst, s.interval, n)
+		if err != nil {
+			return fmt.Errorf("warm up %s %s: %w", s.inst, s.interval, err)
+		}
+		for _, c := range candles {
+			s.update(c)
+		}
+		log.Printf("Warmed up indicators for %s %s from %d candles", s.inst, s.interval, len(candles))
+	}
+	return nil
+}
+
+// Run forwards candleIn to candleOut, sending the indicator values of every
+// finalized candle to valueChan. It returns once candleIn is closed, closing
+// both outputs.
+func (e *Engine) Run(ctx context.Context, candleIn <-chan aggregator.Candle, candleOut chan<- aggregator.Candle, valueChan chan<- Value) {
+	defer close(valueChan)
+	defer close(candleOut)
+	log.Println("Indicator engine started")
+
+	// The aggregator flushes its last candles after ctx is done, so keep
+	// draining until it closes the channel.
+	for candle := range candleIn {
+		for _, v := range e.process(candle) {
+			e.publish(v)
+			// The persister drains valueChan until it is closed.
+			valueChan <- v
+		}
+		// The writer drains until candleOut is closed, and must get
+		// every candle.
+		candleOut <- candle
+	}
+	log.Println("Indicator engine stopped")
+}
+
+func (e *Engine) process(c aggregator.Candle) []Value {
+	if !c.Finalized || c.Revision > 0 {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, ok := e.series[seriesKey(c.Instrument(), c.Interval)]
+	if !ok {
+		return nil
+	}
+	return s.update(c)
+}
+
+// update folds a candle into every indicator of the series. Candles no newer
+// than the last one, such as those already seen during warm up, are
+// skipped.
+func (s *series) update(c aggregator.Candle) []Value {
+	if !c.StartTime.After(s.last) {
+		return nil
+	}
+	s.last = c.StartTime
+
+	var values []Value
+	for i, ind := range s.indicators {
+		out, ok := ind.Update(c)
+		if !ok {
+			continue
+		}
+		values = append(values, Value{
+			Exchange:  c.Exchange,
+			Symbol:    c.Symbol,
+			Interval:  c.Interval,
+			Indicator: s.specs[i].String(),
+			StartTime: c.StartTime,
+			EndTime:   c.EndTime,
+			Values:    out,
+		})
+	}
+	return values
+}
+
+// Subscription receives indicator values of one interval for a set of
+// instruments, optionally narrowed to some indicators.
+type Subscription struct {
+	engine     *Engine
+	insts      map[source.Instrument]struct{}
+	interval   string
+	indicators map[string]struct{}
+
+	mu      sync.Mutex
+	pending []Value
+	notify  chan struct{}
+}
+
+// Subscribe registers interest in the values of the given indicator specs,
+// or of all indicators when none are given.
+func (e *Engine) Subscribe(insts []source.Instrument, interval string, indicators []string) *Subscription {
+	sub := &Subscription{
+		engine:     e,
+		insts:      make(map[source.Instrument]struct{}, len(insts)),
+		interval:   interval,
+		indicators: make(map[string]struct{}, len(indicators)),
+		notify:     make(chan struct{}, 1),
+	}
+	for _, inst := range insts {
+		sub.insts[inst] = struct{}{}
+	}
+	for _, name := range indicators {
+		sub.indicators[name] = struct{}{}
+	}
+
+	e.subsMu.Lock()
+	e.subs[sub] = struct{}{}
+	e.subsMu.Unlock()
+	return sub
+}
+
+func (e *Engine) publish(v Value) {
+	e.subsMu.RLock()
+	defer e.subsMu.RUnlock()
+
+	for sub := range e.subs {
+		if sub.Matches(v) {
+			sub.offer(v)
+		}
+	}
+}
+
+// Matches reports whether v is one of the values the subscription is for.
+func (s *Subscription) Matches(v Value) bool {
+	if v.Interval != s.interval {
+		return false
+	}
+	if _, ok := s.insts[v.Instrument()]; !ok {
+		return false
+	}
+	if len(s.indicators) == 0 {
+		return true
+	}
+	_, ok := s.indicators[v.Indicator]
+	return ok
+}
+
+func (s *Subscription) offer(v Value) {
+	s.mu.Lock()
+	s.pending = append(s.pending, v)
+	if len(s.pending) > subscriptionBuffer {
+		s.pending = s.pending[len(s.pending)-subscriptionBuffer:]
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Next blocks until values are pending and returns them oldest first.
+func (s *Subscription) Next(ctx context.Context) ([]Value, error) {
+	select {
+	case <-s.notify:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	values := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].StartTime.Before(values[j].StartTime)
+	})
+	return values, nil
+}
+
+func (s *Subscription) Close() {
+	s.engine.subsMu.Lock()
+	delete(s.engine.subs, s)
+	s.engine.subsMu.Unlock()
+}
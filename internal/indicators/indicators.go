@@ -0,0 +1,316 @@
+// Package indicators computes technical indicators incrementally from
+// finalized candles. Every indicator does O(1) work per candle.
+//
+// Indicators work in float64: they divide and take square roots, so exact
+// decimals would buy nothing.
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/shubie/trading/internal/aggregator"
+)
+
+// Indicator folds in finalized candles one at a time, oldest first.
+type Indicator interface {
+	// Update returns the indicator's outputs after c, or false while it is
+	// still warming up.
+	Update(c aggregator.Candle) (map[string]float64, bool)
+}
+
+// Spec names an indicator and its parameters, e.g. "rsi:14",
+// "macd:12:26:9" or "bb:20:2". Its String form names its values.
+type Spec struct {
+	Kind   string
+	Params []float64
+}
+
+// defaults holds the parameters used when a spec gives none.
+var defaults = map[string][]float64{
+	"sma":  {20},
+	"ema":  {20},
+	"rsi":  {14},
+	"macd": {12, 26, 9},
+	"bb":   {20, 2},
+	"atr":  {14},
+}
+
+// Parse parses an indicator spec. Parameters may be omitted to use the
+// usual defaults, e.g. "macd" is "macd:12:26:9".
+func Parse(s string) (Spec, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	spec := Spec{Kind: strings.ToLower(parts[0])}
+	def, ok := defaults[spec.Kind]
+	if !ok {
+		return Spec{}, fmt.Errorf("unknown indicator %q", s)
+	}
+	if len(parts) == 1 {
+		spec.Params = def
+		return spec, nil
+	}
+	if len(parts)-1 != len(def) {
+		return Spec{}, fmt.Errorf("indicator %q takes %d parameters", s, len(def))
+	}
+	for i, p := range parts[1:] {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || v <= 0 {
+			return Spec{}, fmt.Errorf("indicator %q: invalid parameter %q", s, p)
+		}
+		// Everything but the Bollinger band width is a bar count.
+		if !(spec.Kind == "bb" && i == 1) && v != math.Trunc(v) {
+			return Spec{}, fmt.Errorf("indicator %q: period %q must be whole", s, p)
+		}
+		spec.Params = append(spec.Params, v)
+	}
+	if spec.Kind == "macd" && spec.Params[0] >= spec.Params[1] {
+		return Spec{}, fmt.Errorf("indicator %q: fast period must be below slow", s)
+	}
+	return spec, nil
+}
+
+func (s Spec) String() string {
+	parts := []string{s.Kind}
+	for _, p := range s.Params {
+		parts = append(parts, strconv.FormatFloat(p, 'f', -1, 64))
+	}
+	return strings.Join(parts, ":")
+}
+
+// New returns a fresh indicator for the spec.
+func (s Spec) New() Indicator {
+	n := int(s.Params[0])
+	switch s.Kind {
+	case "sma":
+		return &sma{window: newWindow(n)}
+	case "ema":
+		return &emaIndicator{ema: newEMA(n)}
+	case "rsi":
+		return &rsi{n: n}
+	case "macd":
+		return &macd{fast: newEMA(n), slow: newEMA(int(s.Params[1])), signal: newEMA(int(s.Params[2]))}
+	case "bb":
+		return &bollinger{window: newWindow(n), width: s.Params[1]}
+	case "atr":
+		return &atr{n: n}
+	default:
+		panic("indicators: unknown kind " + s.Kind)
+	}
+}
+
+// History is how many candles warm the indicator up to a value that no
+// longer depends on where its history started, to within rounding.
+func (s Spec) History() int {
+	switch s.Kind {
+	case "sma", "bb":
+		return int(s.Params[0])
+	case "macd":
+		return 10*int(s.Params[1]) + int(s.Params[2])
+	case "rsi", "atr":
+		// Wilder's smoothing forgets its seed about half as fast as an
+		// EMA of the same period.
+		return 20 * int(s.Params[0])
+	default:
+		// Exponential smoothing forgets its seed geometrically.
+		return 10 * int(s.Params[0])
+	}
+}
+
+func closeOf(c aggregator.Candle) float64 {
+	return c.Close.InexactFloat64()
+}
+
+// window is a fixed size ring of the latest values with their running sum
+// and sum of squares.
+type window struct {
+	values     []float64
+	next, size int
+	sum, sumSq float64
+}
+
+func newWindow(n int) window {
+	return window{values: make([]float64, n)}
+}
+
+func (w *window) add(v float64) bool {
+	if w.size == len(w.values) {
+		old := w.values[w.next]
+		w.sum -= old
+		w.sumSq -= old * old
+	} else {
+		w.size++
+	}
+	w.values[w.next] = v
+	w.next = (w.next + 1) % len(w.values)
+	w.sum += v
+	w.sumSq += v * v
+	if w.next == 0 {
+		// Resum once per lap so rounding errors cannot pile up; still
+		// O(1) per value amortized.
+		w.sum, w.sumSq = 0, 0
+		for _, v := range w.values {
+			w.sum += v
+			w.sumSq += v * v
+		}
+	}
+	return w.size == len(w.values)
+}
+
+func (w *window) mean() float64 {
+	return w.sum / float64(w.size)
+}
+
+type sma struct {
+	window window
+}
+
+func (s *sma) Update(c aggregator.Candle) (map[string]float64, bool) {
+	if !s.window.add(closeOf(c)) {
+		return nil, false
+	}
+	return map[string]float64{"value": s.window.mean()}, true
+}
+
+// ema is an exponential moving average seeded with the simple average of its
+// first n values.
+type ema struct {
+	n     int
+	alpha float64
+	count int
+	value float64
+}
+
+func newEMA(n int) ema {
+	return ema{n: n, alpha: 2 / float64(n+1)}
+}
+
+func (e *ema) add(v float64) (float64, bool) {
+	if e.count < e.n {
+		e.count++
+		e.value += (v - e.value) / float64(e.count)
+		return e.value, e.count == e.n
+	}
+	e.value += e.alpha * (v - e.value)
+	return e.value, true
+}
+
+type emaIndicator struct {
+	ema ema
+}
+
+func (e *emaIndicator) Update(c aggregator.Candle) (map[string]float64, bool) {
+	v, ok := e.ema.add(closeOf(c))
+	if !ok {
+		return nil, false
+	}
+	return map[string]float64{"value": v}, true
+}
+
+// wilder is Wilder's smoothing: a simple average of the first n values, then
+// avg = (avg*(n-1) + v) / n.
+type wilder struct {
+	n     int
+	count int
+	value float64
+}
+
+func (w *wilder) add(v float64) bool {
+	if w.count < w.n {
+		w.count++
+		w.value += (v - w.value) / float64(w.count)
+		return w.count == w.n
+	}
+	w.value = (w.value*float64(w.n-1) + v) / float64(w.n)
+	return true
+}
+
+type rsi struct {
+	n          int
+	prev       float64
+	started    bool
+	gain, loss wilder
+}
+
+func (r *rsi) Update(c aggregator.Candle) (map[string]float64, bool) {
+	price := closeOf(c)
+	if !r.started {
+		r.started = true
+		r.prev = price
+		r.gain.n, r.loss.n = r.n, r.n
+		return nil, false
+	}
+	change := price - r.prev
+	r.prev = price
+	r.gain.add(math.Max(change, 0))
+	if !r.loss.add(math.Max(-change, 0)) {
+		return nil, false
+	}
+	switch {
+	case r.loss.value == 0 && r.gain.value == 0:
+		// A flat series is neither overbought nor oversold.
+		return map[string]float64{"value": 50}, true
+	case r.loss.value == 0:
+		return map[string]float64{"value": 100}, true
+	}
+	rs := r.gain.value / r.loss.value
+	return map[string]float64{"value": 100 - 100/(1+rs)}, true
+}
+
+type macd struct {
+	fast, slow, signal ema
+}
+
+func (m *macd) Update(c aggregator.Candle) (map[string]float64, bool) {
+	price := closeOf(c)
+	fast, _ := m.fast.add(price)
+	slow, ok := m.slow.add(price)
+	if !ok {
+		return nil, false
+	}
+	line := fast - slow
+	signal, ok := m.signal.add(line)
+	if !ok {
+		return nil, false
+	}
+	return map[string]float64{"macd": line, "signal": signal, "histogram": line - signal}, true
+}
+
+type bollinger struct {
+	window window
+	width  float64
+}
+
+func (b *bollinger) Update(c aggregator.Candle) (map[string]float64, bool) {
+	if !b.window.add(closeOf(c)) {
+		return nil, false
+	}
+	mean := b.window.mean()
+	variance := math.Max(b.window.sumSq/float64(b.window.size)-mean*mean, 0)
+	dev := b.width * math.Sqrt(variance)
+	return map[string]float64{"middle": mean, "upper": mean + dev, "lower": mean - dev}, true
+}
+
+type atr struct {
+	n       int
+	prev    float64
+	started bool
+	tr      wilder
+}
+
+func (a *atr) Update(c aggregator.Candle) (map[string]float64, bool) {
+	high, low := c.High.InexactFloat64(), c.Low.InexactFloat64()
+	tr := high - low
+	if a.started {
+		tr = math.Max(tr, math.Max(math.Abs(high-a.prev), math.Abs(low-a.prev)))
+	} else {
+		a.started = true
+		a.tr.n = a.n
+	}
+	a.prev = closeOf(c)
+	if !a.tr.add(tr) {
+		return nil, false
+	}
+	return map[string]float64{"value": a.tr.value}, true
+}
@@ -0,0 +1,359 @@
+package indicators_test
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/indicators"
+	"github.com/shubie/trading/internal/source"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "rsi", want: "rsi:14"},
+		{in: "MACD", want: "macd:12:26:9"},
+		{in: "bb:20:2.5", want: "bb:20:2.5"},
+		{in: "sma:50", want: "sma:50"},
+		{in: "ema:0", wantErr: true},
+		{in: "sma:2.5", wantErr: true},
+		{in: "macd:26:12:9", wantErr: true},
+		{in: "bb:20", wantErr: true},
+		{in: "vwma:20", wantErr: true},
+	}
+	for _, tt := range tests {
+		spec, err := indicators.Parse(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %v, want error", tt.in, spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if spec.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, spec, tt.want)
+		}
+	}
+}
+
+// randomCandles returns a finalized 1m random walk.
+func randomCandles(n int, seed int64) []aggregator.Candle {
+	rng := rand.New(rand.NewSource(seed))
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	price := 100.0
+	candles := make([]aggregator.Candle, n)
+	for i := range candles {
+		open := price
+		price += rng.NormFloat64()
+		high := math.Max(open, price) + rng.Float64()
+		low := math.Min(open, price) - rng.Float64()
+		candles[i] = aggregator.Candle{
+			Exchange: "binance", Symbol: "BTCUSDT", Interval: "1m",
+			Open:      decimal.NewFromFloat(open),
+			High:      decimal.NewFromFloat(high),
+			Low:       decimal.NewFromFloat(low),
+			Close:     decimal.NewFromFloat(price),
+			Volume:    decimal.NewFromInt(1),
+			StartTime: base.Add(time.Duration(i) * time.Minute),
+			EndTime:   base.Add(time.Duration(i+1) * time.Minute),
+			Finalized: true,
+		}
+	}
+	return candles
+}
+
+// flatCandles returns n finalized 1m candles that all trade at one price.
+func flatCandles(n int) []aggregator.Candle {
+	candles := randomCandles(n, 1)
+	price := decimal.NewFromInt(100)
+	for i := range candles {
+		candles[i].Open, candles[i].High, candles[i].Low, candles[i].Close = price, price, price, price
+	}
+	return candles
+}
+
+func closes(candles []aggregator.Candle) []float64 {
+	out := make([]float64, len(candles))
+	for i, c := range candles {
+		out[i] = c.Close.InexactFloat64()
+	}
+	return out
+}
+
+func mean(xs []float64) float64 {
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// emaSeries recomputes an SMA-seeded EMA from scratch; entries before the
+// seed are NaN.
+func emaSeries(xs []float64, n int) []float64 {
+	out := make([]float64, len(xs))
+	alpha := 2 / float64(n+1)
+	for i := range xs {
+		switch {
+		case i < n-1:
+			out[i] = math.NaN()
+		case i == n-1:
+			out[i] = mean(xs[:n])
+		default:
+			out[i] = out[i-1] + alpha*(xs[i]-out[i-1])
+		}
+	}
+	return out
+}
+
+// wilderSeries recomputes Wilder's smoothing from scratch.
+func wilderSeries(xs []float64, n int) []float64 {
+	out := make([]float64, len(xs))
+	for i := range xs {
+		switch {
+		case i < n-1:
+			out[i] = math.NaN()
+		case i == n-1:
+			out[i] = mean(xs[:n])
+		default:
+			out[i] = (out[i-1]*float64(n-1) + xs[i]) / float64(n)
+		}
+	}
+	return out
+}
+
+// reference recomputes each indicator naively over the whole history up to
+// candle i.
+var reference = map[string]func(candles []aggregator.Candle, i int) (map[string]float64, bool){
+	"sma:20": func(candles []aggregator.Candle, i int) (map[string]float64, bool) {
+		if i < 19 {
+			return nil, false
+		}
+		return map[string]float64{"value": mean(closes(candles[i-19 : i+1]))}, true
+	},
+	"ema:20": func(candles []aggregator.Candle, i int) (map[string]float64, bool) {
+		if i < 19 {
+			return nil, false
+		}
+		return map[string]float64{"value": emaSeries(closes(candles[:i+1]), 20)[i]}, true
+	},
+	"rsi:14": func(candles []aggregator.Candle, i int) (map[string]float64, bool) {
+		if i < 14 {
+			return nil, false
+		}
+		cs := closes(candles[:i+1])
+		var gains, losses []float64
+		for j := 1; j < len(cs); j++ {
+			gains = append(gains, math.Max(cs[j]-cs[j-1], 0))
+			losses = append(losses, math.Max(cs[j-1]-cs[j], 0))
+		}
+		gain := wilderSeries(gains, 14)[len(gains)-1]
+		loss := wilderSeries(losses, 14)[len(losses)-1]
+		if gain == 0 && loss == 0 {
+			return map[string]float64{"value": 50}, true
+		}
+		if loss == 0 {
+			return map[string]float64{"value": 100}, true
+		}
+		return map[string]float64{"value": 100 - 100/(1+gain/loss)}, true
+	},
+	"macd:12:26:9": func(candles []aggregator.Candle, i int) (map[string]float64, bool) {
+		if i < 25+8 {
+			return nil, false
+		}
+		cs := closes(candles[:i+1])
+		fast, slow := emaSeries(cs, 12), emaSeries(cs, 26)
+		var lines []float64
+		for j := 25; j < len(cs); j++ {
+			lines = append(lines, fast[j]-slow[j])
+		}
+		line := lines[len(lines)-1]
+		signal := emaSeries(lines, 9)[len(lines)-1]
+		return map[string]float64{"macd": line, "signal": signal, "histogram": line - signal}, true
+	},
+	"bb:20:2": func(candles []aggregator.Candle, i int) (map[string]float64, bool) {
+		if i < 19 {
+			return nil, false
+		}
+		cs := closes(candles[i-19 : i+1])
+		m := mean(cs)
+		variance := 0.0
+		for _, c := range cs {
+			variance += (c - m) * (c - m)
+		}
+		dev := 2 * math.Sqrt(variance/20)
+		return map[string]float64{"middle": m, "upper": m + dev, "lower": m - dev}, true
+	},
+	"atr:14": func(candles []aggregator.Candle, i int) (map[string]float64, bool) {
+		if i < 13 {
+			return nil, false
+		}
+		trs := make([]float64, i+1)
+		for j := 0; j <= i; j++ {
+			high, low := candles[j].High.InexactFloat64(), candles[j].Low.InexactFloat64()
+			trs[j] = high - low
+			if j > 0 {
+				prev := candles[j-1].Close.InexactFloat64()
+				trs[j] = math.Max(trs[j], math.Max(math.Abs(high-prev), math.Abs(low-prev)))
+			}
+		}
+		return map[string]float64{"value": wilderSeries(trs, 14)[i]}, true
+	},
+}
+
+func TestIndicators_MatchReference(t *testing.T) {
+	cases := map[string][]aggregator.Candle{
+		"random walk": randomCandles(300, 1),
+		"flat":        flatCandles(50),
+	}
+	for series, candles := range cases {
+		for name, ref := range reference {
+			spec, err := indicators.Parse(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ind := spec.New()
+			for i, c := range candles {
+				got, ok := ind.Update(c)
+				want, wantOK := ref(candles, i)
+				if ok != wantOK {
+					t.Fatalf("%s %s candle %d: ready = %v, want %v", series, name, i, ok, wantOK)
+				}
+				for k, v := range want {
+					if math.Abs(got[k]-v) > 1e-9*math.Max(1, math.Abs(v)) {
+						t.Fatalf("%s %s candle %d: %s = %v, want %v", series, name, i, k, got[k], v)
+					}
+				}
+				if len(got) != len(want) {
+					t.Fatalf("%s %s candle %d: got %v, want %v", series, name, i, got, want)
+				}
+			}
+		}
+	}
+}
+
+type fakeSource struct {
+	candles []aggregator.Candle
+}
+
+func (f fakeSource) RecentCandles(_ context.Context, _ source.Instrument, _ string, n int) ([]aggregator.Candle, error) {
+	if n > len(f.candles) {
+		n = len(f.candles)
+	}
+	return f.candles[len(f.candles)-n:], nil
+}
+
+// runEngine feeds candles through an engine and returns the values it sent.
+func runEngine(t *testing.T, engine *indicators.Engine, candles []aggregator.Candle) []indicators.Value {
+	t.Helper()
+	candleIn := make(chan aggregator.Candle)
+	candleOut := make(chan aggregator.Candle, len(candles))
+	valueChan := make(chan indicators.Value, 10*len(candles))
+
+	done := make(chan struct{})
+	go func() {
+		engine.Run(context.Background(), candleIn, candleOut, valueChan)
+		close(done)
+	}()
+	for _, c := range candles {
+		candleIn <- c
+	}
+	close(candleIn)
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timeout waiting for the engine to stop")
+	}
+	if len(candleOut) != len(candles) {
+		t.Fatalf("Got %d candles out, want %d", len(candleOut), len(candles))
+	}
+	var values []indicators.Value
+	for v := range valueChan {
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestEngine_WarmUpMatchesContinuousRun(t *testing.T) {
+	specs := []indicators.Spec{}
+	for _, name := range []string{"rsi:14", "macd:12:26:9", "bb:20:2"} {
+		spec, err := indicators.Parse(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		specs = append(specs, spec)
+	}
+	candles := randomCandles(1000, 2)
+	history, live := candles[:800], candles[800:]
+
+	continuous := indicators.NewEngine(indicators.WithSeries("BTCUSDT", "1m", specs...))
+	want := runEngine(t, continuous, candles)
+	want = want[len(want)-len(live)*len(specs):]
+
+	// A restarted engine warms up from stored history, which may overlap
+	// the first live candles.
+	restarted := indicators.NewEngine(indicators.WithSeries("binance:BTCUSDT", "1m", specs...))
+	if err := restarted.WarmUp(context.Background(), fakeSource{candles: history}); err != nil {
+		t.Fatal(err)
+	}
+	got := runEngine(t, restarted, append(history[len(history)-5:len(history):len(history)], live...))
+
+	if len(got) != len(want) {
+		t.Fatalf("Got %d values, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Indicator != want[i].Indicator || !got[i].StartTime.Equal(want[i].StartTime) {
+			t.Fatalf("Value %d is %s at %v, want %s at %v", i, got[i].Indicator, got[i].StartTime, want[i].Indicator, want[i].StartTime)
+		}
+		for k, v := range want[i].Values {
+			if math.Abs(got[i].Values[k]-v) > 1e-6*math.Max(1, math.Abs(v)) {
+				t.Errorf("%s at %v: %s = %v, want %v", want[i].Indicator, want[i].StartTime, k, got[i].Values[k], v)
+			}
+		}
+	}
+}
+
+func TestEngine_SkipsUnfinalizedAndCorrections(t *testing.T) {
+	spec, _ := indicators.Parse("sma:2")
+	engine := indicators.NewEngine(indicators.WithSeries("BTCUSDT", "1m", spec))
+	sub := engine.Subscribe([]source.Instrument{{Exchange: "binance", Symbol: "BTCUSDT"}}, "1m", []string{"sma:2"})
+	defer sub.Close()
+
+	candles := randomCandles(3, 3)
+	inProgress := candles[2]
+	inProgress.Finalized = false
+	correction := candles[1]
+	correction.Revision = 1
+	other := candles[2]
+	other.Symbol = "ETHUSDT"
+
+	values := runEngine(t, engine, []aggregator.Candle{candles[0], candles[1], inProgress, correction, other, candles[2]})
+	if len(values) != 2 {
+		t.Fatalf("Got %d values, want 2: %+v", len(values), values)
+	}
+	if !values[0].StartTime.Equal(candles[1].StartTime) || !values[1].StartTime.Equal(candles[2].StartTime) {
+		t.Errorf("Values at %v and %v, want %v and %v", values[0].StartTime, values[1].StartTime, candles[1].StartTime, candles[2].StartTime)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	published, err := sub.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(published) != 2 {
+		t.Errorf("Subscriber got %d values, want 2", len(published))
+	}
+}
@@ -0,0 +1,120 @@
+package kraken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/source"
+)
+
+const Exchange = "kraken"
+
+// Client consumes the Kraken WebSocket v2 "trade" channel. Symbols use
+// Kraken's "BTC/USD" notation.
+type Client struct {
+	symbols []string
+	feed    *source.WebSocketFeed
+}
+
+func NewClient(wssURL string, symbols []string) *Client {
+	c := &Client{symbols: symbols}
+	c.feed = &source.WebSocketFeed{
+		Name:      Exchange,
+		URL:       wssURL,
+		Symbols:   symbols,
+		Subscribe: c.subscribe,
+		Parse:     parseMessage,
+	}
+	return c
+}
+
+func (c *Client) Name() string {
+	return Exchange
+}
+
+func (c *Client) Connect(ctx context.Context, tickChan chan<- source.Tick) {
+	c.feed.Run(ctx, tickChan)
+}
+
+func (c *Client) Status() []source.FeedStatus {
+	return c.feed.Status()
+}
+
+func (c *Client) subscribe(conn *websocket.Conn) error {
+	return conn.WriteJSON(map[string]interface{}{
+		"method": "subscribe",
+		"params": map[string]interface{}{
+			"channel":  "trade",
+			"symbol":   c.symbols,
+			"snapshot": false,
+		},
+	})
+}
+
+func parseMessage(message []byte) ([]source.Tick, error) {
+	var m struct {
+		Channel string `json:"channel"`
+		Type    string `json:"type"`
+		Method  string `json:"method"`
+		Success *bool  `json:"success"`
+		Error   string `json:"error"`
+		Data    []struct {
+			Symbol    string      `json:"symbol"`
+			Price     json.Number `json:"price"`
+			Qty       json.Number `json:"qty"`
+			Side      string      `json:"side"`
+			Timestamp time.Time   `json:"timestamp"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(message, &m); err != nil {
+		return nil, err
+	}
+
+	if m.Success != nil && !*m.Success {
+		return nil, fmt.Errorf("%s failed: %s", m.Method, m.Error)
+	}
+	// The snapshot repeats recent trades that were already counted before a
+	// reconnect, so only live updates are used.
+	if m.Channel != "trade" || m.Type != "update" {
+		return nil, nil
+	}
+
+	ticks := make([]source.Tick, 0, len(m.Data))
+	for _, t := range m.Data {
+		price, err := decimal.NewFromString(t.Price.String())
+		if err != nil {
+			return nil, fmt.Errorf("price %q: %w", t.Price, err)
+		}
+		qty, err := decimal.NewFromString(t.Qty.String())
+		if err != nil {
+			return nil, fmt.Errorf("qty %q: %w", t.Qty, err)
+		}
+		ticks = append(ticks, source.Tick{
+			Exchange:  Exchange,
+			Symbol:    t.Symbol,
+			Price:     price,
+			Quantity:  qty,
+			Timestamp: t.Timestamp,
+			Side:      takerSide(t.Side),
+			Trades:    1,
+		})
+	}
+	return ticks, nil
+}
+
+// takerSide parses the side of the taker order Kraken reports on a trade.
+func takerSide(side string) source.Side {
+	switch side {
+	case "buy":
+		return source.SideBuy
+	case "sell":
+		return source.SideSell
+	default:
+		return source.SideUnknown
+	}
+}
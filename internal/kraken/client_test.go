@@ -0,0 +1,72 @@
+package kraken_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/shubie/trading/internal/kraken"
+	"github.com/shubie/trading/internal/source"
+)
+
+func TestClient_Connect(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("Failed to upgrade websocket: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		var sub struct {
+			Method string `json:"method"`
+			Params struct {
+				Channel string   `json:"channel"`
+				Symbol  []string `json:"symbol"`
+			} `json:"params"`
+		}
+		if err := conn.ReadJSON(&sub); err != nil {
+			t.Errorf("Failed to read subscribe request: %v", err)
+			return
+		}
+		if sub.Method != "subscribe" || sub.Params.Channel != "trade" || sub.Params.Symbol[0] != "BTC/USD" {
+			t.Errorf("Unexpected subscribe request: %+v", sub)
+		}
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"method":"subscribe","result":{"channel":"trade","symbol":"BTC/USD"},"success":true}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"heartbeat"}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"trade","type":"snapshot","data":[{"symbol":"BTC/USD","price":1,"qty":1,"timestamp":"2024-01-01T00:00:00Z"}]}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"channel":"trade","type":"update","data":[{"symbol":"BTC/USD","side":"sell","price":42000.5,"qty":0.1,"ord_type":"market","trade_id":7,"timestamp":"2024-01-01T00:00:02.25Z"}]}`))
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	client := kraken.NewClient("ws"+strings.TrimPrefix(srv.URL, "http"), []string{"BTC/USD"})
+	tickChan := make(chan source.Tick)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go client.Connect(ctx, tickChan)
+
+	select {
+	case tick := <-tickChan:
+		if tick.Exchange != kraken.Exchange || tick.Symbol != "BTC/USD" {
+			t.Errorf("unexpected instrument %s", tick.Instrument())
+		}
+		if tick.Price.String() != "42000.5" || tick.Quantity.String() != "0.1" {
+			t.Errorf("expected the live trade 42000.5 x 0.1, got %s x %s", tick.Price, tick.Quantity)
+		}
+		if tick.Side != source.SideSell {
+			t.Errorf("expected a sell, got %s", tick.Side)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for tick data")
+	}
+}
@@ -0,0 +1,160 @@
+// Package orderbook maintains local limit order books from exchange depth
+// snapshots and diffs.
+package orderbook
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Level is the total quantity resting at one price.
+type Level struct {
+	Price    decimal.Decimal
+	Quantity decimal.Decimal
+}
+
+// Book is one instrument's order book. It is not safe for concurrent use.
+type Book struct {
+	Exchange string
+	Symbol   string
+	// LastUpdateID is the exchange's sequence number of the last change.
+	LastUpdateID int64
+	UpdatedAt    time.Time
+
+	// Levels are keyed by their normalized price, so "1.10" and "1.1" are
+	// the same level.
+	bids map[string]Level
+	asks map[string]Level
+}
+
+func NewBook(exchange, symbol string) *Book {
+	return &Book{
+		Exchange: exchange,
+		Symbol:   symbol,
+		bids:     make(map[string]Level),
+		asks:     make(map[string]Level),
+	}
+}
+
+// Reset replaces the book with a full snapshot.
+func (b *Book) Reset(lastUpdateID int64, bids, asks []Level, at time.Time) {
+	b.bids = make(map[string]Level, len(bids))
+	b.asks = make(map[string]Level, len(asks))
+	b.Apply(lastUpdateID, bids, asks, at)
+}
+
+// Apply sets the quantity of each given level; a zero quantity removes it.
+func (b *Book) Apply(lastUpdateID int64, bids, asks []Level, at time.Time) {
+	set(b.bids, bids)
+	set(b.asks, asks)
+	b.LastUpdateID = lastUpdateID
+	b.UpdatedAt = at
+}
+
+func set(side map[string]Level, levels []Level) {
+	for _, l := range levels {
+		key := l.Price.String()
+		if l.Quantity.IsZero() {
+			delete(side, key)
+		} else {
+			side[key] = l
+		}
+	}
+}
+
+// Snapshot returns up to depth levels per side, best first. A depth of zero
+// or less returns every level.
+func (b *Book) Snapshot(depth int) Snapshot {
+	return Snapshot{
+		Exchange:     b.Exchange,
+		Symbol:       b.Symbol,
+		LastUpdateID: b.LastUpdateID,
+		Time:         b.UpdatedAt,
+		Bids:         top(b.bids, depth, true),
+		Asks:         top(b.asks, depth, false),
+	}
+}
+
+func top(side map[string]Level, depth int, descending bool) []Level {
+	levels := make([]Level, 0, len(side))
+	for _, l := range side {
+		levels = append(levels, l)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if descending {
+			return levels[i].Price.GreaterThan(levels[j].Price)
+		}
+		return levels[i].Price.LessThan(levels[j].Price)
+	})
+	if depth > 0 && len(levels) > depth {
+		levels = levels[:depth]
+	}
+	return levels
+}
+
+// Snapshot is a copy of the top of a book at one update.
+type Snapshot struct {
+	Exchange     string
+	Symbol       string
+	LastUpdateID int64
+	Time         time.Time
+	Bids         []Level // highest first
+	Asks         []Level // lowest first
+}
+
+func (s Snapshot) BestBid() (Level, bool) {
+	if len(s.Bids) == 0 {
+		return Level{}, false
+	}
+	return s.Bids[0], true
+}
+
+func (s Snapshot) BestAsk() (Level, bool) {
+	if len(s.Asks) == 0 {
+		return Level{}, false
+	}
+	return s.Asks[0], true
+}
+
+// Spread is the best ask minus the best bid, if both sides have orders.
+func (s Snapshot) Spread() (decimal.Decimal, bool) {
+	bid, okBid := s.BestBid()
+	ask, okAsk := s.BestAsk()
+	if !okBid || !okAsk {
+		return decimal.Zero, false
+	}
+	return ask.Price.Sub(bid.Price), true
+}
+
+// Source supplies snapshots of every book that is currently in sync.
+type Source interface {
+	Snapshots(depth int) []Snapshot
+}
+
+// RunSnapshots sends the books of src to snapshotChan every interval until
+// ctx is done, then closes snapshotChan.
+func RunSnapshots(ctx context.Context, src Source, interval time.Duration, depth int, snapshotChan chan<- Snapshot) {
+	defer close(snapshotChan)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	log.Printf("Order book snapshots every %s", interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, s := range src.Snapshots(depth) {
+				select {
+				case snapshotChan <- s:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package orderbook_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/orderbook"
+)
+
+func level(price, qty string) orderbook.Level {
+	return orderbook.Level{Price: decimal.RequireFromString(price), Quantity: decimal.RequireFromString(qty)}
+}
+
+func TestBook_ApplyAndSnapshot(t *testing.T) {
+	book := orderbook.NewBook("binance", "BTCUSDT")
+	now := time.Now()
+	book.Reset(100,
+		[]orderbook.Level{level("99.50", "2"), level("100.00", "1"), level("98", "5")},
+		[]orderbook.Level{level("101.00", "1"), level("100.50", "3")},
+		now)
+
+	// Prices match whatever their trailing zeros; zero quantities remove.
+	book.Apply(101,
+		[]orderbook.Level{level("100", "4"), level("98.0", "0")},
+		[]orderbook.Level{level("100.5", "0"), level("102", "7")},
+		now.Add(time.Second))
+
+	snap := book.Snapshot(2)
+	if snap.LastUpdateID != 101 || !snap.Time.Equal(now.Add(time.Second)) {
+		t.Errorf("Snapshot at update %d, %v", snap.LastUpdateID, snap.Time)
+	}
+	wantBids := []orderbook.Level{level("100", "4"), level("99.5", "2")}
+	wantAsks := []orderbook.Level{level("101", "1"), level("102", "7")}
+	for i, want := range wantBids {
+		if !snap.Bids[i].Price.Equal(want.Price) || !snap.Bids[i].Quantity.Equal(want.Quantity) {
+			t.Errorf("Bid %d is %v, want %v", i, snap.Bids[i], want)
+		}
+	}
+	for i, want := range wantAsks {
+		if !snap.Asks[i].Price.Equal(want.Price) || !snap.Asks[i].Quantity.Equal(want.Quantity) {
+			t.Errorf("Ask %d is %v, want %v", i, snap.Asks[i], want)
+		}
+	}
+	if len(snap.Bids) != 2 || len(snap.Asks) != 2 {
+		t.Errorf("Got %d bids and %d asks, want 2 each", len(snap.Bids), len(snap.Asks))
+	}
+	if spread, ok := snap.Spread(); !ok || !spread.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("Spread = %s, %v; want 1", spread, ok)
+	}
+
+	if all := book.Snapshot(0); len(all.Bids) != 2 || len(all.Asks) != 2 {
+		t.Errorf("Full snapshot has %d bids and %d asks, want 2 each", len(all.Bids), len(all.Asks))
+	}
+
+	book.Reset(200, nil, []orderbook.Level{level("105", "1")}, now)
+	if _, ok := book.Snapshot(10).Spread(); ok {
+		t.Error("Spread of a one sided book should not be defined")
+	}
+}
@@ -0,0 +1,93 @@
+package source
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultExchange is assumed for instruments given without an exchange prefix.
+const DefaultExchange = "binance"
+
+// Tick is a single trade normalised across exchanges. Price and quantity
+// keep the exact decimal the exchange sent.
+type Tick struct {
+	Exchange  string
+	Symbol    string
+	Price     decimal.Decimal
+	Quantity  decimal.Decimal
+	Timestamp time.Time
+	// Side is the taker's side, the side that crossed the spread.
+	Side Side
+	// Trades is how many exchange trades the tick stands for, e.g. a
+	// Binance aggregate trade; zero counts as one.
+	Trades int64
+}
+
+func (t Tick) Instrument() Instrument {
+	return Instrument{Exchange: t.Exchange, Symbol: t.Symbol}
+}
+
+// Side is the aggressor side of a trade.
+type Side int
+
+const (
+	SideUnknown Side = iota
+	SideBuy
+	SideSell
+)
+
+func (s Side) String() string {
+	switch s {
+	case SideBuy:
+		return "buy"
+	case SideSell:
+		return "sell"
+	default:
+		return "unknown"
+	}
+}
+
+// Instrument identifies a trading pair on a specific exchange so the same
+// pair on two venues is aggregated separately.
+type Instrument struct {
+	Exchange string
+	Symbol   string
+}
+
+func (i Instrument) String() string {
+	return i.Exchange + ":" + i.Symbol
+}
+
+// ParseInstrument parses "exchange:symbol", treating a bare symbol as being
+// on DefaultExchange.
+func ParseInstrument(s string) Instrument {
+	if exchange, symbol, ok := strings.Cut(s, ":"); ok {
+		return Instrument{Exchange: strings.ToLower(exchange), Symbol: symbol}
+	}
+	return Instrument{Exchange: DefaultExchange, Symbol: s}
+}
+
+// Source is an exchange connector. Connect streams ticks into tickChan until
+// ctx is cancelled; it must not close tickChan.
+type Source interface {
+	Name() string
+	Connect(ctx context.Context, tickChan chan<- Tick)
+}
+
+// Run connects every source and closes tickChan once all of them return.
+func Run(ctx context.Context, sources []Source, tickChan chan<- Tick) {
+	var wg sync.WaitGroup
+	for _, src := range sources {
+		wg.Add(1)
+		go func(src Source) {
+			defer wg.Done()
+			src.Connect(ctx, tickChan)
+		}(src)
+	}
+	wg.Wait()
+	close(tickChan)
+}
@@ -0,0 +1,107 @@
+package source
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the lifecycle of a feed connection.
+type State int
+
+const (
+	StateConnecting State = iota
+	StateLive
+	StateBackoff
+	StateStopped
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateLive:
+		return "live"
+	case StateBackoff:
+		return "backoff"
+	case StateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// FeedStatus is the connection state of one instrument's feed.
+type FeedStatus struct {
+	Exchange   string
+	Symbol     string
+	State      State
+	Since      time.Time
+	LastError  string
+	Reconnects int
+}
+
+// StatusReporter is implemented by sources that track connection state.
+type StatusReporter interface {
+	Status() []FeedStatus
+}
+
+// ConnState tracks the state of a single connection. It is safe for
+// concurrent use.
+type ConnState struct {
+	mu         sync.Mutex
+	state      State
+	since      time.Time
+	lastErr    string
+	reconnects int
+	connected  bool
+}
+
+// Set moves to state, recording err as the last error when non-nil.
+func (c *ConnState) Set(state State, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if state == StateConnecting && c.connected {
+		c.reconnects++
+	}
+	if state == StateLive {
+		c.connected = true
+	}
+	if err != nil {
+		c.lastErr = err.Error()
+	}
+	if state != c.state || c.since.IsZero() {
+		c.state = state
+		c.since = time.Now()
+	}
+}
+
+func (c *ConnState) State() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Status reports this connection's state for one symbol.
+func (c *ConnState) Status(exchange, symbol string) FeedStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return FeedStatus{
+		Exchange:   exchange,
+		Symbol:     symbol,
+		State:      c.state,
+		Since:      c.since,
+		LastError:  c.lastErr,
+		Reconnects: c.reconnects,
+	}
+}
+
+// CollectStatus gathers feed statuses from every reporter.
+func CollectStatus(reporters []StatusReporter) []FeedStatus {
+	var statuses []FeedStatus
+	for _, r := range reporters {
+		statuses = append(statuses, r.Status()...)
+	}
+	return statuses
+}
@@ -0,0 +1,149 @@
+package source
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/shubie/trading/internal/backoff"
+)
+
+// StableConnection is how long a connection must stay up before a failure
+// restarts the reconnect backoff from its minimum.
+const StableConnection = 30 * time.Second
+
+// WebSocketFeed is a reconnecting WebSocket session shared by the simpler
+// exchange connectors. Subscribe runs after every successful dial and Parse
+// turns each message into zero or more ticks.
+type WebSocketFeed struct {
+	Name      string
+	URL       string
+	Symbols   []string
+	Subscribe func(conn *websocket.Conn) error
+	Parse     func(message []byte) ([]Tick, error)
+
+	// Keepalive, when set, is called every KeepaliveInterval while connected
+	// for exchanges that expect an application level ping.
+	Keepalive         func(conn *websocket.Conn) error
+	KeepaliveInterval time.Duration
+
+	// Backoff controls reconnect delays; nil uses the package defaults.
+	Backoff *backoff.Backoff
+
+	state ConnState
+}
+
+// Status reports the connection state for every symbol on the feed.
+func (f *WebSocketFeed) Status() []FeedStatus {
+	statuses := make([]FeedStatus, 0, len(f.Symbols))
+	for _, symbol := range f.Symbols {
+		statuses = append(statuses, f.state.Status(f.Name, symbol))
+	}
+	return statuses
+}
+
+func (f *WebSocketFeed) Run(ctx context.Context, tickChan chan<- Tick) {
+	b := f.Backoff
+	if b == nil {
+		b = backoff.New(backoff.DefaultMin, backoff.DefaultMax)
+	}
+	defer f.state.Set(StateStopped, nil)
+
+	log.Printf("Connecting to %s (%s)", f.URL, f.Name)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		f.state.Set(StateConnecting, nil)
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, f.URL, nil)
+		if err != nil {
+			log.Printf("dial %s error: %v", f.Name, err)
+			f.state.Set(StateBackoff, err)
+			if !b.Wait(ctx) {
+				return
+			}
+			continue
+		}
+
+		f.state.Set(StateLive, nil)
+		connected := time.Now()
+		err = f.session(ctx, conn, tickChan)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(connected) >= StableConnection {
+			b.Reset()
+		}
+		f.state.Set(StateBackoff, err)
+		if !b.Wait(ctx) {
+			return
+		}
+	}
+}
+
+func (f *WebSocketFeed) session(ctx context.Context, conn *websocket.Conn, tickChan chan<- Tick) error {
+	done := make(chan struct{})
+	defer close(done)
+	defer conn.Close()
+
+	if f.Subscribe != nil {
+		if err := f.Subscribe(conn); err != nil {
+			log.Printf("subscribe %s error: %v", f.Name, err)
+			return err
+		}
+	}
+
+	// Unblock ReadMessage on shutdown and drive the optional keepalive. All
+	// writes after Subscribe happen on this goroutine.
+	go func() {
+		var keepalive <-chan time.Time
+		if f.Keepalive != nil && f.KeepaliveInterval > 0 {
+			ticker := time.NewTicker(f.KeepaliveInterval)
+			defer ticker.Stop()
+			keepalive = ticker.C
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-keepalive:
+				if err := f.Keepalive(conn); err != nil {
+					log.Printf("keepalive %s error: %v", f.Name, err)
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("read %s error: %v", f.Name, err)
+			}
+			return err
+		}
+
+		ticks, err := f.Parse(message)
+		if err != nil {
+			log.Printf("parse %s error: %v", f.Name, err)
+			continue
+		}
+		for _, tick := range ticks {
+			select {
+			case tickChan <- tick:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/alerts"
+)
+
+const saveAlert = `
+        INSERT INTO alerts
+        (id, exchange, symbol, kind, "interval", threshold, direction, lookback,
+         cooldown_ms, webhook_url, created_at, last_triggered_at)
+        VALUES (:id, :exchange, :symbol, :kind, :interval, :threshold, :direction, :lookback,
+                :cooldown_ms, :webhook_url, :created_at, :last_triggered_at)
+        ON CONFLICT (id) DO UPDATE SET
+            exchange = EXCLUDED.exchange, symbol = EXCLUDED.symbol, kind = EXCLUDED.kind,
+            "interval" = EXCLUDED."interval", threshold = EXCLUDED.threshold,
+            direction = EXCLUDED.direction, lookback = EXCLUDED.lookback,
+            cooldown_ms = EXCLUDED.cooldown_ms, webhook_url = EXCLUDED.webhook_url`
+
+// alertRow is an alert as stored, with its cooldown in milliseconds.
+type alertRow struct {
+	ID            string          `db:"id"`
+	Exchange      string          `db:"exchange"`
+	Symbol        string          `db:"symbol"`
+	Kind          string          `db:"kind"`
+	Interval      string          `db:"interval"`
+	Threshold     decimal.Decimal `db:"threshold"`
+	Direction     string          `db:"direction"`
+	Lookback      int             `db:"lookback"`
+	CooldownMs    int64           `db:"cooldown_ms"`
+	WebhookURL    string          `db:"webhook_url"`
+	CreatedAt     time.Time       `db:"created_at"`
+	LastTriggered sql.NullTime    `db:"last_triggered_at"`
+}
+
+// SaveAlert inserts an alert or replaces the rule of an existing one. The
+// last trigger time is only ever set by MarkAlertTriggered.
+func (s *PostgresStorage) SaveAlert(ctx context.Context, a alerts.Alert) error {
+	row := alertRow{
+		ID:            a.ID,
+		Exchange:      a.Exchange,
+		Symbol:        a.Symbol,
+		Kind:          string(a.Kind),
+		Interval:      a.Interval,
+		Threshold:     a.Threshold,
+		Direction:     string(a.Direction),
+		Lookback:      a.Lookback,
+		CooldownMs:    a.Cooldown.Milliseconds(),
+		WebhookURL:    a.WebhookURL,
+		CreatedAt:     a.CreatedAt,
+		LastTriggered: sql.NullTime{Time: a.LastTriggered, Valid: !a.LastTriggered.IsZero()},
+	}
+	if _, err := s.db.NamedExecContext(ctx, saveAlert, row); err != nil {
+		return fmt.Errorf("save alert: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStorage) DeleteAlert(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM alerts WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("delete alert: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStorage) ListAlerts(ctx context.Context) ([]alerts.Alert, error) {
+	rows := []alertRow{}
+	if err := s.db.SelectContext(ctx, &rows, `SELECT * FROM alerts ORDER BY created_at, id`); err != nil {
+		return nil, fmt.Errorf("list alerts: %w", err)
+	}
+	list := make([]alerts.Alert, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, alerts.Alert{
+			ID:            row.ID,
+			Exchange:      row.Exchange,
+			Symbol:        row.Symbol,
+			Kind:          alerts.Kind(row.Kind),
+			Interval:      row.Interval,
+			Threshold:     row.Threshold,
+			Direction:     alerts.Direction(row.Direction),
+			Lookback:      row.Lookback,
+			Cooldown:      time.Duration(row.CooldownMs) * time.Millisecond,
+			WebhookURL:    row.WebhookURL,
+			CreatedAt:     row.CreatedAt,
+			LastTriggered: row.LastTriggered.Time,
+		})
+	}
+	return list, nil
+}
+
+// MarkAlertTriggered records when an alert last fired, so its cooldown
+// survives a restart.
+func (s *PostgresStorage) MarkAlertTriggered(ctx context.Context, id string, at time.Time) error {
+	const query = `
+        UPDATE alerts SET last_triggered_at = $2
+        WHERE id = $1 AND (last_triggered_at IS NULL OR last_triggered_at < $2)`
+	if _, err := s.db.ExecContext(ctx, query, id, at); err != nil {
+		return fmt.Errorf("mark alert triggered: %w", err)
+	}
+	return nil
+}
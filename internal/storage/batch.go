@@ -0,0 +1,52 @@
+package storage
+
+import "time"
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultWriteTimeout  = 10 * time.Second
+)
+
+// batchSettings returns the batch size and flush interval set by opts, for
+// the persisters that batch like the Writer does.
+func batchSettings(opts []WriterOption) (int, time.Duration) {
+	w := &Writer{batchSize: defaultBatchSize, flushInterval: defaultFlushInterval}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w.batchSize, w.flushInterval
+}
+
+// persistBatches collects items from ch and passes them to write once size
+// have been collected, and whatever has been collected every interval. It
+// returns once ch is closed and the last batch is written; write must not
+// keep the slice.
+func persistBatches[T any](ch <-chan T, size int, interval time.Duration, write func([]T)) {
+	batch := make([]T, 0, size)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case item, ok := <-ch:
+			if !ok {
+				if len(batch) > 0 {
+					write(batch)
+				}
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) >= size {
+				write(batch)
+				batch = batch[:0]
+			}
+
+		case <-ticker.C:
+			if len(batch) > 0 {
+				write(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/derived"
+)
+
+const insertHeikinAshi = `
+        INSERT INTO heikin_ashi_candles
+        (exchange, symbol, "interval", open, high, low, close, volume, start_time, end_time)
+        VALUES (:exchange, :symbol, :interval, :open, :high, :low, :close, :volume, :start_time, :end_time)
+        ON CONFLICT (exchange, symbol, "interval", start_time) DO NOTHING`
+
+const insertRenko = `
+        INSERT INTO renko_bricks
+        (exchange, symbol, box_size, open, high, low, close, volume, quote_volume, trade_count, start_time, end_time)
+        VALUES (:exchange, :symbol, :box_size, :open, :high, :low, :close, :volume, :quote_volume, :trade_count, :start_time, :end_time)
+        ON CONFLICT (exchange, symbol, box_size, start_time) DO NOTHING`
+
+// StartPersistingDerived writes derived bars to their own tables in batches
+// sized by opts, like StartPersisting does for candles, until barChan is
+// closed.
+func (s *PostgresStorage) StartPersistingDerived(barChan <-chan derived.Bar, opts ...WriterOption) {
+	size, interval := batchSettings(opts)
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		log.Println("Derived series persistence worker started")
+		// The stage sends the bars of the candles flushed at shutdown
+		// after ctx is done, so only barChan closing stops this.
+		persistBatches(barChan, size, interval, s.persistDerived)
+		log.Println("Derived series persistence worker stopped")
+	}()
+}
+
+func (s *PostgresStorage) persistDerived(bars []derived.Bar) {
+	var heikinAshi, renko []derived.Bar
+	for _, bar := range bars {
+		switch bar.Kind {
+		case derived.HeikinAshi:
+			heikinAshi = append(heikinAshi, bar)
+		case derived.Renko:
+			renko = append(renko, bar)
+		}
+	}
+
+	if len(heikinAshi) > 0 {
+		if _, err := s.db.NamedExec(insertHeikinAshi, heikinAshi); err != nil {
+			log.Printf("Heikin-Ashi persistence error: %v", err)
+		}
+	}
+	if len(renko) > 0 {
+		if _, err := s.db.NamedExec(insertRenko, renko); err != nil {
+			log.Printf("Renko persistence error: %v", err)
+		}
+	}
+}
+
+// LatestDerived returns the newest Heikin-Ashi candle of every series and
+// the newest Renko brick of every instrument, to warm the derived stage up
+// with.
+func (s *PostgresStorage) LatestDerived(ctx context.Context) ([]derived.Bar, error) {
+	heikinAshi := []derived.Bar{}
+	if err := s.db.SelectContext(ctx, &heikinAshi, `
+        SELECT DISTINCT ON (exchange, symbol, "interval")
+               exchange, symbol, "interval", open, high, low, close, volume, start_time, end_time
+        FROM heikin_ashi_candles
+        ORDER BY exchange, symbol, "interval", start_time DESC`); err != nil {
+		return nil, fmt.Errorf("query latest heikin_ashi: %w", err)
+	}
+	renko := []derived.Bar{}
+	if err := s.db.SelectContext(ctx, &renko, `
+        SELECT DISTINCT ON (exchange, symbol)
+               exchange, symbol, box_size, open, high, low, close, volume, quote_volume, trade_count, start_time, end_time
+        FROM renko_bricks
+        ORDER BY exchange, symbol, start_time DESC`); err != nil {
+		return nil, fmt.Errorf("query latest renko: %w", err)
+	}
+
+	bars := make([]derived.Bar, 0, len(heikinAshi)+len(renko))
+	for _, bar := range heikinAshi {
+		bar.Kind, bar.Finalized = derived.HeikinAshi, true
+		bars = append(bars, bar)
+	}
+	for _, bar := range renko {
+		bar.Kind, bar.Interval, bar.Finalized = derived.Renko, derived.RenkoInterval, true
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
+
+// QueryDerived reads a derived series like QueryCandles reads candles.
+// Renko bricks are selected by instrument, in the box size of its newest
+// brick, so bricks of different sizes never mix; q.Interval is ignored.
+func (s *PostgresStorage) QueryDerived(ctx context.Context, kind derived.Kind, q CandleQuery) ([]aggregator.Candle, error) {
+	var query string
+	var args []any
+	switch kind {
+	case derived.HeikinAshi:
+		query = `
+        SELECT exchange, symbol, "interval", open, high, low, close, volume, start_time, end_time
+        FROM heikin_ashi_candles
+        WHERE exchange = $1 AND symbol = $2 AND "interval" = $3
+          AND start_time >= $4 AND start_time < $5 AND start_time > $6
+        ORDER BY start_time
+        LIMIT $7`
+		args = []any{q.Exchange, q.Symbol, q.Interval, q.From, q.To, q.After, q.Limit}
+	case derived.Renko:
+		query = `
+        SELECT exchange, symbol, open, high, low, close, volume, quote_volume, trade_count, start_time, end_time
+        FROM renko_bricks
+        WHERE exchange = $1 AND symbol = $2
+          AND box_size = (
+              SELECT box_size FROM renko_bricks
+              WHERE exchange = $1 AND symbol = $2
+              ORDER BY start_time DESC
+              LIMIT 1)
+          AND start_time >= $3 AND start_time < $4 AND start_time > $5
+        ORDER BY start_time
+        LIMIT $6`
+		args = []any{q.Exchange, q.Symbol, q.From, q.To, q.After, q.Limit}
+	default:
+		return nil, fmt.Errorf("unknown derived series %d", kind)
+	}
+
+	candles := []aggregator.Candle{}
+	if err := s.db.SelectContext(ctx, &candles, query, args...); err != nil {
+		return nil, fmt.Errorf("query %s: %w", kind, err)
+	}
+	for i := range candles {
+		candles[i].Finalized = true
+		if kind == derived.Renko {
+			candles[i].Interval = derived.RenkoInterval
+		}
+	}
+	return candles, nil
+}
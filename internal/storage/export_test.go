@@ -0,0 +1,4 @@
+package storage
+
+// PGInterval exposes pgInterval to the tests.
+var PGInterval = pgInterval
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shubie/trading/internal/indicators"
+	"github.com/shubie/trading/internal/source"
+)
+
+const insertIndicatorValues = `
+        INSERT INTO indicator_values
+        (exchange, symbol, "interval", indicator, start_time, end_time, "values")
+        VALUES (:exchange, :symbol, :interval, :indicator, :start_time, :end_time, :values)
+        ON CONFLICT (exchange, symbol, "interval", indicator, start_time) DO UPDATE SET
+            end_time = EXCLUDED.end_time, "values" = EXCLUDED."values"`
+
+// indicatorRow is an indicator value as stored, with its outputs as JSON.
+type indicatorRow struct {
+	Exchange  string    `db:"exchange"`
+	Symbol    string    `db:"symbol"`
+	Interval  string    `db:"interval"`
+	Indicator string    `db:"indicator"`
+	StartTime time.Time `db:"start_time"`
+	EndTime   time.Time `db:"end_time"`
+	Values    []byte    `db:"values"`
+}
+
+// StartPersistingIndicators writes indicator values in batches sized by
+// opts, like StartPersisting does for candles, until valueChan is closed.
+func (s *PostgresStorage) StartPersistingIndicators(valueChan <-chan indicators.Value, opts ...WriterOption) {
+	size, interval := batchSettings(opts)
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		log.Println("Indicator persistence worker started")
+		// The engine sends the values of the candles flushed at shutdown
+		// after ctx is done, so only valueChan closing stops this.
+		persistBatches(valueChan, size, interval, s.persistIndicators)
+		log.Println("Indicator persistence worker stopped")
+	}()
+}
+
+func (s *PostgresStorage) persistIndicators(values []indicators.Value) {
+	rows := make([]indicatorRow, 0, len(values))
+	for _, v := range values {
+		raw, err := json.Marshal(v.Values)
+		if err != nil {
+			log.Printf("Indicator encoding error: %v", err)
+			continue
+		}
+		rows = append(rows, indicatorRow{
+			Exchange:  v.Exchange,
+			Symbol:    v.Symbol,
+			Interval:  v.Interval,
+			Indicator: v.Indicator,
+			StartTime: v.StartTime,
+			EndTime:   v.EndTime,
+			Values:    raw,
+		})
+	}
+	if len(rows) == 0 {
+		return
+	}
+	if _, err := s.db.NamedExec(insertIndicatorValues, rows); err != nil {
+		log.Printf("Indicator persistence error: %v", err)
+	}
+}
+
+// QueryIndicators returns the stored values of an instrument and interval
+// for candles that started at or after since, oldest first.
+func (s *PostgresStorage) QueryIndicators(ctx context.Context, inst source.Instrument, interval string, since time.Time) ([]indicators.Value, error) {
+	const query = `
+        SELECT exchange, symbol, "interval", indicator, start_time, end_time, "values"
+        FROM indicator_values
+        WHERE exchange = $1 AND symbol = $2 AND "interval" = $3 AND start_time >= $4
+        ORDER BY start_time, indicator`
+
+	rows := []indicatorRow{}
+	if err := s.db.SelectContext(ctx, &rows, query, inst.Exchange, inst.Symbol, interval, since); err != nil {
+		return nil, fmt.Errorf("query indicators: %w", err)
+	}
+	values := make([]indicators.Value, 0, len(rows))
+	for _, row := range rows {
+		v := indicators.Value{
+			Exchange:  row.Exchange,
+			Symbol:    row.Symbol,
+			Interval:  row.Interval,
+			Indicator: row.Indicator,
+			StartTime: row.StartTime,
+			EndTime:   row.EndTime,
+		}
+		if err := json.Unmarshal(row.Values, &v.Values); err != nil {
+			return nil, fmt.Errorf("decode %s values: %w", row.Indicator, err)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
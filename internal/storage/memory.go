@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+)
+
+type seriesKey struct {
+	inst     source.Instrument
+	interval string
+}
+
+// MemoryStorage is a CandleStore that keeps candles in memory, for local
+// development and tests. Nothing survives a restart.
+type MemoryStorage struct {
+	mu     sync.RWMutex
+	series map[seriesKey][]aggregator.Candle // oldest first
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{series: make(map[seriesKey][]aggregator.Candle)}
+}
+
+func (m *MemoryStorage) WriteCandles(ctx context.Context, candles []aggregator.Candle, mode MergeMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, c := range candles {
+		key := seriesKey{c.Instrument(), c.Interval}
+		bars := m.series[key]
+		i := sort.Search(len(bars), func(i int) bool {
+			return !bars[i].StartTime.Before(c.StartTime)
+		})
+		if i < len(bars) && bars[i].StartTime.Equal(c.StartTime) {
+			merged, ok := resolve(bars[i], c, mode)
+			if ok {
+				merged.Finalized, merged.UpdatedAt = true, now
+				bars[i] = merged
+			}
+			continue
+		}
+		c.Finalized, c.UpdatedAt = true, now
+		bars = append(bars, aggregator.Candle{})
+		copy(bars[i+1:], bars[i:])
+		bars[i] = c
+		m.series[key] = bars
+	}
+	return nil
+}
+
+func (m *MemoryStorage) QueryCandles(ctx context.Context, q CandleQuery) ([]aggregator.Candle, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	candles := []aggregator.Candle{}
+	for _, c := range m.series[seriesKey{source.Instrument{Exchange: q.Exchange, Symbol: q.Symbol}, q.Interval}] {
+		if len(candles) >= q.Limit {
+			break
+		}
+		if q.matches(c) {
+			candles = append(candles, c)
+		}
+	}
+	return candles, nil
+}
+
+func (m *MemoryStorage) RecentCandles(ctx context.Context, inst source.Instrument, interval string, n int) ([]aggregator.Candle, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bars := m.series[seriesKey{inst, interval}]
+	if n < 0 {
+		n = 0
+	}
+	if len(bars) > n {
+		bars = bars[len(bars)-n:]
+	}
+	return append([]aggregator.Candle{}, bars...), nil
+}
+
+func (m *MemoryStorage) LatestCandle(ctx context.Context, inst source.Instrument, interval string) (aggregator.Candle, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bars := m.series[seriesKey{inst, interval}]
+	if len(bars) == 0 {
+		return aggregator.Candle{}, false, nil
+	}
+	return bars[len(bars)-1], true, nil
+}
+
+func (m *MemoryStorage) Close() error {
+	return nil
+}
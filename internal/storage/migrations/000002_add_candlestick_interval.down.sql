@@ -0,0 +1,6 @@
+DELETE FROM candlesticks WHERE "interval" <> '1m';
+
+ALTER TABLE candlesticks DROP CONSTRAINT IF EXISTS candlesticks_pkey;
+ALTER TABLE candlesticks ADD PRIMARY KEY (symbol, start_time);
+
+ALTER TABLE candlesticks DROP COLUMN IF EXISTS "interval";
@@ -0,0 +1,4 @@
+ALTER TABLE candlesticks ADD COLUMN IF NOT EXISTS "interval" TEXT NOT NULL DEFAULT '1m';
+
+ALTER TABLE candlesticks DROP CONSTRAINT IF EXISTS candlesticks_pkey;
+ALTER TABLE candlesticks ADD PRIMARY KEY (symbol, "interval", start_time);
@@ -0,0 +1,6 @@
+DELETE FROM candlesticks WHERE exchange <> 'binance';
+
+ALTER TABLE candlesticks DROP CONSTRAINT IF EXISTS candlesticks_pkey;
+ALTER TABLE candlesticks ADD PRIMARY KEY (symbol, "interval", start_time);
+
+ALTER TABLE candlesticks DROP COLUMN IF EXISTS exchange;
@@ -0,0 +1,4 @@
+ALTER TABLE candlesticks ADD COLUMN IF NOT EXISTS exchange TEXT NOT NULL DEFAULT 'binance';
+
+ALTER TABLE candlesticks DROP CONSTRAINT IF EXISTS candlesticks_pkey;
+ALTER TABLE candlesticks ADD PRIMARY KEY (exchange, symbol, "interval", start_time);
@@ -0,0 +1,6 @@
+ALTER TABLE candlesticks
+    ALTER COLUMN open TYPE DOUBLE PRECISION,
+    ALTER COLUMN high TYPE DOUBLE PRECISION,
+    ALTER COLUMN low TYPE DOUBLE PRECISION,
+    ALTER COLUMN close TYPE DOUBLE PRECISION,
+    ALTER COLUMN volume TYPE DOUBLE PRECISION;
@@ -0,0 +1,6 @@
+ALTER TABLE candlesticks
+    ALTER COLUMN open TYPE NUMERIC,
+    ALTER COLUMN high TYPE NUMERIC,
+    ALTER COLUMN low TYPE NUMERIC,
+    ALTER COLUMN close TYPE NUMERIC,
+    ALTER COLUMN volume TYPE NUMERIC;
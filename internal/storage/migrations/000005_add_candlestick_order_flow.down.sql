@@ -0,0 +1,6 @@
+ALTER TABLE candlesticks
+    DROP COLUMN IF EXISTS vwap,
+    DROP COLUMN IF EXISTS quote_volume,
+    DROP COLUMN IF EXISTS trade_count,
+    DROP COLUMN IF EXISTS taker_buy_volume,
+    DROP COLUMN IF EXISTS taker_sell_volume;
@@ -0,0 +1,6 @@
+ALTER TABLE candlesticks
+    ADD COLUMN IF NOT EXISTS vwap NUMERIC NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS quote_volume NUMERIC NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS trade_count BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS taker_buy_volume NUMERIC NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS taker_sell_volume NUMERIC NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE candlesticks
+    DROP COLUMN IF EXISTS synthetic;
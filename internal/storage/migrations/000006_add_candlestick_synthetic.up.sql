@@ -0,0 +1,2 @@
+ALTER TABLE candlesticks
+    ADD COLUMN IF NOT EXISTS synthetic BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,4 @@
+DELETE FROM candlesticks WHERE bar_type <> 'time';
+
+ALTER TABLE candlesticks
+    DROP COLUMN IF EXISTS bar_type;
@@ -0,0 +1,2 @@
+ALTER TABLE candlesticks
+    ADD COLUMN IF NOT EXISTS bar_type TEXT NOT NULL DEFAULT 'time';
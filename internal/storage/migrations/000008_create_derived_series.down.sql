@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS renko_bricks;
+DROP TABLE IF EXISTS heikin_ashi_candles;
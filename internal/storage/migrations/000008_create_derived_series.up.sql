@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS heikin_ashi_candles (
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    "interval" TEXT NOT NULL,
+    open NUMERIC NOT NULL,
+    high NUMERIC NOT NULL,
+    low NUMERIC NOT NULL,
+    close NUMERIC NOT NULL,
+    volume NUMERIC NOT NULL,
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    PRIMARY KEY (exchange, symbol, "interval", start_time)
+);
+
+SELECT create_hypertable('heikin_ashi_candles', 'start_time', if_not_exists => TRUE);
+
+CREATE TABLE IF NOT EXISTS renko_bricks (
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    box_size NUMERIC NOT NULL,
+    open NUMERIC NOT NULL,
+    high NUMERIC NOT NULL,
+    low NUMERIC NOT NULL,
+    close NUMERIC NOT NULL,
+    volume NUMERIC NOT NULL,
+    quote_volume NUMERIC NOT NULL,
+    trade_count BIGINT NOT NULL,
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    PRIMARY KEY (exchange, symbol, start_time)
+);
+
+SELECT create_hypertable('renko_bricks', 'start_time', if_not_exists => TRUE);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS indicator_values (
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    "interval" TEXT NOT NULL,
+    indicator TEXT NOT NULL,
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    "values" JSONB NOT NULL,
+    PRIMARY KEY (exchange, symbol, "interval", indicator, start_time)
+);
+
+SELECT create_hypertable('indicator_values', 'start_time', if_not_exists => TRUE);
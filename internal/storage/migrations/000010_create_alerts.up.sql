@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS alerts (
+    id TEXT PRIMARY KEY,
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    "interval" TEXT NOT NULL DEFAULT '',
+    threshold NUMERIC NOT NULL,
+    direction TEXT NOT NULL DEFAULT '',
+    lookback INTEGER NOT NULL DEFAULT 0,
+    cooldown_ms BIGINT NOT NULL DEFAULT 0,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    last_triggered_at TIMESTAMP
+);
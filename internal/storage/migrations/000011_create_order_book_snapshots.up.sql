@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS order_book_snapshots (
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    time TIMESTAMP NOT NULL,
+    last_update_id BIGINT NOT NULL,
+    bids JSONB NOT NULL,
+    asks JSONB NOT NULL,
+    PRIMARY KEY (exchange, symbol, time)
+);
+
+SELECT create_hypertable('order_book_snapshots', 'time', if_not_exists => TRUE);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS candlesticks_updated_at_idx;
+
+ALTER TABLE candlesticks
+    DROP COLUMN IF EXISTS revision,
+    DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,5 @@
+ALTER TABLE candlesticks
+    ADD COLUMN IF NOT EXISTS revision INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC');
+
+CREATE INDEX IF NOT EXISTS candlesticks_updated_at_idx ON candlesticks (updated_at);
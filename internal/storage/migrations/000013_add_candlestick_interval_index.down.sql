@@ -0,0 +1 @@
+DROP INDEX IF EXISTS candlesticks_interval_start_time_idx;
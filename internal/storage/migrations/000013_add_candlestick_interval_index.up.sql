@@ -0,0 +1,2 @@
+-- Retention expires and looks up bars by interval across every instrument.
+CREATE INDEX IF NOT EXISTS candlesticks_interval_start_time_idx ON candlesticks ("interval", start_time);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS candlesticks_1m_updated_at_idx;
@@ -0,0 +1,2 @@
+-- Rollups find the 1m bars written since their last run.
+CREATE INDEX IF NOT EXISTS candlesticks_1m_updated_at_idx ON candlesticks (updated_at) WHERE "interval" = '1m';
@@ -0,0 +1,3 @@
+-- Fails if bricks of two box sizes share a start time.
+ALTER TABLE renko_bricks DROP CONSTRAINT IF EXISTS renko_bricks_pkey;
+ALTER TABLE renko_bricks ADD PRIMARY KEY (exchange, symbol, start_time);
@@ -0,0 +1,5 @@
+-- Bricks of different box sizes are different series, so a new box (a
+-- changed config, or box_percent after a restart) must not collide with the
+-- bricks laid before it.
+ALTER TABLE renko_bricks DROP CONSTRAINT IF EXISTS renko_bricks_pkey;
+ALTER TABLE renko_bricks ADD PRIMARY KEY (exchange, symbol, box_size, start_time);
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/shubie/trading/internal/orderbook"
+)
+
+const insertBookSnapshot = `
+        INSERT INTO order_book_snapshots
+        (exchange, symbol, time, last_update_id, bids, asks)
+        VALUES (:exchange, :symbol, :time, :last_update_id, :bids, :asks)
+        ON CONFLICT (exchange, symbol, time) DO NOTHING`
+
+// bookRow is a book snapshot as stored, each side as JSON
+// [["price", "quantity"], ...] like Binance's depth endpoint.
+type bookRow struct {
+	Exchange     string    `db:"exchange"`
+	Symbol       string    `db:"symbol"`
+	Time         time.Time `db:"time"`
+	LastUpdateID int64     `db:"last_update_id"`
+	Bids         []byte    `db:"bids"`
+	Asks         []byte    `db:"asks"`
+}
+
+// StartPersistingBooks writes order book snapshots in batches sized by
+// opts, like StartPersisting does for candles, until snapshotChan is closed.
+func (s *PostgresStorage) StartPersistingBooks(snapshotChan <-chan orderbook.Snapshot, opts ...WriterOption) {
+	size, interval := batchSettings(opts)
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		log.Println("Order book persistence worker started")
+		persistBatches(snapshotChan, size, interval, s.persistBooks)
+		log.Println("Order book persistence worker stopped")
+	}()
+}
+
+func (s *PostgresStorage) persistBooks(snapshots []orderbook.Snapshot) {
+	rows := make([]bookRow, 0, len(snapshots))
+	for _, snap := range snapshots {
+		bids, err := json.Marshal(levelPairs(snap.Bids))
+		if err != nil {
+			log.Printf("Order book encoding error: %v", err)
+			continue
+		}
+		asks, err := json.Marshal(levelPairs(snap.Asks))
+		if err != nil {
+			log.Printf("Order book encoding error: %v", err)
+			continue
+		}
+		rows = append(rows, bookRow{
+			Exchange:     snap.Exchange,
+			Symbol:       snap.Symbol,
+			Time:         snap.Time,
+			LastUpdateID: snap.LastUpdateID,
+			Bids:         bids,
+			Asks:         asks,
+		})
+	}
+	if len(rows) == 0 {
+		return
+	}
+	if _, err := s.db.NamedExec(insertBookSnapshot, rows); err != nil {
+		log.Printf("Order book persistence error: %v", err)
+	}
+}
+
+func levelPairs(levels []orderbook.Level) [][2]string {
+	pairs := make([][2]string, 0, len(levels))
+	for _, l := range levels {
+		pairs = append(pairs, [2]string{l.Price.String(), l.Quantity.String()})
+	}
+	return pairs
+}
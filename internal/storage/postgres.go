@@ -5,7 +5,7 @@ import (
 	_ "database/sql"
 	"errors"
 	"fmt"
-	"log"
+	"sync"
 	"time"
 
 	"embed"
@@ -14,107 +14,389 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
 )
 
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
+// defaultCopyThreshold is the smallest candle batch written with COPY.
+// Creating and merging the staging table costs more than it saves on
+// smaller batches.
+const defaultCopyThreshold = 500
+
 type PostgresStorage struct {
-	db *sqlx.DB
+	db            *sqlx.DB
+	copyThreshold int
+
+	// workers counts the background goroutines Close waits for.
+	workers sync.WaitGroup
+
+	retentionMu sync.Mutex
+	retention   *retentionState
 }
 
-func NewPostgresStorage(dsn string) *PostgresStorage {
+type PostgresOption func(*PostgresStorage)
+
+// WithCopyThreshold writes candle batches of at least n bars with COPY into
+// a staging table and a single merge, instead of multi-row inserts. n <= 0
+// never uses COPY.
+func WithCopyThreshold(n int) PostgresOption {
+	return func(s *PostgresStorage) {
+		s.copyThreshold = n
+	}
+}
+
+// NewPostgresStorage connects to dsn and applies pending migrations.
+func NewPostgresStorage(dsn string, opts ...PostgresOption) (*PostgresStorage, error) {
 	db, err := sqlx.Connect("postgres", dsn)
 	if err != nil {
-		panic(fmt.Sprintf("DB connection error: %v", err))
+		return nil, fmt.Errorf("connect: %w", err)
 	}
 
 	db.SetMaxOpenConns(25)
 	db.SetMaxIdleConns(25)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
-	runMigrations(dsn)
+	if err := runMigrations(dsn); err != nil {
+		db.Close()
+		return nil, err
+	}
 
-	return &PostgresStorage{db: db}
+	s := &PostgresStorage{db: db, copyThreshold: defaultCopyThreshold}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
 }
 
-func runMigrations(dsn string) {
+func runMigrations(dsn string) error {
 	driver, err := iofs.New(migrationsFS, "migrations")
 	if err != nil {
-		log.Fatal("Failed to create migration driver:", err)
+		return fmt.Errorf("create migration driver: %w", err)
 	}
 
 	m, err := migrate.NewWithSourceInstance("iofs", driver, dsn)
 	if err != nil {
-		log.Fatal("Migration setup failed:", err)
+		return fmt.Errorf("migration setup: %w", err)
 	}
 
 	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		log.Fatal("Migration failed:", err)
+		return fmt.Errorf("migrate: %w", err)
 	}
+	return nil
 }
 
-func (s *PostgresStorage) StartPersisting(ctx context.Context, candleChan <-chan aggregator.Candle) {
-	go func() {
-		log.Println("Persistence worker started")
-		batch := make([]aggregator.Candle, 0, 100)
-		ticker := time.NewTicker(1 * time.Second)
-		defer func() {
-			ticker.Stop()
-			if len(batch) > 0 {
-				s.persistBatch(batch)
-				log.Printf("Persisted final batch of %d candles\n", len(batch))
-			}
-			log.Println("Persistence worker stopped")
-		}()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-
-			case candle, ok := <-candleChan:
-				if !ok {
-					return
-				}
-				batch = append(batch, candle)
-				if len(batch) >= 100 {
-					s.persistBatch(batch)
-					batch = batch[:0]
-				}
-
-			case <-ticker.C:
-				if len(batch) > 0 {
-					s.persistBatch(batch)
-					batch = batch[:0]
-				}
-			}
+const candleColumns = `
+        exchange, symbol, "interval", bar_type, open, high, low, close, volume,
+        vwap, quote_volume, trade_count, taker_buy_volume, taker_sell_volume, synthetic, start_time, end_time,
+        revision, updated_at`
+
+const insertCandles = `
+        INSERT INTO candlesticks (` + candleColumns + `)
+        VALUES (:exchange, :symbol, :interval, :bar_type, :open, :high, :low, :close, :volume,
+         :vwap, :quote_volume, :trade_count, :taker_buy_volume, :taker_sell_volume, :synthetic, :start_time, :end_time,
+         :revision, :updated_at)`
+
+const setCandleColumns = `
+            bar_type = EXCLUDED.bar_type, open = EXCLUDED.open, high = EXCLUDED.high, low = EXCLUDED.low,
+            close = EXCLUDED.close, volume = EXCLUDED.volume, vwap = EXCLUDED.vwap,
+            quote_volume = EXCLUDED.quote_volume, trade_count = EXCLUDED.trade_count,
+            taker_buy_volume = EXCLUDED.taker_buy_volume, taker_sell_volume = EXCLUDED.taker_sell_volume,
+            synthetic = EXCLUDED.synthetic, end_time = EXCLUDED.end_time`
+
+// replaceStored replaces stored candles with the written ones, revision
+// included.
+const replaceStored = `
+        ON CONFLICT (exchange, symbol, "interval", start_time) DO UPDATE SET` + setCandleColumns + `,
+            revision = EXCLUDED.revision, updated_at = EXCLUDED.updated_at`
+
+// candleChanged holds when the written candle differs from the stored one
+// in anything but its revision and update time.
+const candleChanged = `
+            (candlesticks.bar_type, candlesticks.open, candlesticks.high, candlesticks.low, candlesticks.close,
+             candlesticks.volume, candlesticks.vwap, candlesticks.quote_volume, candlesticks.trade_count,
+             candlesticks.taker_buy_volume, candlesticks.taker_sell_volume, candlesticks.synthetic, candlesticks.end_time)
+            IS DISTINCT FROM
+            (EXCLUDED.bar_type, EXCLUDED.open, EXCLUDED.high, EXCLUDED.low, EXCLUDED.close,
+             EXCLUDED.volume, EXCLUDED.vwap, EXCLUDED.quote_volume, EXCLUDED.trade_count,
+             EXCLUDED.taker_buy_volume, EXCLUDED.taker_sell_volume, EXCLUDED.synthetic, EXCLUDED.end_time)`
+
+// replaceOlderRevisions replaces stored candles of a lower revision, and
+// stored candles a differing first version rebuilt, which then counts one
+// revision past the stored one, as resolve does.
+const replaceOlderRevisions = `
+        ON CONFLICT (exchange, symbol, "interval", start_time) DO UPDATE SET` + setCandleColumns + `,
+            revision = CASE WHEN EXCLUDED.revision > candlesticks.revision
+                THEN EXCLUDED.revision ELSE candlesticks.revision + 1 END,
+            updated_at = EXCLUDED.updated_at
+        WHERE candlesticks.revision < EXCLUDED.revision
+           OR (EXCLUDED.revision = 0 AND` + candleChanged + `)`
+
+const selectCandles = `
+        SELECT` + candleColumns + `
+        FROM candlesticks`
+
+// The staging table lives as long as the connection, and is emptied when
+// each transaction ends.
+const createStaging = `
+        CREATE TEMP TABLE IF NOT EXISTS candlesticks_staging (LIKE candlesticks) ON COMMIT DELETE ROWS`
+
+const mergeStaging = `
+        INSERT INTO candlesticks (` + candleColumns + `)
+        SELECT` + candleColumns + `
+        FROM candlesticks_staging`
+
+// copyColumns are filled by COPY in the order copyRow lists the values.
+var copyColumns = []string{
+	"exchange", "symbol", "interval", "bar_type", "open", "high", "low", "close", "volume",
+	"vwap", "quote_volume", "trade_count", "taker_buy_volume", "taker_sell_volume", "synthetic", "start_time", "end_time",
+	"revision", "updated_at",
+}
+
+func copyRow(c aggregator.Candle) []interface{} {
+	return []interface{}{
+		c.Exchange, c.Symbol, c.Interval, c.BarType, c.Open, c.High, c.Low, c.Close, c.Volume,
+		c.VWAP, c.QuoteVolume, c.TradeCount, c.TakerBuyVolume, c.TakerSellVolume, c.Synthetic, c.StartTime, c.EndTime,
+		c.Revision, c.UpdatedAt,
+	}
+}
+
+func (s *PostgresStorage) WriteCandles(ctx context.Context, candles []aggregator.Candle, mode MergeMode) error {
+	return pgRejected(s.writeCandles(ctx, candles, mode))
+}
+
+// pgRejected wraps errors for data Postgres refuses, a data exception or
+// an integrity constraint violation, in ErrRejected.
+func pgRejected(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "22", "23":
+			return fmt.Errorf("%w: %w", ErrRejected, err)
 		}
-	}()
+	}
+	return err
 }
 
-func (s *PostgresStorage) persistBatch(candles []aggregator.Candle) {
+func (s *PostgresStorage) writeCandles(ctx context.Context, candles []aggregator.Candle, mode MergeMode) error {
 	if len(candles) == 0 {
-		return
+		return nil
+	}
+	now := time.Now().UTC()
+	stamped := make([]aggregator.Candle, len(candles))
+	for i, c := range candles {
+		c.UpdatedAt = now
+		stamped[i] = c
+	}
+
+	if mode == MergeCombine {
+		return s.combineCandles(ctx, stamped)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+	// One upsert cannot touch a row twice, so only the newest revision
+	// of each bar is written.
+	if err := s.upsertBatch(ctx, tx, replaceOlderRevisions, newestRevisions(stamped)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// upsertBatch inserts candles within tx, resolving conflicts with stored
+// bars by onConflict. Batches past the copy threshold go through the
+// staging table.
+func (s *PostgresStorage) upsertBatch(ctx context.Context, tx *sqlx.Tx, onConflict string, candles []aggregator.Candle) error {
+	if s.copyThreshold > 0 && len(candles) >= s.copyThreshold {
+		return copyCandles(ctx, tx, onConflict, candles)
+	}
+	for len(candles) > 0 {
+		n := min(len(candles), upsertBatchSize)
+		if _, err := tx.NamedExecContext(ctx, insertCandles+onConflict, candles[:n]); err != nil {
+			return fmt.Errorf("upsert candles: %w", err)
+		}
+		candles = candles[n:]
+	}
+	return nil
+}
+
+// copyCandles streams candles into the staging table with COPY FROM STDIN
+// and merges them into candlesticks with one statement.
+func copyCandles(ctx context.Context, tx *sqlx.Tx, onConflict string, candles []aggregator.Candle) error {
+	if _, err := tx.ExecContext(ctx, createStaging); err != nil {
+		return fmt.Errorf("create staging table: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("candlesticks_staging", copyColumns...))
+	if err != nil {
+		return fmt.Errorf("copy candles: %w", err)
+	}
+	for _, c := range candles {
+		if _, err := stmt.ExecContext(ctx, copyRow(c)...); err != nil {
+			stmt.Close()
+			return fmt.Errorf("copy candles: %w", err)
+		}
 	}
+	// The final empty Exec flushes the buffered rows.
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return fmt.Errorf("copy candles: %w", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("copy candles: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, mergeStaging+onConflict); err != nil {
+		return fmt.Errorf("merge staged candles: %w", err)
+	}
+	return nil
+}
+
+type barKey struct {
+	inst     source.Instrument
+	interval string
+	start    int64
+}
+
+func keyOf(c aggregator.Candle) barKey {
+	return barKey{c.Instrument(), c.Interval, c.StartTime.UnixNano()}
+}
 
-	query := `
-        INSERT INTO candlesticks 
-        (symbol, open, high, low, close, volume, start_time, end_time)
-        VALUES (:symbol, :open, :high, :low, :close, :volume, :start_time, :end_time)
-        ON CONFLICT (symbol, start_time) DO NOTHING`
+// newestRevisions keeps the highest revision of each bar, the last one
+// written if several are equal, in order.
+func newestRevisions(candles []aggregator.Candle) []aggregator.Candle {
+	newest := make(map[barKey]int, len(candles))
+	for i, c := range candles {
+		if j, ok := newest[keyOf(c)]; !ok || c.Revision >= candles[j].Revision {
+			newest[keyOf(c)] = i
+		}
+	}
+	kept := candles[:0:0]
+	for i, c := range candles {
+		if newest[keyOf(c)] == i {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
 
-	_, err := s.db.NamedExec(query, candles)
+// combineCandles merges candles into the stored versions of their bars as
+// MergeCombine says. The stored rows are locked while they are merged.
+func (s *PostgresStorage) combineCandles(ctx context.Context, candles []aggregator.Candle) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
-		log.Printf("Persistence error: %v", err)
-		return
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Load the stored bars of each series over the span the batch covers.
+	type span struct{ from, to time.Time }
+	spans := make(map[seriesKey]span)
+	for _, c := range candles {
+		key := seriesKey{c.Instrument(), c.Interval}
+		sp, ok := spans[key]
+		if !ok || c.StartTime.Before(sp.from) {
+			sp.from = c.StartTime
+		}
+		if !ok || c.StartTime.After(sp.to) {
+			sp.to = c.StartTime
+		}
+		spans[key] = sp
+	}
+	bars := make(map[barKey]aggregator.Candle)
+	for key, sp := range spans {
+		var stored []aggregator.Candle
+		if err := tx.SelectContext(ctx, &stored, selectCandles+`
+        WHERE exchange = $1 AND symbol = $2 AND "interval" = $3 AND start_time >= $4 AND start_time <= $5
+        FOR UPDATE`, key.inst.Exchange, key.inst.Symbol, key.interval, sp.from, sp.to); err != nil {
+			return fmt.Errorf("lock stored candles: %w", err)
+		}
+		for _, c := range stored {
+			bars[keyOf(c)] = c
+		}
+	}
+
+	var changed []aggregator.Candle
+	index := make(map[barKey]int)
+	for _, c := range candles {
+		key := keyOf(c)
+		if stored, ok := bars[key]; ok {
+			merged, ok := resolve(stored, c, MergeCombine)
+			if !ok {
+				continue
+			}
+			merged.UpdatedAt = c.UpdatedAt
+			c = merged
+		}
+		bars[key] = c
+		if i, ok := index[key]; ok {
+			changed[i] = c
+		} else {
+			index[key] = len(changed)
+			changed = append(changed, c)
+		}
+	}
+
+	if err := s.upsertBatch(ctx, tx, replaceStored, changed); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresStorage) QueryCandles(ctx context.Context, q CandleQuery) ([]aggregator.Candle, error) {
+	query := selectCandles + `
+        WHERE exchange = $1 AND symbol = $2 AND "interval" = $3
+          AND start_time >= $4 AND start_time < $5 AND start_time > $6
+        ORDER BY start_time
+        LIMIT $7`
+
+	candles := []aggregator.Candle{}
+	if err := s.db.SelectContext(ctx, &candles, query,
+		q.Exchange, q.Symbol, q.Interval, q.From, q.To, q.After, q.Limit); err != nil {
+		return nil, fmt.Errorf("query candles: %w", err)
+	}
+	for i := range candles {
+		candles[i].Finalized = true
 	}
+	return candles, nil
+}
+
+func (s *PostgresStorage) RecentCandles(ctx context.Context, inst source.Instrument, interval string, n int) ([]aggregator.Candle, error) {
+	const query = selectCandles + `
+        WHERE exchange = $1 AND symbol = $2 AND "interval" = $3
+        ORDER BY start_time DESC
+        LIMIT $4`
 
-	log.Printf("Persisted %d candles", len(candles))
+	candles := []aggregator.Candle{}
+	if err := s.db.SelectContext(ctx, &candles, query, inst.Exchange, inst.Symbol, interval, n); err != nil {
+		return nil, fmt.Errorf("query recent candles: %w", err)
+	}
+	for i, j := 0, len(candles)-1; i < j; i, j = i+1, j-1 {
+		candles[i], candles[j] = candles[j], candles[i]
+	}
+	for i := range candles {
+		candles[i].Finalized = true
+	}
+	return candles, nil
+}
+
+func (s *PostgresStorage) LatestCandle(ctx context.Context, inst source.Instrument, interval string) (aggregator.Candle, bool, error) {
+	candles, err := s.RecentCandles(ctx, inst, interval, 1)
+	if err != nil || len(candles) == 0 {
+		return aggregator.Candle{}, false, err
+	}
+	return candles[0], true, nil
 }
 
+// Close waits for the background workers, which stop once the context they
+// were started with is done or, for the persisters, once their channel is
+// closed, then closes the database.
 func (s *PostgresStorage) Close() error {
+	s.workers.Wait()
 	return s.db.Close()
 }
@@ -0,0 +1,492 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/shubie/trading/internal/aggregator"
+)
+
+// retentionEvery is how often expired bars are deleted.
+const retentionEvery = time.Hour
+
+// AnyInterval labels the retention of intervals without one of their own in
+// StorageStatus.
+const AnyInterval = "*"
+
+// RetentionPolicy is how the candlesticks hypertable is chunked, compressed
+// and expired.
+type RetentionPolicy struct {
+	// ChunkInterval is the time span of new chunks; zero leaves it as is.
+	ChunkInterval time.Duration
+	// CompressAfter compresses chunks once they are this old, segmented by
+	// instrument and interval; zero removes the compression policy.
+	CompressAfter time.Duration
+	// Keep is how long bars are kept by interval label, e.g. "1m"; zero
+	// keeps them forever.
+	Keep map[string]time.Duration
+	// Default is how long bars of intervals missing from Keep are kept;
+	// zero keeps them forever.
+	Default time.Duration
+}
+
+// ParseRetentionPolicy parses the ages of a policy as configured, such as
+// "7d" or "730d", where an empty age keeps bars forever. Keep labels are
+// normalized to the labels bars are stored under, so "60m" covers the 1h
+// bars.
+func ParseRetentionPolicy(chunkInterval, compressAfter, defaultAge string, keep map[string]string) (RetentionPolicy, error) {
+	age := func(s string) (time.Duration, error) {
+		if s == "" {
+			return 0, nil
+		}
+		return aggregator.ParseInterval(s)
+	}
+
+	var policy RetentionPolicy
+	var err error
+	if policy.ChunkInterval, err = age(chunkInterval); err != nil {
+		return policy, fmt.Errorf("chunk_interval: %w", err)
+	}
+	if policy.CompressAfter, err = age(compressAfter); err != nil {
+		return policy, fmt.Errorf("compress_after: %w", err)
+	}
+	if policy.Default, err = age(defaultAge); err != nil {
+		return policy, fmt.Errorf("default: %w", err)
+	}
+
+	labels := make([]string, 0, len(keep))
+	for label := range keep {
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+	policy.Keep = make(map[string]time.Duration, len(keep))
+	configured := make(map[string]string, len(keep))
+	for _, label := range labels {
+		interval := label
+		if d, err := aggregator.ParseInterval(label); err == nil {
+			interval = aggregator.FormatInterval(d)
+		}
+		if other, ok := configured[interval]; ok {
+			return policy, fmt.Errorf("keep.%s: same interval as keep.%s", label, other)
+		}
+		configured[interval] = label
+		if policy.Keep[interval], err = age(keep[label]); err != nil {
+			return policy, fmt.Errorf("keep.%s: %w", label, err)
+		}
+	}
+	return policy, nil
+}
+
+// DropAfter is the age past which every bar has expired, so whole chunks
+// can be dropped, or zero if some are kept forever.
+func (p RetentionPolicy) DropAfter() time.Duration {
+	if p.Default <= 0 {
+		return 0
+	}
+	longest := p.Default
+	for _, keep := range p.Keep {
+		if keep <= 0 {
+			return 0
+		}
+		longest = max(longest, keep)
+	}
+	return longest
+}
+
+// IntervalRetention is how long bars of one interval are kept, and what the
+// last expiry run deleted.
+type IntervalRetention struct {
+	Interval string
+	Keep     time.Duration
+	// Oldest is the start of the oldest stored bar, zero if there is none.
+	Oldest  time.Time
+	Deleted int64
+	LastRun time.Time
+}
+
+// StorageStatus describes the candlesticks hypertable and the policies
+// applied to it.
+type StorageStatus struct {
+	ChunkInterval          time.Duration
+	Chunks                 int
+	CompressedChunks       int
+	CompressionEnabled     bool
+	CompressAfter          time.Duration // zero without a compression policy
+	DropAfter              time.Duration // zero without a retention policy
+	TotalBytes             int64
+	BeforeCompressionBytes int64
+	AfterCompressionBytes  int64
+	Retention              []IntervalRetention
+	// ReconciledAt is when the policy was applied, and LastError why the
+	// latest run failed to apply it or delete expired bars.
+	ReconciledAt time.Time
+	LastError    string
+}
+
+// retentionState is what the reconciler reports beyond the database.
+type retentionState struct {
+	policy       RetentionPolicy
+	runs         map[string]IntervalRetention
+	reconciledAt time.Time
+	lastError    string
+}
+
+// StartRetention applies policy to the candlesticks hypertable, then deletes
+// expired bars every hour until ctx is done. TimescaleDB retention policies
+// drop whole chunks, which hold every interval, so bars are expired per
+// interval with DELETE; a drop_chunks policy is added only once Default
+// bounds every interval.
+func (s *PostgresStorage) StartRetention(ctx context.Context, policy RetentionPolicy) {
+	s.retentionMu.Lock()
+	s.retention = &retentionState{policy: policy, runs: make(map[string]IntervalRetention)}
+	s.retentionMu.Unlock()
+
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		log.Println("Retention worker started")
+		reconciled := false
+		ticker := time.NewTicker(retentionEvery)
+		defer ticker.Stop()
+
+		for {
+			var err error
+			if !reconciled {
+				err = s.reconcile(ctx, policy)
+				reconciled = err == nil
+			}
+			if err == nil {
+				err = s.expire(ctx, policy)
+			}
+			s.recordRetention(ctx, err, reconciled)
+
+			select {
+			case <-ctx.Done():
+				log.Println("Retention worker stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// recordRetention keeps the outcome of a run of the worker for
+// StorageStatus.
+func (s *PostgresStorage) recordRetention(ctx context.Context, err error, reconciled bool) {
+	if ctx.Err() != nil {
+		return
+	}
+	s.retentionMu.Lock()
+	defer s.retentionMu.Unlock()
+	if reconciled && s.retention.reconciledAt.IsZero() {
+		s.retention.reconciledAt = time.Now()
+	}
+	s.retention.lastError = ""
+	if err != nil {
+		log.Printf("Retention: %v", err)
+		s.retention.lastError = err.Error()
+	}
+}
+
+// reconcile brings the chunk interval, compression and retention policies of
+// candlesticks in line with policy.
+func (s *PostgresStorage) reconcile(ctx context.Context, policy RetentionPolicy) error {
+	if policy.ChunkInterval > 0 {
+		if _, err := s.db.ExecContext(ctx,
+			`SELECT set_chunk_time_interval('candlesticks', $1::interval)`, pgInterval(policy.ChunkInterval)); err != nil {
+			return fmt.Errorf("set chunk interval: %w", err)
+		}
+	}
+
+	if policy.CompressAfter > 0 {
+		var enabled bool
+		if err := s.db.GetContext(ctx, &enabled, `
+        SELECT compression_enabled FROM timescaledb_information.hypertables
+        WHERE hypertable_name = 'candlesticks'`); err != nil {
+			return fmt.Errorf("read compression settings: %w", err)
+		}
+		var segmentBy []string
+		if enabled {
+			var err error
+			if segmentBy, err = s.compressSegmentBy(ctx); err != nil {
+				return err
+			}
+		}
+		// Every column of the primary key must be a segment or order
+		// column for upserts into compressed chunks. TimescaleDB before
+		// 2.14 refuses to change the segments while chunks are compressed,
+		// and the error is reported until they are decompressed.
+		if !enabled || !slices.Equal(segmentBy, candleSegmentBy) {
+			if _, err := s.db.ExecContext(ctx, `
+        ALTER TABLE candlesticks SET (
+            timescaledb.compress,
+            timescaledb.compress_segmentby = 'exchange, symbol, "interval"',
+            timescaledb.compress_orderby = 'start_time DESC')`); err != nil {
+				return fmt.Errorf("set compression segments: %w", err)
+			}
+		}
+	}
+	if err := s.setPolicy(ctx, "compression", "compress_after", policy.CompressAfter); err != nil {
+		return err
+	}
+	if err := s.setPolicy(ctx, "retention", "drop_after", policy.DropAfter()); err != nil {
+		return err
+	}
+
+	log.Printf("Retention policy applied: chunk interval %s, compress after %s, drop chunks after %s",
+		formatAge(policy.ChunkInterval), formatAge(policy.CompressAfter), formatAge(policy.DropAfter()))
+	return nil
+}
+
+// candleSegmentBy are the columns compressed chunks of candlesticks are
+// segmented by.
+var candleSegmentBy = []string{"exchange", "symbol", "interval"}
+
+// compressSegmentBy reads the columns compressed chunks of candlesticks are
+// segmented by.
+func (s *PostgresStorage) compressSegmentBy(ctx context.Context) ([]string, error) {
+	var columns string
+	err := s.db.GetContext(ctx, &columns, `
+        SELECT COALESCE(segmentby, '') FROM timescaledb_information.hypertable_compression_settings
+        WHERE hypertable = 'candlesticks'::regclass`)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P01" {
+		// hypertable_compression_settings arrived in TimescaleDB 2.14.
+		err = s.db.GetContext(ctx, &columns, `
+        SELECT COALESCE(string_agg(attname, ',' ORDER BY segmentby_column_index), '')
+        FROM timescaledb_information.compression_settings
+        WHERE hypertable_name = 'candlesticks' AND segmentby_column_index IS NOT NULL`)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("read compression segments: %w", err)
+	}
+
+	var segmentBy []string
+	for _, column := range strings.Split(columns, ",") {
+		if column = strings.Trim(column, `" `); column != "" {
+			segmentBy = append(segmentBy, column)
+		}
+	}
+	return segmentBy, nil
+}
+
+// setPolicy replaces the compression or retention policy of candlesticks if
+// it does not act after age, and removes it if age is zero.
+func (s *PostgresStorage) setPolicy(ctx context.Context, kind, setting string, age time.Duration) error {
+	if age > 0 {
+		var current bool
+		if err := s.db.GetContext(ctx, &current, `
+        SELECT EXISTS (
+            SELECT 1 FROM timescaledb_information.jobs
+            WHERE hypertable_name = 'candlesticks' AND proc_name = $1
+              AND (config->>$2)::interval = $3::interval)`,
+			"policy_"+kind, setting, pgInterval(age)); err != nil {
+			return fmt.Errorf("read %s policy: %w", kind, err)
+		}
+		if current {
+			return nil
+		}
+	}
+
+	if _, err := s.db.ExecContext(ctx,
+		fmt.Sprintf(`SELECT remove_%s_policy('candlesticks', if_exists => true)`, kind)); err != nil {
+		return fmt.Errorf("remove %s policy: %w", kind, err)
+	}
+	if age > 0 {
+		if _, err := s.db.ExecContext(ctx,
+			fmt.Sprintf(`SELECT add_%s_policy('candlesticks', $1::interval)`, kind), pgInterval(age)); err != nil {
+			return fmt.Errorf("add %s policy: %w", kind, err)
+		}
+	}
+	return nil
+}
+
+// expire deletes the bars of each interval older than it is kept for.
+func (s *PostgresStorage) expire(ctx context.Context, policy RetentionPolicy) error {
+	now := time.Now().UTC()
+	intervals := make([]string, 0, len(policy.Keep))
+	for interval := range policy.Keep {
+		intervals = append(intervals, interval)
+	}
+	slices.Sort(intervals)
+
+	for _, interval := range intervals {
+		if policy.Keep[interval] <= 0 {
+			continue
+		}
+		deleted, err := s.deleteExpired(ctx, `"interval" = $1 AND start_time < $2`,
+			interval, now.Add(-policy.Keep[interval]))
+		if err != nil {
+			return fmt.Errorf("expire %s bars: %w", interval, err)
+		}
+		s.recordExpiry(interval, deleted, now)
+	}
+	if policy.Default > 0 {
+		deleted, err := s.deleteExpired(ctx, `"interval" <> ALL($1) AND start_time < $2`,
+			pq.Array(intervals), now.Add(-policy.Default))
+		if err != nil {
+			return fmt.Errorf("expire bars: %w", err)
+		}
+		s.recordExpiry(AnyInterval, deleted, now)
+	}
+	return nil
+}
+
+// deleteExpired deletes the bars matching cond. Compressed chunks holding
+// any of them are decompressed first, in the same transaction, rather than
+// leaving TimescaleDB to decompress each affected segment, which versions
+// before 2.11 cannot do at all. Only chunks with expired bars are touched,
+// typically the one the cutoff moved into since the last run, and the
+// compression policy compresses them again on its next run.
+func (s *PostgresStorage) deleteExpired(ctx context.Context, cond string, args ...interface{}) (int64, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	var chunks []string
+	if err := tx.SelectContext(ctx, &chunks, `
+        SELECT format('%I.%I', ch.chunk_schema, ch.chunk_name)
+        FROM timescaledb_information.chunks ch
+        WHERE ch.hypertable_name = 'candlesticks' AND ch.is_compressed
+          AND EXISTS (
+            SELECT 1 FROM candlesticks
+            WHERE `+cond+` AND start_time >= ch.range_start AND start_time < ch.range_end)`, args...); err != nil {
+		return 0, fmt.Errorf("find compressed chunks: %w", err)
+	}
+	for _, chunk := range chunks {
+		if _, err := tx.ExecContext(ctx, `SELECT decompress_chunk($1::regclass, if_compressed => true)`, chunk); err != nil {
+			return 0, fmt.Errorf("decompress %s: %w", chunk, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM candlesticks WHERE `+cond, args...)
+	if err != nil {
+		return 0, err
+	}
+	deleted, _ := res.RowsAffected()
+	return deleted, tx.Commit()
+}
+
+func (s *PostgresStorage) recordExpiry(interval string, deleted int64, at time.Time) {
+	if deleted > 0 {
+		log.Printf("Retention deleted %d expired %s bars", deleted, interval)
+	}
+	s.retentionMu.Lock()
+	defer s.retentionMu.Unlock()
+	s.retention.runs[interval] = IntervalRetention{Interval: interval, Deleted: deleted, LastRun: at}
+}
+
+// StorageStatus reads the layout, size and policies of the candlesticks
+// hypertable.
+func (s *PostgresStorage) StorageStatus(ctx context.Context) (StorageStatus, error) {
+	var st StorageStatus
+	var chunkSeconds float64
+	if err := s.db.GetContext(ctx, &chunkSeconds, `
+        SELECT COALESCE(MAX(EXTRACT(EPOCH FROM time_interval)), 0) FROM timescaledb_information.dimensions
+        WHERE hypertable_name = 'candlesticks' AND column_name = 'start_time'`); err != nil {
+		return st, fmt.Errorf("read chunk interval: %w", err)
+	}
+	st.ChunkInterval = time.Duration(chunkSeconds * float64(time.Second))
+
+	if err := s.db.QueryRowxContext(ctx, `
+        SELECT COUNT(*), COUNT(*) FILTER (WHERE is_compressed) FROM timescaledb_information.chunks
+        WHERE hypertable_name = 'candlesticks'`).Scan(&st.Chunks, &st.CompressedChunks); err != nil {
+		return st, fmt.Errorf("count chunks: %w", err)
+	}
+	if err := s.db.GetContext(ctx, &st.CompressionEnabled, `
+        SELECT compression_enabled FROM timescaledb_information.hypertables
+        WHERE hypertable_name = 'candlesticks'`); err != nil {
+		return st, fmt.Errorf("read compression settings: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT proc_name, EXTRACT(EPOCH FROM COALESCE((config->>'compress_after')::interval, (config->>'drop_after')::interval))
+        FROM timescaledb_information.jobs
+        WHERE hypertable_name = 'candlesticks' AND proc_name IN ('policy_compression', 'policy_retention')`)
+	if err != nil {
+		return st, fmt.Errorf("read policies: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var proc string
+		var seconds float64
+		if err := rows.Scan(&proc, &seconds); err != nil {
+			return st, fmt.Errorf("read policies: %w", err)
+		}
+		age := time.Duration(seconds * float64(time.Second))
+		if proc == "policy_compression" {
+			st.CompressAfter = age
+		} else {
+			st.DropAfter = age
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return st, fmt.Errorf("read policies: %w", err)
+	}
+
+	if err := s.db.QueryRowxContext(ctx, `
+        SELECT hypertable_size('candlesticks'),
+               COALESCE(SUM(before_compression_total_bytes), 0)::bigint,
+               COALESCE(SUM(after_compression_total_bytes), 0)::bigint
+        FROM hypertable_compression_stats('candlesticks')`).Scan(
+		&st.TotalBytes, &st.BeforeCompressionBytes, &st.AfterCompressionBytes); err != nil {
+		return st, fmt.Errorf("read sizes: %w", err)
+	}
+
+	s.retentionMu.Lock()
+	var policy RetentionPolicy
+	runs := make(map[string]IntervalRetention)
+	if s.retention != nil {
+		policy = s.retention.policy
+		for k, v := range s.retention.runs {
+			runs[k] = v
+		}
+		st.ReconciledAt, st.LastError = s.retention.reconciledAt, s.retention.lastError
+	}
+	s.retentionMu.Unlock()
+
+	intervals := make([]string, 0, len(policy.Keep))
+	for interval := range policy.Keep {
+		intervals = append(intervals, interval)
+	}
+	slices.Sort(intervals)
+	for _, interval := range intervals {
+		r := runs[interval]
+		r.Interval, r.Keep = interval, policy.Keep[interval]
+		var oldest sql.NullTime
+		if err := s.db.GetContext(ctx, &oldest,
+			`SELECT MIN(start_time) FROM candlesticks WHERE "interval" = $1`, interval); err != nil {
+			return st, fmt.Errorf("find oldest %s bar: %w", interval, err)
+		}
+		r.Oldest = oldest.Time
+		st.Retention = append(st.Retention, r)
+	}
+	if policy.Default > 0 {
+		r := runs[AnyInterval]
+		r.Interval, r.Keep = AnyInterval, policy.Default
+		st.Retention = append(st.Retention, r)
+	}
+	return st, nil
+}
+
+// pgInterval formats d as a Postgres interval.
+func pgInterval(d time.Duration) string {
+	return fmt.Sprintf("%d microseconds", d.Microseconds())
+}
+
+func formatAge(d time.Duration) string {
+	if d <= 0 {
+		return "never"
+	}
+	return aggregator.FormatInterval(d)
+}
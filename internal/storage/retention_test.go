@@ -0,0 +1,270 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+	"github.com/shubie/trading/internal/storage"
+)
+
+const day = 24 * time.Hour
+
+func TestParseRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		name                        string
+		chunk, compress, defaultAge string
+		keep                        map[string]string
+		want                        storage.RetentionPolicy
+		wantErr                     bool
+	}{
+		{
+			name:  "days",
+			chunk: "1d", compress: "7d", defaultAge: "30d",
+			keep: map[string]string{"1s": "7d", "1m": "730d"},
+			want: storage.RetentionPolicy{
+				ChunkInterval: day,
+				CompressAfter: 7 * day,
+				Default:       30 * day,
+				Keep:          map[string]time.Duration{"1s": 7 * day, "1m": 730 * day},
+			},
+		},
+		{
+			name: "labels are normalized",
+			keep: map[string]string{"60m": "90d", "60s": "7d", "1440m": "730d"},
+			want: storage.RetentionPolicy{
+				Keep: map[string]time.Duration{"1h": 90 * day, "1m": 7 * day, "1d": 730 * day},
+			},
+		},
+		{
+			name: "empty ages keep forever",
+			keep: map[string]string{"1m": ""},
+			want: storage.RetentionPolicy{Keep: map[string]time.Duration{"1m": 0}},
+		},
+		{name: "same interval twice", keep: map[string]string{"1h": "7d", "60m": "30d"}, wantErr: true},
+		{name: "bad keep", keep: map[string]string{"1m": "7x"}, wantErr: true},
+		{name: "bad default", defaultAge: "-1d", wantErr: true},
+		{name: "zero compress after", compress: "0", wantErr: true},
+		{name: "bad chunk interval", chunk: "1.5d", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := storage.ParseRetentionPolicy(tt.chunk, tt.compress, tt.defaultAge, tt.keep)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRetentionPolicy = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRetentionPolicy: %v", err)
+			}
+			if got.ChunkInterval != tt.want.ChunkInterval || got.CompressAfter != tt.want.CompressAfter ||
+				got.Default != tt.want.Default || !maps.Equal(got.Keep, tt.want.Keep) {
+				t.Errorf("ParseRetentionPolicy:\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetentionPolicy_DropAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy storage.RetentionPolicy
+		want   time.Duration
+	}{
+		{"nothing expires", storage.RetentionPolicy{}, 0},
+		{"only per interval", storage.RetentionPolicy{Keep: map[string]time.Duration{"1s": 7 * day}}, 0},
+		{"default only", storage.RetentionPolicy{Default: 30 * day}, 30 * day},
+		{
+			"shorter keeps",
+			storage.RetentionPolicy{Default: 30 * day, Keep: map[string]time.Duration{"1s": 7 * day}},
+			30 * day,
+		},
+		{
+			// Dropping at 30 days would remove 1m bars kept for two years.
+			"a longer keep",
+			storage.RetentionPolicy{Default: 30 * day, Keep: map[string]time.Duration{"1s": 7 * day, "1m": 730 * day}},
+			730 * day,
+		},
+		{
+			"a keep forever",
+			storage.RetentionPolicy{Default: 30 * day, Keep: map[string]time.Duration{"1s": 7 * day, "1d": 0}},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.DropAfter(); got != tt.want {
+				t.Errorf("DropAfter = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPGInterval(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{time.Second, "1000000 microseconds"},
+		{90 * time.Minute, "5400000000 microseconds"},
+		{7 * day, "604800000000 microseconds"},
+		{730 * day, "63072000000000 microseconds"},
+	}
+	for _, tt := range tests {
+		if got := storage.PGInterval(tt.in); got != tt.want {
+			t.Errorf("PGInterval(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestPostgresRetention applies a policy to the hypertable in
+// TEST_POSTGRES_DSN and checks which bars expire, including bars in
+// compressed chunks. It deletes old bars of every series in that database.
+func TestPostgresRetention(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	s, err := storage.NewPostgresStorage(dsn)
+	if err != nil {
+		t.Fatalf("NewPostgresStorage: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	before, err := s.StorageStatus(context.Background())
+	if err != nil {
+		t.Fatalf("StorageStatus: %v", err)
+	}
+	t.Cleanup(func() {
+		// Leave the policies and chunks as they were for other tests.
+		retain(t, s, storage.RetentionPolicy{ChunkInterval: before.ChunkInterval})
+		if _, err := db.Exec(`SELECT decompress_chunk(c, if_compressed => true) FROM show_chunks('candlesticks') c`); err != nil {
+			t.Errorf("decompress chunks: %v", err)
+		}
+	})
+
+	// Enable compression first, so the bars can be compressed before
+	// they expire.
+	retain(t, s, storage.RetentionPolicy{CompressAfter: 3 * day})
+
+	inst := source.Instrument{Exchange: fmt.Sprintf("retention-%d", time.Now().UnixNano()), Symbol: "BTCUSDT"}
+	now := time.Now().UTC().Truncate(time.Hour)
+	bars := []aggregator.Candle{
+		retentionBar(inst, "1s", now.Add(-8*day)),  // past its 7d
+		retentionBar(inst, "1s", now.Add(-6*day)),  // kept
+		retentionBar(inst, "1m", now.Add(-40*day)), // kept for 730d, past the default
+		retentionBar(inst, "5m", now.Add(-40*day)), // past the default
+		retentionBar(inst, "5m", now.Add(-20*day)), // kept
+	}
+	if err := s.WriteCandles(context.Background(), bars, storage.MergeRevision); err != nil {
+		t.Fatalf("WriteCandles: %v", err)
+	}
+	if _, err := db.Exec(`
+        SELECT compress_chunk(c, if_not_compressed => true)
+        FROM show_chunks('candlesticks', older_than => interval '5 days') c`); err != nil {
+		t.Fatalf("compress chunks: %v", err)
+	}
+
+	policy := storage.RetentionPolicy{
+		ChunkInterval: day,
+		CompressAfter: 3 * day,
+		Default:       30 * day,
+		Keep:          map[string]time.Duration{"1s": 7 * day, "1m": 730 * day},
+	}
+	st := retain(t, s, policy)
+
+	if st.ChunkInterval != day || !st.CompressionEnabled || st.CompressAfter != 3*day || st.DropAfter != 730*day {
+		t.Errorf("Status after reconcile: chunk interval %s, compression %t after %s, drop after %s",
+			st.ChunkInterval, st.CompressionEnabled, st.CompressAfter, st.DropAfter)
+	}
+	labels := make([]string, len(st.Retention))
+	for i, r := range st.Retention {
+		labels[i] = r.Interval
+	}
+	if fmt.Sprint(labels) != fmt.Sprint([]string{"1m", "1s", storage.AnyInterval}) {
+		t.Errorf("Retention reported for %v", labels)
+	}
+
+	want := map[string]int{"1s": 1, "1m": 1, "5m": 1}
+	for interval, n := range want {
+		got, err := s.QueryCandles(context.Background(), storage.CandleQuery{
+			Exchange: inst.Exchange, Symbol: inst.Symbol, Interval: interval,
+			From: now.Add(-100 * day), To: now, Limit: 10,
+		})
+		if err != nil {
+			t.Fatalf("QueryCandles: %v", err)
+		}
+		if len(got) != n {
+			t.Errorf("Kept %d %s bars, want %d", len(got), interval, n)
+		}
+	}
+
+	// Reconciling again with the policy in place changes nothing.
+	if again := retain(t, s, policy); again.CompressAfter != st.CompressAfter || again.DropAfter != st.DropAfter {
+		t.Errorf("Second reconcile changed the policies to compress after %s, drop after %s", again.CompressAfter, again.DropAfter)
+	}
+}
+
+func retentionBar(inst source.Instrument, interval string, start time.Time) aggregator.Candle {
+	price := decimal.NewFromInt(100)
+	d, _ := aggregator.ParseInterval(interval)
+	return aggregator.Candle{
+		Exchange: inst.Exchange, Symbol: inst.Symbol, Interval: interval, BarType: aggregator.BarTime,
+		Open: price, High: price, Low: price, Close: price, Volume: decimal.NewFromInt(1),
+		VWAP: price, QuoteVolume: price, TradeCount: 1,
+		StartTime: start, EndTime: start.Add(d), Finalized: true,
+	}
+}
+
+// retain runs retention with policy once and returns the status after it.
+func retain(t *testing.T, s *storage.PostgresStorage, policy storage.RetentionPolicy) storage.StorageStatus {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.StartRetention(ctx, policy)
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		st, err := s.StorageStatus(context.Background())
+		if err != nil {
+			t.Fatalf("StorageStatus: %v", err)
+		}
+		if st.LastError != "" {
+			t.Fatalf("Retention: %s", st.LastError)
+		}
+		if ran(st) {
+			return st
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("Retention did not run within 30s")
+	return storage.StorageStatus{}
+}
+
+// ran reports whether st shows a reconcile and an expiry run of every
+// interval that expires.
+func ran(st storage.StorageStatus) bool {
+	if st.ReconciledAt.IsZero() {
+		return false
+	}
+	for _, r := range st.Retention {
+		if r.Keep > 0 && r.LastRun.IsZero() {
+			return false
+		}
+	}
+	return true
+}
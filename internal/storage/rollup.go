@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+)
+
+const (
+	// rollupSource is the interval higher timeframes are derived from.
+	rollupSource = time.Minute
+
+	rollupEvery = time.Minute
+
+	// rollupDelay is how long after a bucket ends it is rolled up, so the
+	// 1m bars it covers have been finalized and persisted.
+	rollupDelay = 2 * time.Minute
+
+	// rollupSpan bounds how much 1m history is read per query while
+	// catching up.
+	rollupSpan = 24 * time.Hour
+
+	// upsertBatchSize keeps a multi-row insert under the bind parameter
+	// limit of Postgres.
+	upsertBatchSize = 1000
+
+	// vwapPlaces matches the rounding of VWAP in the aggregator.
+	vwapPlaces = 18
+)
+
+// ValidateRollupInterval reports whether interval can be rolled up from 1m
+// bars.
+func ValidateRollupInterval(interval time.Duration) error {
+	if interval <= rollupSource || interval%rollupSource != 0 {
+		return fmt.Errorf("rollup interval %s is not a multiple of %s", aggregator.FormatInterval(interval), aggregator.FormatInterval(rollupSource))
+	}
+	return nil
+}
+
+// Rollup combines the bars of one instrument, oldest first, into bars of the
+// given interval. A rolled up bar is synthetic only if every bar in it is.
+func Rollup(bars []aggregator.Candle, interval time.Duration) []aggregator.Candle {
+	label := aggregator.FormatInterval(interval)
+
+	var out []aggregator.Candle
+	for _, bar := range bars {
+		start := bar.StartTime.Truncate(interval)
+		if n := len(out); n > 0 && out[n-1].StartTime.Equal(start) {
+			merge(&out[n-1], bar)
+			continue
+		}
+		rolled := bar
+		rolled.Interval = label
+		rolled.StartTime = start
+		rolled.EndTime = start.Add(interval)
+		rolled.Finalized = true
+		rolled.Revision = 0
+		out = append(out, rolled)
+	}
+	return out
+}
+
+func merge(c *aggregator.Candle, bar aggregator.Candle) {
+	if bar.Synthetic && !c.Synthetic {
+		// A flat fill carries the previous close forward; it moves no
+		// price of a bar that traded.
+		return
+	}
+	if c.Synthetic && !bar.Synthetic {
+		// The first trade of the bucket opens it, as it would live.
+		label, start, end := c.Interval, c.StartTime, c.EndTime
+		*c = bar
+		c.Interval, c.StartTime, c.EndTime = label, start, end
+		c.Finalized = true
+		return
+	}
+
+	if bar.High.GreaterThan(c.High) {
+		c.High = bar.High
+	}
+	if bar.Low.LessThan(c.Low) {
+		c.Low = bar.Low
+	}
+	c.Close = bar.Close
+	c.Volume = c.Volume.Add(bar.Volume)
+	c.QuoteVolume = c.QuoteVolume.Add(bar.QuoteVolume)
+	c.TradeCount += bar.TradeCount
+	c.TakerBuyVolume = c.TakerBuyVolume.Add(bar.TakerBuyVolume)
+	c.TakerSellVolume = c.TakerSellVolume.Add(bar.TakerSellVolume)
+	if c.Volume.IsPositive() {
+		c.VWAP = c.QuoteVolume.DivRound(c.Volume, vwapPlaces)
+	}
+}
+
+// StartRollup periodically derives bars of the given intervals from the 1m
+// bars in storage and upserts them, so GetCandles serves them like any
+// other interval. Each run rolls up again every bucket holding 1m bars
+// written since the previous run, so late corrections, spool replays and
+// the history of symbols added later are all folded in.
+func (s *PostgresStorage) StartRollup(ctx context.Context, intervals []time.Duration) {
+	if len(intervals) == 0 {
+		return
+	}
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		log.Printf("Rollup worker started for %d intervals", len(intervals))
+		states := make(map[time.Duration]*rollupState, len(intervals))
+		ticker := time.NewTicker(rollupEvery)
+		defer ticker.Stop()
+
+		for {
+			for _, interval := range intervals {
+				if states[interval] == nil {
+					states[interval] = &rollupState{pending: make(map[source.Instrument]time.Time)}
+				}
+				if err := s.rollupInterval(ctx, interval, states[interval]); err != nil && ctx.Err() == nil {
+					log.Printf("Rollup %s: %v", aggregator.FormatInterval(interval), err)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				log.Println("Rollup worker stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// rollupState is how far rolling up one interval has got.
+type rollupState struct {
+	// checked is when the 1m bars were last looked at; bars written
+	// after it may change their buckets.
+	checked time.Time
+	started bool
+	// pending is the first bucket of each instrument that still has to be
+	// rolled up once it is complete.
+	pending map[source.Instrument]time.Time
+}
+
+// rollupInterval rolls up, for each instrument, the complete buckets holding
+// 1m bars written since the last run, and those left pending by it.
+func (s *PostgresStorage) rollupInterval(ctx context.Context, interval time.Duration, st *rollupState) error {
+	if !st.started {
+		checked, err := s.rollupStart(ctx, interval)
+		if err != nil {
+			return err
+		}
+		st.checked, st.started = checked, true
+	}
+
+	// Bars committed shortly after they were stamped are seen by the next
+	// run; rolling a bucket twice changes nothing.
+	runAt := time.Now().UTC()
+	var written []struct {
+		Exchange string    `db:"exchange"`
+		Symbol   string    `db:"symbol"`
+		Oldest   time.Time `db:"oldest"`
+		Newest   time.Time `db:"newest"`
+	}
+	if err := s.db.SelectContext(ctx, &written, `
+        SELECT exchange, symbol, MIN(start_time) AS oldest, MAX(start_time) AS newest
+        FROM candlesticks
+        WHERE "interval" = $1 AND updated_at > $2
+        GROUP BY exchange, symbol`, aggregator.FormatInterval(rollupSource), st.checked); err != nil {
+		return fmt.Errorf("find written 1m bars: %w", err)
+	}
+
+	spans := make(map[source.Instrument][2]time.Time, len(written)+len(st.pending))
+	for inst, from := range st.pending {
+		spans[inst] = [2]time.Time{from, from.Add(interval)}
+	}
+	for _, w := range written {
+		inst := source.Instrument{Exchange: w.Exchange, Symbol: w.Symbol}
+		from, through := w.Oldest.Truncate(interval), w.Newest.Truncate(interval).Add(interval)
+		if span, ok := spans[inst]; ok {
+			from, through = minTime(from, span[0]), maxTime(through, span[1])
+		}
+		spans[inst] = [2]time.Time{from, through}
+	}
+
+	to := time.Now().Add(-rollupDelay).Truncate(interval)
+	step := interval * ((rollupSpan + interval - 1) / interval)
+	for inst, span := range spans {
+		from, through := span[0], span[1]
+		end := minTime(through, to)
+		for from.Before(end) {
+			next := minTime(from.Add(step), end)
+			if err := s.rollupRange(ctx, inst, interval, from, next); err != nil {
+				st.pending[inst] = from
+				return err
+			}
+			from = next
+		}
+		if through.After(from) {
+			st.pending[inst] = from
+		} else {
+			delete(st.pending, inst)
+		}
+	}
+	st.checked = runAt.Add(-rollupEvery)
+	return nil
+}
+
+// rollupStart returns when rolled up bars of an interval were last written,
+// less enough that the buckets still incomplete then are rolled up again,
+// or the zero time to roll up every 1m bar.
+func (s *PostgresStorage) rollupStart(ctx context.Context, interval time.Duration) (time.Time, error) {
+	var newest sql.NullTime
+	if err := s.db.GetContext(ctx, &newest,
+		`SELECT MAX(updated_at) FROM candlesticks WHERE "interval" = $1`, aggregator.FormatInterval(interval)); err != nil {
+		return time.Time{}, fmt.Errorf("find newest rollup: %w", err)
+	}
+	if !newest.Valid {
+		return time.Time{}, nil
+	}
+	return newest.Time.Add(-(interval + rollupDelay + rollupEvery)), nil
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// rollupRange rolls up the 1m bars of inst with start_time in [from, to),
+// which must be bucket aligned.
+func (s *PostgresStorage) rollupRange(ctx context.Context, inst source.Instrument, interval time.Duration, from, to time.Time) error {
+	const query = `
+        SELECT exchange, symbol, "interval", bar_type, open, high, low, close, volume,
+               vwap, quote_volume, trade_count, taker_buy_volume, taker_sell_volume, synthetic, start_time, end_time
+        FROM candlesticks
+        WHERE exchange = $1 AND symbol = $2 AND "interval" = $3 AND start_time >= $4 AND start_time < $5
+        ORDER BY start_time`
+
+	bars := []aggregator.Candle{}
+	if err := s.db.SelectContext(ctx, &bars, query,
+		inst.Exchange, inst.Symbol, aggregator.FormatInterval(rollupSource), from, to); err != nil {
+		return fmt.Errorf("query 1m bars: %w", err)
+	}
+	return s.upsert(ctx, Rollup(bars, interval))
+}
+
+// upsertRollups writes rolled up bars over stored ones, counting a revision
+// only when a bar actually changed, as buckets are redone whenever any of
+// their 1m bars is written.
+const upsertRollups = insertCandles + `
+        ON CONFLICT (exchange, symbol, "interval", start_time) DO UPDATE SET` + setCandleColumns + `,
+            revision = candlesticks.revision + 1, updated_at = EXCLUDED.updated_at
+        WHERE` + candleChanged
+
+// upsert writes rolled up bars over any stored ones with the same key.
+func (s *PostgresStorage) upsert(ctx context.Context, candles []aggregator.Candle) error {
+	now := time.Now().UTC()
+	for i := range candles {
+		candles[i].UpdatedAt = now
+	}
+	for len(candles) > 0 {
+		n := min(len(candles), upsertBatchSize)
+		if _, err := s.db.NamedExecContext(ctx, upsertRollups, candles[:n]); err != nil {
+			return fmt.Errorf("upsert candles: %w", err)
+		}
+		candles = candles[n:]
+	}
+	return nil
+}
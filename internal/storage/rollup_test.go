@@ -0,0 +1,129 @@
+package storage_test
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+	"github.com/shubie/trading/internal/storage"
+)
+
+// aggregate runs ticks through an aggregator and returns its finalized
+// candles by interval label.
+func aggregate(t *testing.T, ticks []source.Tick, opts ...aggregator.Option) map[string][]aggregator.Candle {
+	t.Helper()
+	tickChan := make(chan source.Tick)
+	candleChan := make(chan aggregator.Candle)
+
+	opts = append(opts, aggregator.WithAllowedLateness(0), aggregator.WithIdleTimeout(0))
+	agg := aggregator.NewAggregator(opts...)
+	go agg.Run(context.Background(), tickChan, candleChan)
+	go func() {
+		for _, tick := range ticks {
+			tickChan <- tick
+		}
+		close(tickChan)
+	}()
+
+	got := make(map[string][]aggregator.Candle)
+	for c := range candleChan {
+		got[c.Interval] = append(got[c.Interval], c)
+	}
+	for _, candles := range got {
+		sort.Slice(candles, func(i, j int) bool {
+			return candles[i].StartTime.Before(candles[j].StartTime)
+		})
+	}
+	return got
+}
+
+func randomTicks(base time.Time, span time.Duration, n int) []source.Tick {
+	rng := rand.New(rand.NewSource(1))
+	ticks := make([]source.Tick, 0, n)
+	offsets := make([]time.Duration, n)
+	for i := range offsets {
+		offsets[i] = time.Duration(rng.Int63n(int64(span)))
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	for _, offset := range offsets {
+		side := source.SideBuy
+		if rng.Intn(2) == 0 {
+			side = source.SideSell
+		}
+		ticks = append(ticks, source.Tick{
+			Exchange:  "binance",
+			Symbol:    "BTCUSDT",
+			Price:     decimal.New(rng.Int63n(1000000)+1, -2),
+			Quantity:  decimal.New(rng.Int63n(100000)+1, -5),
+			Timestamp: base.Add(offset),
+			Side:      side,
+			Trades:    rng.Int63n(3) + 1,
+		})
+	}
+	return ticks
+}
+
+func assertSameCandles(t *testing.T, label string, got, want []aggregator.Candle) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: rolled up %d candles, aggregated %d", label, len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		same := g.Interval == w.Interval && g.StartTime.Equal(w.StartTime) && g.EndTime.Equal(w.EndTime) &&
+			g.Open.Equal(w.Open) && g.High.Equal(w.High) && g.Low.Equal(w.Low) && g.Close.Equal(w.Close) &&
+			g.Volume.Equal(w.Volume) && g.QuoteVolume.Equal(w.QuoteVolume) && g.VWAP.Equal(w.VWAP) &&
+			g.TradeCount == w.TradeCount && g.TakerBuyVolume.Equal(w.TakerBuyVolume) &&
+			g.TakerSellVolume.Equal(w.TakerSellVolume) && g.Synthetic == w.Synthetic
+		if !same {
+			t.Errorf("%s candle %d:\nrolled up  %+v\naggregated %+v", label, i, g, w)
+		}
+	}
+}
+
+func TestRollup_MatchesDirectAggregation(t *testing.T) {
+	base := time.Now().Add(-72 * time.Hour).Truncate(24 * time.Hour)
+	ticks := randomTicks(base, 48*time.Hour, 5000)
+
+	direct := aggregate(t, ticks, aggregator.WithIntervals(time.Minute, 5*time.Minute, time.Hour, 24*time.Hour))
+	for _, interval := range []time.Duration{5 * time.Minute, time.Hour, 24 * time.Hour} {
+		label := aggregator.FormatInterval(interval)
+		assertSameCandles(t, label, storage.Rollup(direct["1m"], interval), direct[label])
+	}
+}
+
+func TestRollup_GapFilledBars(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Hour)
+	tick := func(offset time.Duration, price int64) source.Tick {
+		return source.Tick{Exchange: "binance", Symbol: "BTCUSDT", Price: decimal.NewFromInt(price), Quantity: decimal.NewFromInt(1), Timestamp: base.Add(offset)}
+	}
+	// The second bucket trades only in its third minute; the third not at
+	// all.
+	ticks := []source.Tick{tick(0, 100), tick(7*time.Minute, 90), tick(7*time.Minute+time.Second, 95), tick(16*time.Minute, 120)}
+
+	direct := aggregate(t, ticks, aggregator.WithIntervals(time.Minute, 5*time.Minute), aggregator.WithGapFill("BTCUSDT"))
+	rolled := storage.Rollup(direct["1m"], 5*time.Minute)
+	assertSameCandles(t, "5m", rolled, direct["5m"])
+	if len(rolled) != 4 || !rolled[2].Synthetic || !rolled[2].Open.Equal(decimal.NewFromInt(95)) {
+		t.Errorf("Expected the empty bucket to roll up to a flat candle at 95, got %+v", rolled)
+	}
+}
+
+func TestValidateRollupInterval(t *testing.T) {
+	for _, interval := range []time.Duration{5 * time.Minute, time.Hour, 24 * time.Hour} {
+		if err := storage.ValidateRollupInterval(interval); err != nil {
+			t.Errorf("%s: unexpected error %v", interval, err)
+		}
+	}
+	for _, interval := range []time.Duration{30 * time.Second, time.Minute, 90 * time.Second} {
+		if err := storage.ValidateRollupInterval(interval); err == nil {
+			t.Errorf("%s: expected an error", interval)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/shubie/trading/internal/aggregator"
+)
+
+// Each spooled batch is one line: a state byte followed by the JSON record.
+// Appending writes the line with statePending; once the batch reaches the
+// database its state byte is overwritten in place with stateDone, so a
+// restart resumes after the last replayed batch.
+const (
+	statePending = 'P'
+	stateDone    = 'D'
+)
+
+var errSpoolClosed = errors.New("spool is closed")
+
+type spoolRecord struct {
+	SpooledAt time.Time           `json:"spooled_at"`
+	Candles   []aggregator.Candle `json:"candles"`
+}
+
+type spoolEntry struct {
+	offset    int64
+	size      int64
+	spooledAt time.Time
+	candles   int
+}
+
+// SpoolStats describes the batches waiting in a spool.
+type SpoolStats struct {
+	Batches int
+	Candles int
+	// Oldest is when the oldest waiting batch was spooled; zero if the
+	// spool is empty.
+	Oldest time.Time
+}
+
+// Spool is an append-only file of candle batches that could not be written
+// to the database, kept in the order they were spooled. The file is
+// truncated whenever the last batch has been replayed.
+type Spool struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	end     int64
+	pending []spoolEntry
+	closed  bool
+}
+
+// OpenSpool opens the spool at path, creating it if needed, and loads the
+// batches a previous run left pending. A torn last line from a crash while
+// appending is cut off.
+func OpenSpool(path string) (*Spool, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create spool directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open spool: %w", err)
+	}
+
+	s := &Spool{path: path, f: f}
+	if err := s.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if len(s.pending) > 0 {
+		log.Printf("Spool %s has %d pending batches", path, len(s.pending))
+	}
+	return s, nil
+}
+
+func (s *Spool) load() error {
+	r := bufio.NewReader(s.f)
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				log.Printf("Spool %s: discarding %d bytes of a torn write", s.path, len(line))
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read spool: %w", err)
+		}
+
+		var rec spoolRecord
+		if (line[0] != statePending && line[0] != stateDone) || json.Unmarshal(line[1:], &rec) != nil {
+			log.Printf("Spool %s: discarding corrupt record at offset %d and everything after it", s.path, offset)
+			break
+		}
+		if line[0] == statePending {
+			s.pending = append(s.pending, spoolEntry{
+				offset:    offset,
+				size:      int64(len(line)),
+				spooledAt: rec.SpooledAt,
+				candles:   len(rec.Candles),
+			})
+		}
+		offset += int64(len(line))
+	}
+
+	s.end = offset
+	if len(s.pending) == 0 {
+		s.end = 0
+	}
+	if err := s.f.Truncate(s.end); err != nil {
+		return fmt.Errorf("truncate spool: %w", err)
+	}
+	return nil
+}
+
+// Append durably adds a batch to the end of the spool.
+func (s *Spool) Append(candles []aggregator.Candle) error {
+	data, err := json.Marshal(spoolRecord{SpooledAt: time.Now(), Candles: candles})
+	if err != nil {
+		return fmt.Errorf("encode spool record: %w", err)
+	}
+	line := make([]byte, 0, len(data)+2)
+	line = append(line, statePending)
+	line = append(line, data...)
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errSpoolClosed
+	}
+	if _, err := s.f.WriteAt(line, s.end); err != nil {
+		return fmt.Errorf("write spool: %w", err)
+	}
+	if err := s.f.Sync(); err != nil {
+		return fmt.Errorf("sync spool: %w", err)
+	}
+	s.pending = append(s.pending, spoolEntry{
+		offset:    s.end,
+		size:      int64(len(line)),
+		spooledAt: time.Now(),
+		candles:   len(candles),
+	})
+	s.end += int64(len(line))
+	return nil
+}
+
+// Peek returns the oldest pending batch, or false if there is none.
+func (s *Spool) Peek() ([]aggregator.Candle, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, false, errSpoolClosed
+	}
+	if len(s.pending) == 0 {
+		return nil, false, nil
+	}
+
+	e := s.pending[0]
+	line := make([]byte, e.size)
+	if _, err := s.f.ReadAt(line, e.offset); err != nil {
+		return nil, false, fmt.Errorf("read spool: %w", err)
+	}
+	var rec spoolRecord
+	if err := json.Unmarshal(line[1:], &rec); err != nil {
+		return nil, false, fmt.Errorf("decode spool record: %w", err)
+	}
+	return rec.Candles, true, nil
+}
+
+// Pop marks the oldest pending batch as replayed, and syncs the mark to
+// disk before returning, so a batch written once is not replayed after a
+// crash; in MergeCombine mode that would count its trades twice.
+func (s *Spool) Pop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errSpoolClosed
+	}
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	e := s.pending[0]
+	s.pending = s.pending[1:]
+	if len(s.pending) == 0 {
+		s.pending = nil
+		s.end = 0
+		if err := s.f.Truncate(0); err != nil {
+			return fmt.Errorf("truncate spool: %w", err)
+		}
+		return s.f.Sync()
+	}
+	if _, err := s.f.WriteAt([]byte{stateDone}, e.offset); err != nil {
+		return fmt.Errorf("write spool: %w", err)
+	}
+	return s.f.Sync()
+}
+
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+func (s *Spool) Stats() SpoolStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := SpoolStats{Batches: len(s.pending)}
+	for _, e := range s.pending {
+		stats.Candles += e.candles
+	}
+	if len(s.pending) > 0 {
+		stats.Oldest = s.pending[0].spooledAt
+	}
+	return stats
+}
+
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.f.Close()
+}
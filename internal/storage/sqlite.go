@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+)
+
+// sqliteDriver is the database/sql driver registered by the pure Go
+// modernc.org/sqlite, so no cgo is needed.
+const sqliteDriver = "sqlite"
+
+// sqliteMigrations upgrade the schema in order; PRAGMA user_version counts
+// those already applied.
+var sqliteMigrations = []string{`
+CREATE TABLE IF NOT EXISTS candlesticks (
+    exchange          TEXT    NOT NULL,
+    symbol            TEXT    NOT NULL,
+    "interval"        TEXT    NOT NULL,
+    bar_type          TEXT    NOT NULL,
+    open              TEXT    NOT NULL,
+    high              TEXT    NOT NULL,
+    low               TEXT    NOT NULL,
+    close             TEXT    NOT NULL,
+    volume            TEXT    NOT NULL,
+    vwap              TEXT    NOT NULL,
+    quote_volume      TEXT    NOT NULL,
+    trade_count       INTEGER NOT NULL,
+    taker_buy_volume  TEXT    NOT NULL,
+    taker_sell_volume TEXT    NOT NULL,
+    synthetic         INTEGER NOT NULL,
+    start_time        INTEGER NOT NULL, -- Unix nanoseconds
+    end_time          INTEGER NOT NULL,
+    PRIMARY KEY (exchange, symbol, "interval", start_time)
+)`, `
+-- updated_at is in Unix nanoseconds, like start_time.
+ALTER TABLE candlesticks ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE candlesticks ADD COLUMN updated_at INTEGER NOT NULL DEFAULT 0
+`}
+
+const sqliteInsertCandle = `
+        INSERT INTO candlesticks
+        (exchange, symbol, "interval", bar_type, open, high, low, close, volume,
+         vwap, quote_volume, trade_count, taker_buy_volume, taker_sell_volume, synthetic, start_time, end_time,
+         revision, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+const sqliteUpsertCandle = sqliteInsertCandle + `
+        ON CONFLICT (exchange, symbol, "interval", start_time) DO UPDATE SET
+            bar_type = excluded.bar_type, open = excluded.open, high = excluded.high, low = excluded.low,
+            close = excluded.close, volume = excluded.volume, vwap = excluded.vwap,
+            quote_volume = excluded.quote_volume, trade_count = excluded.trade_count,
+            taker_buy_volume = excluded.taker_buy_volume, taker_sell_volume = excluded.taker_sell_volume,
+            synthetic = excluded.synthetic, end_time = excluded.end_time,
+            revision = excluded.revision, updated_at = excluded.updated_at`
+
+const sqliteSelectCandles = `
+        SELECT exchange, symbol, "interval", bar_type, open, high, low, close, volume,
+               vwap, quote_volume, trade_count, taker_buy_volume, taker_sell_volume, synthetic, start_time, end_time,
+               revision, updated_at
+        FROM candlesticks`
+
+// SQLiteStorage is a CandleStore in a single SQLite file, for running
+// without TimescaleDB. It only stores candles.
+type SQLiteStorage struct {
+	db *sqlx.DB
+}
+
+// OpenSQLite opens or creates the database at path; ":memory:" keeps it in
+// memory.
+func OpenSQLite(path string) (*SQLiteStorage, error) {
+	db, err := sqlx.Connect(sqliteDriver, path)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// SQLite allows one writer at a time, and every connection to
+	// ":memory:" would get a database of its own.
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range []string{"PRAGMA busy_timeout = 5000", "PRAGMA journal_mode = WAL"} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("prepare sqlite: %w", err)
+		}
+	}
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStorage{db: db}, nil
+}
+
+func migrateSQLite(db *sqlx.DB) error {
+	var version int
+	if err := db.Get(&version, "PRAGMA user_version"); err != nil {
+		return fmt.Errorf("read sqlite schema version: %w", err)
+	}
+	for ; version < len(sqliteMigrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("migrate sqlite: %w", err)
+		}
+		for _, stmt := range strings.Split(sqliteMigrations[version], ";") {
+			if strings.TrimSpace(stmt) == "" {
+				continue
+			}
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migrate sqlite to version %d: %w", version+1, err)
+			}
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrate sqlite to version %d: %w", version+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migrate sqlite to version %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
+
+type sqliteCandle struct {
+	Exchange        string             `db:"exchange"`
+	Symbol          string             `db:"symbol"`
+	Interval        string             `db:"interval"`
+	BarType         aggregator.BarType `db:"bar_type"`
+	Open            decimal.Decimal    `db:"open"`
+	High            decimal.Decimal    `db:"high"`
+	Low             decimal.Decimal    `db:"low"`
+	Close           decimal.Decimal    `db:"close"`
+	Volume          decimal.Decimal    `db:"volume"`
+	VWAP            decimal.Decimal    `db:"vwap"`
+	QuoteVolume     decimal.Decimal    `db:"quote_volume"`
+	TradeCount      int64              `db:"trade_count"`
+	TakerBuyVolume  decimal.Decimal    `db:"taker_buy_volume"`
+	TakerSellVolume decimal.Decimal    `db:"taker_sell_volume"`
+	Synthetic       bool               `db:"synthetic"`
+	StartTime       int64              `db:"start_time"`
+	EndTime         int64              `db:"end_time"`
+	Revision        int                `db:"revision"`
+	UpdatedAt       int64              `db:"updated_at"`
+}
+
+func (r sqliteCandle) candle() aggregator.Candle {
+	return aggregator.Candle{
+		Exchange:        r.Exchange,
+		Symbol:          r.Symbol,
+		Interval:        r.Interval,
+		BarType:         r.BarType,
+		Open:            r.Open,
+		High:            r.High,
+		Low:             r.Low,
+		Close:           r.Close,
+		Volume:          r.Volume,
+		VWAP:            r.VWAP,
+		QuoteVolume:     r.QuoteVolume,
+		TradeCount:      r.TradeCount,
+		TakerBuyVolume:  r.TakerBuyVolume,
+		TakerSellVolume: r.TakerSellVolume,
+		Synthetic:       r.Synthetic,
+		StartTime:       time.Unix(0, r.StartTime).UTC(),
+		EndTime:         time.Unix(0, r.EndTime).UTC(),
+		Finalized:       true,
+		Revision:        r.Revision,
+		UpdatedAt:       time.Unix(0, r.UpdatedAt).UTC(),
+	}
+}
+
+// sqliteTime stores t as Unix nanoseconds, clamping times outside the range
+// that fits, such as the zero time.
+func sqliteTime(t time.Time) int64 {
+	switch {
+	case t.Before(time.Unix(0, math.MinInt64)):
+		return math.MinInt64
+	case t.After(time.Unix(0, math.MaxInt64)):
+		return math.MaxInt64
+	}
+	return t.UnixNano()
+}
+
+func (s *SQLiteStorage) WriteCandles(ctx context.Context, candles []aggregator.Candle, mode MergeMode) error {
+	return sqliteRejected(s.writeCandles(ctx, candles, mode))
+}
+
+// sqliteRejected wraps errors for data SQLite refuses in ErrRejected.
+func sqliteRejected(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		// The low byte is the primary result code.
+		switch sqliteErr.Code() & 0xff {
+		case sqlite3.SQLITE_CONSTRAINT, sqlite3.SQLITE_MISMATCH, sqlite3.SQLITE_TOOBIG:
+			return fmt.Errorf("%w: %w", ErrRejected, err)
+		}
+	}
+	return err
+}
+
+func (s *SQLiteStorage) writeCandles(ctx context.Context, candles []aggregator.Candle, mode MergeMode) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	find, err := tx.PreparexContext(ctx, sqliteSelectCandles+`
+        WHERE exchange = ? AND symbol = ? AND "interval" = ? AND start_time = ?`)
+	if err != nil {
+		return fmt.Errorf("prepare select: %w", err)
+	}
+	defer find.Close()
+	upsert, err := tx.PrepareContext(ctx, sqliteUpsertCandle)
+	if err != nil {
+		return fmt.Errorf("prepare upsert: %w", err)
+	}
+	defer upsert.Close()
+
+	now := sqliteTime(time.Now())
+	for _, c := range candles {
+		var row sqliteCandle
+		err := find.QueryRowxContext(ctx, c.Exchange, c.Symbol, c.Interval, sqliteTime(c.StartTime)).StructScan(&row)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return fmt.Errorf("read stored candle: %w", err)
+		default:
+			merged, ok := resolve(row.candle(), c, mode)
+			if !ok {
+				continue
+			}
+			c = merged
+		}
+
+		if _, err := upsert.ExecContext(ctx, c.Exchange, c.Symbol, c.Interval, c.BarType,
+			c.Open, c.High, c.Low, c.Close, c.Volume, c.VWAP, c.QuoteVolume, c.TradeCount,
+			c.TakerBuyVolume, c.TakerSellVolume, c.Synthetic,
+			sqliteTime(c.StartTime), sqliteTime(c.EndTime), c.Revision, now); err != nil {
+			return fmt.Errorf("write candle: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) QueryCandles(ctx context.Context, q CandleQuery) ([]aggregator.Candle, error) {
+	query := sqliteSelectCandles + `
+        WHERE exchange = ? AND symbol = ? AND "interval" = ?
+          AND start_time >= ? AND start_time < ? AND start_time > ?
+        ORDER BY start_time
+        LIMIT ?`
+	return s.selectRows(ctx, query, q.Exchange, q.Symbol, q.Interval,
+		sqliteTime(q.From), sqliteTime(q.To), sqliteTime(q.After), q.Limit)
+}
+
+func (s *SQLiteStorage) RecentCandles(ctx context.Context, inst source.Instrument, interval string, n int) ([]aggregator.Candle, error) {
+	query := sqliteSelectCandles + `
+        WHERE exchange = ? AND symbol = ? AND "interval" = ?
+        ORDER BY start_time DESC
+        LIMIT ?`
+	candles, err := s.selectRows(ctx, query, inst.Exchange, inst.Symbol, interval, max(n, 0))
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(candles)
+	return candles, nil
+}
+
+func (s *SQLiteStorage) LatestCandle(ctx context.Context, inst source.Instrument, interval string) (aggregator.Candle, bool, error) {
+	candles, err := s.RecentCandles(ctx, inst, interval, 1)
+	if err != nil || len(candles) == 0 {
+		return aggregator.Candle{}, false, err
+	}
+	return candles[0], true, nil
+}
+
+func (s *SQLiteStorage) selectRows(ctx context.Context, query string, args ...interface{}) ([]aggregator.Candle, error) {
+	var rows []sqliteCandle
+	if err := s.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("query candles: %w", err)
+	}
+	candles := make([]aggregator.Candle, len(rows))
+	for i, r := range rows {
+		candles[i] = r.candle()
+	}
+	return candles, nil
+}
+
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
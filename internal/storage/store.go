@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+)
+
+// CandleStore keeps finalized candles. PostgresStorage, SQLiteStorage and
+// MemoryStorage implement it; the storetest package checks that they agree.
+type CandleStore interface {
+	// WriteCandles stores a batch of finalized candles, combining each
+	// with a stored version of the same bar as mode says. Stored candles
+	// carry their revision and the time they were last written. An error
+	// wrapping ErrRejected means retrying the batch cannot succeed.
+	WriteCandles(ctx context.Context, candles []aggregator.Candle, mode MergeMode) error
+	QueryCandles(ctx context.Context, q CandleQuery) ([]aggregator.Candle, error)
+	// RecentCandles returns up to n of the newest candles of a series,
+	// oldest first.
+	RecentCandles(ctx context.Context, inst source.Instrument, interval string, n int) ([]aggregator.Candle, error)
+	// LatestCandle returns the newest candle of a series, or false if it
+	// has none.
+	LatestCandle(ctx context.Context, inst source.Instrument, interval string) (aggregator.Candle, bool, error)
+	Close() error
+}
+
+// ErrRejected is wrapped by the errors of stores that refuse the candles
+// themselves, such as values out of range or a violated constraint.
+var ErrRejected = errors.New("candles rejected by the store")
+
+// MergeMode decides what happens when a candle is written over a stored
+// version of the same bar.
+type MergeMode string
+
+const (
+	// MergeRevision keeps the version with the higher Revision. A first
+	// version (Revision 0) that differs from the stored bar was rebuilt,
+	// and replaces it as the stored revision plus one. Writing anything
+	// else no newer than the stored version changes nothing, so writes
+	// can safely be repeated.
+	MergeRevision MergeMode = "revision"
+	// MergeCombine treats a first version (Revision 0) written over a
+	// stored bar as more trades of that bar, such as a backfill: high is
+	// the max, low the min, volumes and trade counts are summed, close is
+	// the written one, and the stored revision goes up by one. Corrections
+	// still follow MergeRevision. Repeating a write counts its trades
+	// twice.
+	MergeCombine MergeMode = "combine"
+)
+
+func ParseMergeMode(s string) (MergeMode, error) {
+	switch mode := MergeMode(strings.ToLower(s)); mode {
+	case "":
+		return MergeRevision, nil
+	case MergeRevision, MergeCombine:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown merge mode %q", s)
+}
+
+// resolve returns what to store when c is written over stored, or false if
+// stored stays as it is. Stores that can compute it in SQL need not call it,
+// but must agree with it.
+func resolve(stored aggregator.Candle, c aggregator.Candle, mode MergeMode) (aggregator.Candle, bool) {
+	if mode != MergeCombine || c.Revision > 0 {
+		switch {
+		case c.Revision > stored.Revision:
+			return c, true
+		case c.Revision == 0 && !sameData(stored, c):
+			// A first version that differs from the stored one was
+			// rebuilt, from replayed or backfilled trades after a restart
+			// say, and is published as the next revision of the bar.
+			c.Revision = stored.Revision + 1
+			return c, true
+		}
+		return stored, false
+	}
+	combined := stored
+	merge(&combined, c)
+	combined.Interval, combined.StartTime = stored.Interval, stored.StartTime
+	if c.EndTime.After(stored.EndTime) {
+		combined.EndTime = c.EndTime
+	}
+	combined.Revision = stored.Revision + 1
+	return combined, true
+}
+
+// sameData reports whether a and b hold the same bar, apart from their
+// revisions and update times.
+func sameData(a, b aggregator.Candle) bool {
+	return a.BarType == b.BarType && a.Open.Equal(b.Open) && a.High.Equal(b.High) &&
+		a.Low.Equal(b.Low) && a.Close.Equal(b.Close) && a.Volume.Equal(b.Volume) &&
+		a.VWAP.Equal(b.VWAP) && a.QuoteVolume.Equal(b.QuoteVolume) && a.TradeCount == b.TradeCount &&
+		a.TakerBuyVolume.Equal(b.TakerBuyVolume) && a.TakerSellVolume.Equal(b.TakerSellVolume) &&
+		a.Synthetic == b.Synthetic && a.EndTime.Equal(b.EndTime)
+}
+
+// CandleQuery selects finalized candles for one instrument and interval with
+// start_time in [From, To) and strictly after After, oldest first.
+type CandleQuery struct {
+	Exchange string
+	Symbol   string
+	Interval string
+	From     time.Time
+	To       time.Time
+	After    time.Time
+	Limit    int
+}
+
+func (q CandleQuery) matches(c aggregator.Candle) bool {
+	return c.Exchange == q.Exchange && c.Symbol == q.Symbol && c.Interval == q.Interval &&
+		!c.StartTime.Before(q.From) && c.StartTime.Before(q.To) && c.StartTime.After(q.After)
+}
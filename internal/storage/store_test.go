@@ -0,0 +1,102 @@
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shubie/trading/internal/storage"
+	"github.com/shubie/trading/internal/storage/storetest"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storage.CandleStore {
+		return storage.NewMemoryStorage()
+	})
+}
+
+func TestSQLiteStorage(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storage.CandleStore {
+		s, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "candles.db"))
+		if err != nil {
+			t.Fatalf("OpenSQLite: %v", err)
+		}
+		return s
+	})
+}
+
+// TestPostgresStorage runs against the database in TEST_POSTGRES_DSN, as
+// there is no TimescaleDB in unit tests.
+func TestPostgresStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	for _, path := range postgresPaths {
+		t.Run(path.name, func(t *testing.T) {
+			storetest.Run(t, func(t *testing.T) storage.CandleStore {
+				s, err := storage.NewPostgresStorage(dsn, storage.WithCopyThreshold(path.copyThreshold))
+				if err != nil {
+					t.Fatalf("NewPostgresStorage: %v", err)
+				}
+				return s
+			})
+		})
+	}
+}
+
+// postgresPaths write every batch with multi-row inserts or every batch
+// with COPY.
+var postgresPaths = []struct {
+	name          string
+	copyThreshold int
+}{
+	{"Insert", 0},
+	{"Copy", 1},
+}
+
+// BenchmarkPostgresWriteCandles compares the insert and COPY paths, writing
+// one 1m bar for each of size symbols per operation. Like
+// TestPostgresStorage it needs a database:
+//
+//	TEST_POSTGRES_DSN=... go test ./internal/storage -run '^$' -bench PostgresWriteCandles
+func BenchmarkPostgresWriteCandles(b *testing.B) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		b.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	for _, size := range []int{100, 1000, 5000} {
+		for _, path := range postgresPaths {
+			b.Run(fmt.Sprintf("%s/batch=%d", path.name, size), func(b *testing.B) {
+				s, err := storage.NewPostgresStorage(dsn, storage.WithCopyThreshold(path.copyThreshold))
+				if err != nil {
+					b.Fatalf("NewPostgresStorage: %v", err)
+				}
+				defer s.Close()
+
+				exchange := fmt.Sprintf("bench-%d", time.Now().UnixNano())
+				batch := spoolBatch(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), size)
+				for i := range batch {
+					batch[i].Exchange = exchange
+					batch[i].Symbol = fmt.Sprintf("SYM%dUSDT", i)
+					batch[i].StartTime, batch[i].EndTime = batch[0].StartTime, batch[0].EndTime
+				}
+
+				b.ResetTimer()
+				for n := 0; n < b.N; n++ {
+					if err := s.WriteCandles(context.Background(), batch, storage.MergeRevision); err != nil {
+						b.Fatalf("WriteCandles: %v", err)
+					}
+					for i := range batch {
+						batch[i].StartTime = batch[i].EndTime
+						batch[i].EndTime = batch[i].EndTime.Add(time.Minute)
+					}
+				}
+				b.ReportMetric(float64(b.N*size)/b.Elapsed().Seconds(), "candles/s")
+			})
+		}
+	}
+}
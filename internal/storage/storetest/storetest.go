@@ -0,0 +1,351 @@
+// Package storetest is a conformance suite for storage.CandleStore
+// implementations, so every backend stores and returns candles the same way.
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+	"github.com/shubie/trading/internal/storage"
+)
+
+// Run checks the store returned by open against the CandleStore contract.
+// Each subtest writes under an exchange name of its own, so open may return
+// a store that already holds data.
+func Run(t *testing.T, open func(t *testing.T) storage.CandleStore) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s storage.CandleStore, inst source.Instrument)
+	}{
+		{"RoundTrip", testRoundTrip},
+		{"QueryBounds", testQueryBounds},
+		{"Corrections", testCorrections},
+		{"RestartRebuild", testRestartRebuild},
+		{"Combine", testCombine},
+		{"RecentAndLatest", testRecentAndLatest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := open(t)
+			t.Cleanup(func() { s.Close() })
+			inst := source.Instrument{
+				Exchange: fmt.Sprintf("storetest-%d", time.Now().UnixNano()),
+				Symbol:   "BTCUSDT",
+			}
+			tt.test(t, s, inst)
+		})
+	}
+}
+
+var base = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func bar(inst source.Instrument, interval string, minute int, closePrice string) aggregator.Candle {
+	price := decimal.RequireFromString(closePrice)
+	start := base.Add(time.Duration(minute) * time.Minute)
+	return aggregator.Candle{
+		Exchange:        inst.Exchange,
+		Symbol:          inst.Symbol,
+		Interval:        interval,
+		BarType:         aggregator.BarTime,
+		Open:            price,
+		High:            price,
+		Low:             price,
+		Close:           price,
+		Volume:          decimal.NewFromInt(2),
+		VWAP:            price,
+		QuoteVolume:     price.Mul(decimal.NewFromInt(2)),
+		TradeCount:      3,
+		TakerBuyVolume:  decimal.NewFromInt(1),
+		TakerSellVolume: decimal.NewFromInt(1),
+		StartTime:       start,
+		EndTime:         start.Add(time.Minute),
+		Finalized:       true,
+	}
+}
+
+func write(t *testing.T, s storage.CandleStore, candles ...aggregator.Candle) {
+	t.Helper()
+	writeMode(t, s, storage.MergeRevision, candles...)
+}
+
+func writeMode(t *testing.T, s storage.CandleStore, mode storage.MergeMode, candles ...aggregator.Candle) {
+	t.Helper()
+	if err := s.WriteCandles(context.Background(), candles, mode); err != nil {
+		t.Fatalf("WriteCandles: %v", err)
+	}
+}
+
+func query(t *testing.T, s storage.CandleStore, q storage.CandleQuery) []aggregator.Candle {
+	t.Helper()
+	candles, err := s.QueryCandles(context.Background(), q)
+	if err != nil {
+		t.Fatalf("QueryCandles: %v", err)
+	}
+	return candles
+}
+
+func all(inst source.Instrument, interval string) storage.CandleQuery {
+	return storage.CandleQuery{
+		Exchange: inst.Exchange,
+		Symbol:   inst.Symbol,
+		Interval: interval,
+		From:     base.Add(-time.Hour),
+		To:       base.Add(24 * time.Hour),
+		Limit:    1000,
+	}
+}
+
+// starts returns the minutes after base the candles start at.
+func starts(candles []aggregator.Candle) []int {
+	minutes := make([]int, len(candles))
+	for i, c := range candles {
+		minutes[i] = int(c.StartTime.Sub(base) / time.Minute)
+	}
+	return minutes
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameCandle(got, want aggregator.Candle) bool {
+	return got.Exchange == want.Exchange && got.Symbol == want.Symbol && got.Interval == want.Interval &&
+		got.BarType == want.BarType && got.Open.Equal(want.Open) && got.High.Equal(want.High) &&
+		got.Low.Equal(want.Low) && got.Close.Equal(want.Close) && got.Volume.Equal(want.Volume) &&
+		got.VWAP.Equal(want.VWAP) && got.QuoteVolume.Equal(want.QuoteVolume) &&
+		got.TradeCount == want.TradeCount && got.TakerBuyVolume.Equal(want.TakerBuyVolume) &&
+		got.TakerSellVolume.Equal(want.TakerSellVolume) && got.Synthetic == want.Synthetic &&
+		got.StartTime.Equal(want.StartTime) && got.EndTime.Equal(want.EndTime) && got.Finalized &&
+		got.Revision == want.Revision && !got.UpdatedAt.IsZero()
+}
+
+func testRoundTrip(t *testing.T, s storage.CandleStore, inst source.Instrument) {
+	want := bar(inst, "1m", 0, "0.00001234")
+	want.High = decimal.RequireFromString("0.00001301")
+	want.Low = decimal.RequireFromString("0.00001199")
+	want.VWAP = decimal.RequireFromString("0.000012501234567891")
+	gap := bar(inst, "1m", 1, "0.00001234")
+	gap.Volume, gap.QuoteVolume, gap.TradeCount = decimal.Zero, decimal.Zero, 0
+	gap.TakerBuyVolume, gap.TakerSellVolume = decimal.Zero, decimal.Zero
+	gap.Synthetic = true
+	write(t, s, want, gap)
+
+	got := query(t, s, all(inst, "1m"))
+	if len(got) != 2 {
+		t.Fatalf("Got %d candles, want 2", len(got))
+	}
+	if !sameCandle(got[0], want) {
+		t.Errorf("Candle did not round trip:\n got %+v\nwant %+v", got[0], want)
+	}
+	if !sameCandle(got[1], gap) {
+		t.Errorf("Gap fill did not round trip:\n got %+v\nwant %+v", got[1], gap)
+	}
+}
+
+func testQueryBounds(t *testing.T, s storage.CandleStore, inst source.Instrument) {
+	// Written out of order, with neighbours that must never match.
+	for _, m := range []int{3, 0, 4, 1, 2} {
+		write(t, s, bar(inst, "1m", m, "100"))
+	}
+	other := inst
+	other.Symbol = "ETHUSDT"
+	write(t, s, bar(inst, "5m", 0, "100"), bar(other, "1m", 0, "100"))
+
+	tests := []struct {
+		name string
+		edit func(q *storage.CandleQuery)
+		want []int
+	}{
+		{"all", func(q *storage.CandleQuery) {}, []int{0, 1, 2, 3, 4}},
+		{"from inclusive", func(q *storage.CandleQuery) { q.From = base.Add(2 * time.Minute) }, []int{2, 3, 4}},
+		{"to exclusive", func(q *storage.CandleQuery) { q.To = base.Add(2 * time.Minute) }, []int{0, 1}},
+		{"after exclusive", func(q *storage.CandleQuery) { q.After = base.Add(time.Minute) }, []int{2, 3, 4}},
+		{"limit", func(q *storage.CandleQuery) { q.Limit = 2 }, []int{0, 1}},
+		{"page", func(q *storage.CandleQuery) { q.After, q.Limit = base.Add(time.Minute), 2 }, []int{2, 3}},
+	}
+	for _, tt := range tests {
+		q := all(inst, "1m")
+		tt.edit(&q)
+		if got := starts(query(t, s, q)); !equalInts(got, tt.want) {
+			t.Errorf("%s: got minutes %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	missing := all(inst, "1h")
+	if got := query(t, s, missing); len(got) != 0 {
+		t.Errorf("Query of an empty series returned %d candles", len(got))
+	}
+}
+
+func testCorrections(t *testing.T, s storage.CandleStore, inst source.Instrument) {
+	first := bar(inst, "1m", 0, "100")
+	write(t, s, first, bar(inst, "1m", 1, "101"))
+	stored := query(t, s, all(inst, "1m"))[0]
+
+	// Repeating the first version leaves the stored bar untouched.
+	write(t, s, first)
+	got := query(t, s, all(inst, "1m"))
+	if len(got) != 2 || !sameCandle(got[0], first) || !got[0].UpdatedAt.Equal(stored.UpdatedAt) {
+		t.Fatalf("After rewriting a first version got %+v, want the original unchanged", got[0])
+	}
+
+	// Corrections replace it, and the highest revision in a batch wins.
+	rev1 := bar(inst, "1m", 0, "102")
+	rev1.Revision = 1
+	rev2 := bar(inst, "1m", 0, "103")
+	rev2.Revision = 2
+	rev2.TradeCount = 4
+	time.Sleep(time.Millisecond)
+	write(t, s, rev2, rev1)
+	write(t, s, rev1, rev2) // replaying a batch is harmless
+
+	got = query(t, s, all(inst, "1m"))
+	if len(got) != 2 {
+		t.Fatalf("Got %d candles after corrections, want 2", len(got))
+	}
+	if !sameCandle(got[0], rev2) {
+		t.Errorf("Corrected candle:\n got %+v\nwant %+v", got[0], rev2)
+	}
+	if !got[0].UpdatedAt.After(stored.UpdatedAt) {
+		t.Errorf("Corrected candle updated at %s, not after %s", got[0].UpdatedAt, stored.UpdatedAt)
+	}
+
+	// An older revision arriving late is ignored.
+	write(t, s, rev1)
+	if got := query(t, s, all(inst, "1m")); !sameCandle(got[0], rev2) {
+		t.Errorf("Older revision replaced the newer one: %+v", got[0])
+	}
+}
+
+func testRestartRebuild(t *testing.T, s storage.CandleStore, inst source.Instrument) {
+	// The bar open at shutdown is stored with the trades seen so far, and
+	// another bar had been corrected twice.
+	partial := bar(inst, "1m", 0, "100")
+	corrected := bar(inst, "1m", 1, "101")
+	corrected.Revision = 2
+	write(t, s, partial, corrected)
+	stored := query(t, s, all(inst, "1m"))
+
+	// After a restart both are rebuilt from replayed trades as first
+	// versions, and overwrite the stale bars as their next revisions.
+	rebuilt := bar(inst, "1m", 0, "104")
+	rebuilt.High = decimal.NewFromInt(106)
+	rebuilt.Volume, rebuilt.TradeCount = decimal.NewFromInt(5), 7
+	rebuiltCorrected := bar(inst, "1m", 1, "102")
+	time.Sleep(time.Millisecond)
+	write(t, s, rebuilt, rebuiltCorrected)
+
+	want := []aggregator.Candle{rebuilt, rebuiltCorrected}
+	want[0].Revision, want[1].Revision = 1, 3
+	got := query(t, s, all(inst, "1m"))
+	if len(got) != 2 {
+		t.Fatalf("Got %d candles after the rebuild, want 2", len(got))
+	}
+	for i := range want {
+		if !sameCandle(got[i], want[i]) {
+			t.Errorf("Rebuilt candle %d:\n got %+v\nwant %+v", i, got[i], want[i])
+		}
+		if !got[i].UpdatedAt.After(stored[i].UpdatedAt) {
+			t.Errorf("Rebuilt candle %d updated at %s, not after %s", i, got[i].UpdatedAt, stored[i].UpdatedAt)
+		}
+	}
+
+	// Writing the rebuilt bars again changes nothing.
+	write(t, s, rebuilt, rebuiltCorrected)
+	again := query(t, s, all(inst, "1m"))
+	for i := range want {
+		if !sameCandle(again[i], want[i]) || !again[i].UpdatedAt.Equal(got[i].UpdatedAt) {
+			t.Errorf("Rewriting rebuilt candle %d changed it to %+v", i, again[i])
+		}
+	}
+}
+
+func testCombine(t *testing.T, s storage.CandleStore, inst source.Instrument) {
+	stored := bar(inst, "1m", 0, "100")
+	stored.High = decimal.NewFromInt(105)
+	stored.Low = decimal.NewFromInt(95)
+	writeMode(t, s, storage.MergeCombine, stored)
+
+	// Two more parts of the bar in one batch: the second has the extremes.
+	part1 := bar(inst, "1m", 0, "101")
+	part2 := bar(inst, "1m", 0, "108")
+	part2.High = decimal.NewFromInt(110)
+	part2.Low = decimal.NewFromInt(90)
+	writeMode(t, s, storage.MergeCombine, part1, part2)
+
+	want := stored
+	want.High, want.Low, want.Close = part2.High, part2.Low, part2.Close
+	want.Volume = decimal.NewFromInt(6)
+	want.QuoteVolume = stored.QuoteVolume.Add(part1.QuoteVolume).Add(part2.QuoteVolume)
+	want.VWAP = want.QuoteVolume.DivRound(want.Volume, 18)
+	want.TradeCount = 9
+	want.TakerBuyVolume, want.TakerSellVolume = decimal.NewFromInt(3), decimal.NewFromInt(3)
+	want.Revision = 2
+
+	got := query(t, s, all(inst, "1m"))
+	if len(got) != 1 || !sameCandle(got[0], want) {
+		t.Fatalf("Combined candle:\n got %+v\nwant %+v", got, want)
+	}
+
+	// Corrections still replace the bar if they are newer.
+	correction := bar(inst, "1m", 0, "104")
+	correction.Revision = 3
+	writeMode(t, s, storage.MergeCombine, correction)
+	if got := query(t, s, all(inst, "1m")); !sameCandle(got[0], correction) {
+		t.Errorf("Correction in combine mode:\n got %+v\nwant %+v", got[0], correction)
+	}
+	stale := bar(inst, "1m", 0, "1")
+	stale.Revision = 1
+	writeMode(t, s, storage.MergeCombine, stale)
+	if got := query(t, s, all(inst, "1m")); !sameCandle(got[0], correction) {
+		t.Errorf("Stale correction in combine mode replaced the bar: %+v", got[0])
+	}
+}
+
+func testRecentAndLatest(t *testing.T, s storage.CandleStore, inst source.Instrument) {
+	ctx := context.Background()
+	if _, ok, err := s.LatestCandle(ctx, inst, "1m"); err != nil || ok {
+		t.Fatalf("LatestCandle of an empty series: ok %v, err %v", ok, err)
+	}
+	for _, m := range []int{2, 0, 4, 1, 3} {
+		write(t, s, bar(inst, "1m", m, "100"))
+	}
+	write(t, s, bar(inst, "5m", 10, "100"))
+
+	recent, err := s.RecentCandles(ctx, inst, "1m", 3)
+	if err != nil {
+		t.Fatalf("RecentCandles: %v", err)
+	}
+	if got := starts(recent); !equalInts(got, []int{2, 3, 4}) {
+		t.Errorf("RecentCandles(3) = minutes %v, want [2 3 4]", got)
+	}
+	recent, err = s.RecentCandles(ctx, inst, "1m", 10)
+	if err != nil {
+		t.Fatalf("RecentCandles: %v", err)
+	}
+	if got := starts(recent); !equalInts(got, []int{0, 1, 2, 3, 4}) {
+		t.Errorf("RecentCandles(10) = minutes %v, want [0 1 2 3 4]", got)
+	}
+
+	latest, ok, err := s.LatestCandle(ctx, inst, "1m")
+	if err != nil || !ok {
+		t.Fatalf("LatestCandle: ok %v, err %v", ok, err)
+	}
+	if want := bar(inst, "1m", 4, "100"); !sameCandle(latest, want) {
+		t.Errorf("LatestCandle:\n got %+v\nwant %+v", latest, want)
+	}
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/backoff"
+)
+
+// Writer batches finalized candles into a CandleStore.
+type Writer struct {
+	store         CandleStore
+	mode          MergeMode
+	batchSize     int
+	flushInterval time.Duration
+	writeTimeout  time.Duration
+
+	// spool holds batches that failed to write until they can be
+	// replayed. writeMu serializes writes with the replay, so bars reach
+	// the store in the order they were finalized.
+	spool    *Spool
+	spooled  chan struct{}
+	retryMin time.Duration
+	retryMax time.Duration
+	writeMu  sync.Mutex
+}
+
+type WriterOption func(*Writer)
+
+// WithSpool keeps candle batches that fail to write in sp and replays them
+// once the store is back, instead of dropping them.
+func WithSpool(sp *Spool) WriterOption {
+	return func(w *Writer) {
+		w.spool = sp
+	}
+}
+
+// WithRetryBackoff sets the bounds of the delay between spool replay
+// attempts while the store is failing.
+func WithRetryBackoff(min, max time.Duration) WriterOption {
+	return func(w *Writer) {
+		if min > 0 {
+			w.retryMin = min
+		}
+		if max > 0 {
+			w.retryMax = max
+		}
+	}
+}
+
+// WithBatchSize writes candles once n have been collected; the default is
+// 100. The persisters of derived series, indicator values and order book
+// snapshots take it too.
+func WithBatchSize(n int) WriterOption {
+	return func(w *Writer) {
+		if n > 0 {
+			w.batchSize = n
+		}
+	}
+}
+
+// WithFlushInterval writes whatever candles have been collected every d,
+// however few; the default is a second. The other persisters take it too.
+func WithFlushInterval(d time.Duration) WriterOption {
+	return func(w *Writer) {
+		if d > 0 {
+			w.flushInterval = d
+		}
+	}
+}
+
+// WithWriteTimeout bounds each write to the store; the default is ten
+// seconds. A batch whose write times out is spooled like any failed one,
+// so a hung database cannot hold up shutdown.
+func WithWriteTimeout(d time.Duration) WriterOption {
+	return func(w *Writer) {
+		if d > 0 {
+			w.writeTimeout = d
+		}
+	}
+}
+
+// WithMergeMode sets how written candles combine with stored versions of
+// the same bar; the default is MergeRevision.
+func WithMergeMode(mode MergeMode) WriterOption {
+	return func(w *Writer) {
+		if mode != "" {
+			w.mode = mode
+		}
+	}
+}
+
+func NewWriter(store CandleStore, opts ...WriterOption) *Writer {
+	w := &Writer{
+		store:         store,
+		mode:          MergeRevision,
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+		writeTimeout:  defaultWriteTimeout,
+		spooled:       make(chan struct{}, 1),
+		retryMin:      backoff.DefaultMin,
+		retryMax:      backoff.DefaultMax,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// StartPersisting writes candles from candleChan in batches until the
+// channel is closed, and replays the spool until ctx is done. The returned
+// channel is closed once the last batch is written and the replay has
+// stopped, so the store can be closed.
+func (w *Writer) StartPersisting(ctx context.Context, candleChan <-chan aggregator.Candle) <-chan struct{} {
+	var replay sync.WaitGroup
+	if w.spool != nil {
+		replay.Add(1)
+		go func() {
+			defer replay.Done()
+			w.replaySpool(ctx)
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		log.Println("Persistence worker started")
+		// Candles keep coming after ctx is done, as the aggregator
+		// flushes its open bars, so only the channel closing stops this.
+		persistBatches(candleChan, w.batchSize, w.flushInterval, w.persistBatch)
+		replay.Wait()
+		if w.spool != nil {
+			w.spool.Close()
+		}
+		log.Println("Persistence worker stopped")
+	}()
+	return done
+}
+
+func (w *Writer) persistBatch(candles []aggregator.Candle) {
+	if len(candles) == 0 {
+		return
+	}
+
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	// Writing past spooled batches could let an older version of a bar
+	// overwrite a newer one when they are replayed.
+	if w.spool != nil && w.spool.Len() > 0 {
+		w.spoolBatch(candles)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), w.writeTimeout)
+	defer cancel()
+	if err := w.store.WriteCandles(ctx, candles, w.mode); err != nil {
+		log.Printf("Persistence error: %v", err)
+		if w.spool != nil && !permanent(err) {
+			w.spoolBatch(candles)
+		}
+		return
+	}
+	log.Printf("Persisted %d candles", len(candles))
+}
+
+func (w *Writer) spoolBatch(candles []aggregator.Candle) {
+	if err := w.spool.Append(candles); err != nil {
+		log.Printf("Failed to spool %d candles, dropping them: %v", len(candles), err)
+		return
+	}
+	select {
+	case w.spooled <- struct{}{}:
+	default:
+	}
+}
+
+// replaySpool writes spooled batches back to the store oldest first,
+// backing off while it keeps failing, until ctx is done.
+func (w *Writer) replaySpool(ctx context.Context) {
+	b := backoff.New(w.retryMin, w.retryMax)
+	for ctx.Err() == nil {
+		replayed, err := w.replayNext(ctx)
+		switch {
+		case err != nil:
+			log.Printf("Spool replay failed: %v", err)
+			if !b.Wait(ctx) {
+				return
+			}
+			continue
+		case replayed:
+			b.Reset()
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.spooled:
+		}
+	}
+}
+
+// replayNext writes the oldest spooled batch, reporting whether there was
+// one.
+func (w *Writer) replayNext(ctx context.Context) (bool, error) {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	candles, ok, err := w.spool.Peek()
+	if err != nil || !ok {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.writeTimeout)
+	defer cancel()
+	if err := w.store.WriteCandles(ctx, candles, w.mode); err != nil {
+		if !permanent(err) {
+			return false, err
+		}
+		log.Printf("Dropping %d spooled candles the store rejects: %v", len(candles), err)
+	} else {
+		log.Printf("Replayed %d spooled candles", len(candles))
+	}
+	return true, w.spool.Pop()
+}
+
+// permanent reports whether the store refused the data itself, which
+// retrying cannot fix.
+func permanent(err error) bool {
+	return errors.Is(err, ErrRejected)
+}
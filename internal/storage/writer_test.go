@@ -0,0 +1,205 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shubie/trading/internal/aggregator"
+	"github.com/shubie/trading/internal/source"
+	"github.com/shubie/trading/internal/storage"
+)
+
+// flakyStore fails every write while down is set.
+type flakyStore struct {
+	*storage.MemoryStorage
+	down atomic.Bool
+}
+
+func (f *flakyStore) WriteCandles(ctx context.Context, candles []aggregator.Candle, mode storage.MergeMode) error {
+	if f.down.Load() {
+		return errors.New("connection refused")
+	}
+	return f.MemoryStorage.WriteCandles(ctx, candles, mode)
+}
+
+// rejectingStore refuses every write as invalid data.
+type rejectingStore struct {
+	*storage.MemoryStorage
+	writes atomic.Int32
+}
+
+func (r *rejectingStore) WriteCandles(ctx context.Context, candles []aggregator.Candle, mode storage.MergeMode) error {
+	r.writes.Add(1)
+	return fmt.Errorf("write candles: %w", storage.ErrRejected)
+}
+
+// hangingStore never finishes a write until its context is done.
+type hangingStore struct {
+	*storage.MemoryStorage
+}
+
+func (h *hangingStore) WriteCandles(ctx context.Context, candles []aggregator.Candle, mode storage.MergeMode) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestWriter_SpoolsWritesThatTimeOut(t *testing.T) {
+	store := &hangingStore{MemoryStorage: storage.NewMemoryStorage()}
+	sp, err := storage.OpenSpool(filepath.Join(t.TempDir(), "candles.spool"))
+	if err != nil {
+		t.Fatalf("OpenSpool: %v", err)
+	}
+	w := storage.NewWriter(store, storage.WithSpool(sp), storage.WithWriteTimeout(50*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	candleChan := make(chan aggregator.Candle)
+	done := w.StartPersisting(ctx, candleChan)
+
+	// The final batch, flushed on shutdown, must not wait on the store.
+	cancel()
+	for _, c := range spoolBatch(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 3) {
+		candleChan <- c
+	}
+	close(candleChan)
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Writer did not stop while the store hung")
+	}
+	if st := sp.Stats(); st.Batches != 1 || st.Candles != 3 {
+		t.Errorf("Spooled %d batches of %d candles, want 1 of 3", st.Batches, st.Candles)
+	}
+}
+
+func TestWriter_DropsRejectedBatches(t *testing.T) {
+	store := &rejectingStore{MemoryStorage: storage.NewMemoryStorage()}
+	sp, err := storage.OpenSpool(filepath.Join(t.TempDir(), "candles.spool"))
+	if err != nil {
+		t.Fatalf("OpenSpool: %v", err)
+	}
+	w := storage.NewWriter(store, storage.WithSpool(sp))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	candleChan := make(chan aggregator.Candle)
+	done := w.StartPersisting(ctx, candleChan)
+	candleChan <- spoolBatch(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1)[0]
+	// The spool replay only stops with the context.
+	cancel()
+	close(candleChan)
+	<-done
+
+	if store.writes.Load() != 1 {
+		t.Errorf("Store saw %d writes, want 1", store.writes.Load())
+	}
+	if n := sp.Len(); n != 0 {
+		t.Errorf("Spooled %d rejected batches, want none", n)
+	}
+}
+
+func TestWriter_SpoolsWhileStoreIsDown(t *testing.T) {
+	store := &flakyStore{MemoryStorage: storage.NewMemoryStorage()}
+	store.down.Store(true)
+	sp, err := storage.OpenSpool(filepath.Join(t.TempDir(), "candles.spool"))
+	if err != nil {
+		t.Fatalf("OpenSpool: %v", err)
+	}
+	w := storage.NewWriter(store, storage.WithSpool(sp), storage.WithRetryBackoff(10*time.Millisecond, 20*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	candleChan := make(chan aggregator.Candle)
+	w.StartPersisting(ctx, candleChan)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := spoolBatch(start, 1)[0]
+	correction := first
+	correction.Close = first.Close.Add(first.Close)
+	correction.Revision = 1
+	candleChan <- first
+	candleChan <- correction
+
+	deadline := time.Now().Add(3 * time.Second)
+	for sp.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sp.Stats().Candles != 2 {
+		t.Fatalf("Spooled %d candles while the store was down, want 2", sp.Stats().Candles)
+	}
+
+	store.down.Store(false)
+	for sp.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sp.Len() != 0 {
+		t.Fatal("Spool was not replayed once the store came back")
+	}
+
+	latest, ok, err := store.LatestCandle(ctx, source.Instrument{Exchange: "binance", Symbol: "BTCUSDT"}, "1m")
+	if err != nil || !ok {
+		t.Fatalf("LatestCandle: ok %v, err %v", ok, err)
+	}
+	if !latest.Close.Equal(correction.Close) {
+		t.Errorf("Stored close %s, want the corrected %s", latest.Close, correction.Close)
+	}
+}
+
+func TestWriter_FlushesFullBatches(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	w := storage.NewWriter(store, storage.WithBatchSize(3), storage.WithFlushInterval(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	candleChan := make(chan aggregator.Candle)
+	w.StartPersisting(ctx, candleChan)
+
+	inst := source.Instrument{Exchange: "binance", Symbol: "BTCUSDT"}
+	batch := spoolBatch(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 5)
+	for _, c := range batch {
+		candleChan <- c
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	var stored []aggregator.Candle
+	for len(stored) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		stored, _ = store.RecentCandles(ctx, inst, "1m", 10)
+	}
+	// The last two wait for the flush interval.
+	time.Sleep(50 * time.Millisecond)
+	stored, _ = store.RecentCandles(ctx, inst, "1m", 10)
+	if len(stored) != 3 {
+		t.Fatalf("Stored %d candles before the flush interval, want the first batch of 3", len(stored))
+	}
+}
+
+func TestWriter_DrainsAfterCancel(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	w := storage.NewWriter(store, storage.WithFlushInterval(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	candleChan := make(chan aggregator.Candle)
+	done := w.StartPersisting(ctx, candleChan)
+
+	// The aggregator flushes its open bars after the context is done.
+	cancel()
+	for _, c := range spoolBatch(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 3) {
+		candleChan <- c
+	}
+	close(candleChan)
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Writer did not stop after its channel was closed")
+	}
+	stored, _ := store.RecentCandles(context.Background(), source.Instrument{Exchange: "binance", Symbol: "BTCUSDT"}, "1m", 10)
+	if len(stored) != 3 {
+		t.Errorf("Stored %d candles sent after cancel, want 3", len(stored))
+	}
+}